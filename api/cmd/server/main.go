@@ -19,11 +19,17 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
 	"github.com/kyash99252/Medical-Portal/internal/document"
 	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/notify"
 	"github.com/kyash99252/Medical-Portal/internal/patient"
+	"github.com/kyash99252/Medical-Portal/internal/portal"
 	"github.com/kyash99252/Medical-Portal/internal/prescription"
 	"github.com/kyash99252/Medical-Portal/pkg/config"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
+	"github.com/kyash99252/Medical-Portal/pkg/oidc"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 )
@@ -57,6 +63,8 @@ func main() {
 	// Run Migrations
 	runMigrations(cfg.DatabaseURL)
 
+	jwtKeys := loadJWTKeys(cfg)
+
 	// Initialize DB connection
 	db, err := sqlx.Connect("postgres", cfg.DatabaseURL)
 	if err != nil {
@@ -72,6 +80,10 @@ func main() {
 	// Set Gin mode
 	gin.SetMode(cfg.GinMode)
 
+	// Background jobs stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
 	// Initialize Router
 	router := gin.Default()
 
@@ -79,7 +91,7 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -96,57 +108,276 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Public keys for verifying our access tokens
+	router.GET("/.well-known/jwks.json", auth.JWKSHandler(jwtKeys))
+
 	// Swagger Docs
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// API v1 Group
 	v1 := router.Group("/api/v1")
 	{
+		mrnFormat, err := patient.ParseMRNFormat(cfg.MRNPattern)
+		if err != nil {
+			log.Fatalf("Invalid MRN_PATTERN: %v", err)
+		}
+
 		// Repositories
 		userRepo := auth.NewPostgresRepository(db)
-		patientRepo := patient.NewPostgresRepository(db)
+		tokenRepo := auth.NewPostgresTokenRepository(db)
+		mfaRepo := auth.NewPostgresMFARepository(db)
+		attemptRepo := auth.NewPostgresAttemptRepository(db)
+		apiKeyRepo := auth.NewPostgresAPIKeyRepository(db)
+		ssoRepo := auth.NewPostgresSSORepository(db)
+		permissionRepo := authz.NewPostgresRepository(db)
+		patientRepo := patient.NewPostgresRepository(db, mrnFormat)
 		docRepo := document.NewPostgresRepository(db)
 		prescriptionRepo := prescription.NewPostgresRepository(db)
+		careTeamRepo := careteam.NewPostgresRepository(db)
+		emergencyRepo := careteam.NewPostgresEmergencyRepository(db)
+		portalRepo := portal.NewPostgresRepository(db)
+		mergeRepo := patient.NewPostgresMergeRepository(db)
+		contactRepo := patient.NewPostgresContactRepository(db)
+		identifierRepo := patient.NewPostgresIdentifierRepository(db)
+		versionRepo := patient.NewPostgresVersionRepository(db)
+		importRepo := patient.NewPostgresImportRepository(db, mrnFormat)
+		exportRepo := patient.NewPostgresExportRepository(db)
 
 		// Services
-		authSvc := auth.NewService(userRepo, cfg.JWTSecretKey)
-		patientSvc := patient.NewService(patientRepo)
-		docSvc := document.NewService(docRepo, cld)
-		prescriptionSvc := prescription.NewService(prescriptionRepo)
+		lockoutPolicy := auth.DefaultLockoutPolicy()
+		lockoutPolicy.LockAfter = cfg.LoginLockAfter
+		lockoutPolicy.LockDuration = cfg.LoginLockDuration
+		lockoutPolicy.IPLockAfter = cfg.LoginIPLockAfter
+		authSvc := auth.NewThrottledService(auth.NewService(userRepo, tokenRepo, auth.Options{
+			Keys:            jwtKeys,
+			Issuer:          cfg.JWTIssuer,
+			AccessTokenTTL:  cfg.AccessTokenTTL,
+			RefreshTokenTTL: cfg.RefreshTokenTTL,
+		}), attemptRepo, lockoutPolicy)
+		lockoutSvc := auth.NewLockoutService(userRepo, attemptRepo)
+		permissionSvc := authz.NewService(permissionRepo)
+		apiKeySvc := auth.NewAPIKeyService(apiKeyRepo, permissionSvc)
+		userSvc := auth.NewUserService(userRepo, authSvc)
+		passwordSvc := auth.NewPasswordService(userRepo, tokenRepo, authSvc, notify.NewLogSender(), auth.PasswordOptions{
+			ResetURL: cfg.PasswordResetURL,
+			ResetTTL: cfg.PasswordResetTTL,
+		})
+		mfaSvc := auth.NewThrottledMFAService(auth.NewMFAService(userRepo, mfaRepo, tokenRepo, authSvc, auth.MFAOptions{Issuer: cfg.MFAIssuer}), userRepo, tokenRepo, attemptRepo, lockoutPolicy)
+		careTeamSvc := careteam.NewService(careTeamRepo, emergencyRepo)
+		emergencySvc := careteam.NewEmergencyService(emergencyRepo, cfg.EmergencyAccessTTL)
+		portalSvc := portal.NewService(portalRepo, patientRepo, prescriptionRepo, docRepo, authSvc, notify.NewLogSender(), portal.Options{
+			InviteURL: cfg.PortalInviteURL,
+			InviteTTL: cfg.PortalInviteTTL,
+		})
+		patientSvc := patient.NewService(patientRepo, careTeamSvc)
+		mergeSvc := patient.NewMergeService(mergeRepo, careTeamSvc)
+		contactSvc := patient.NewContactService(contactRepo, careTeamSvc)
+		identifierSvc := patient.NewIdentifierService(identifierRepo, patientRepo, careTeamSvc)
+		versionSvc := patient.NewVersionService(versionRepo, careTeamSvc)
+		importSvc := patient.NewImportService(importRepo, patientRepo)
+		exportSvc := patient.NewExportService(exportRepo, patientRepo, cfg.PatientExportTTL)
+		retentionSvc := patient.NewRetentionService(patientRepo, document.NewCloudinaryFileStore(cld), cfg.PatientRetention)
+		docSvc := document.NewService(docRepo, cld, careTeamSvc)
+		prescriptionSvc := prescription.NewService(prescriptionRepo, careTeamSvc)
 
 		// Handlers
 		authHandler := auth.NewHandler(authSvc)
+		userHandler := auth.NewUserHandler(userSvc)
+		passwordHandler := auth.NewPasswordHandler(passwordSvc)
+		mfaHandler := auth.NewMFAHandler(mfaSvc)
+		lockoutHandler := auth.NewLockoutHandler(lockoutSvc)
+		permissionHandler := authz.NewHandler(permissionSvc)
+		apiKeyHandler := auth.NewAPIKeyHandler(apiKeySvc)
+		careTeamHandler := careteam.NewHandler(careTeamSvc)
+		emergencyHandler := careteam.NewEmergencyHandler(emergencySvc)
+		portalHandler := portal.NewHandler(portalSvc)
 		patientHandler := patient.NewHandler(patientSvc)
+		mergeHandler := patient.NewMergeHandler(mergeSvc)
+		contactHandler := patient.NewContactHandler(contactSvc)
+		identifierHandler := patient.NewIdentifierHandler(identifierSvc)
+		versionHandler := patient.NewVersionHandler(versionSvc)
+		importHandler := patient.NewImportHandler(importSvc)
+		exportHandler := patient.NewExportHandler(exportSvc)
 		docHandler := document.NewHandler(docSvc)
 		prescriptionHandler := prescription.NewHandler(prescriptionSvc)
 
+		// Background jobs
+		go retentionSvc.Run(jobsCtx, cfg.PatientPurgeInterval)
+		go exportSvc.Run(jobsCtx, cfg.PatientExportPollInterval)
+
 		// Routes
 		v1.POST("/login", authHandler.Login)
+		v1.POST("/login/mfa", mfaHandler.CompleteLogin)
+		v1.POST("/token/refresh", authHandler.Refresh)
+		v1.POST("/password/reset", passwordHandler.ResetPassword)
+		v1.POST("/portal/activate", portalHandler.Activate)
+
+		// Single sign-on through the hospital identity provider
+		if cfg.OIDCIssuerURL != "" {
+			ssoHandler := auth.NewSSOHandler(newSSOService(cfg, userRepo, ssoRepo, authSvc))
+			v1.GET("/sso/login", ssoHandler.StartLogin)
+			v1.POST("/sso/callback", ssoHandler.Callback)
+		}
 
+		// Staff access tokens and API keys are both accepted from here on
 		authRoutes := v1.Group("/")
-		authRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		authRoutes.Use(middleware.AuthMiddleware(auth.NewCredentialVerifier(authSvc, apiKeySvc)), auth.APIKeyAuditMiddleware(apiKeySvc))
+		{
+			authRoutes.POST("/logout", middleware.UserOnlyMiddleware(), authHandler.Logout)
+			authRoutes.POST("/logout/all", middleware.UserOnlyMiddleware(), authHandler.LogoutAll)
+			authRoutes.PUT("/me/password", middleware.UserOnlyMiddleware(), passwordHandler.ChangePassword)
+		}
+
+		// Everything below is unavailable until a pending forced password change is done
+		protected := authRoutes.Group("/")
+		protected.Use(middleware.PasswordChangeMiddleware(), middleware.LoadPermissions(permissionSvc))
 		{
+			// Own MFA settings
+			me := protected.Group("/me")
+			me.Use(middleware.UserOnlyMiddleware())
+			{
+				me.GET("/mfa", mfaHandler.GetStatus)
+				me.POST("/mfa/enroll", mfaHandler.Enroll)
+				me.POST("/mfa/activate", mfaHandler.Activate)
+				me.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+			}
+
+			// User management routes
+			u := protected.Group("/users")
+			u.Use(middleware.RequirePermission(authz.UserManage))
+			{
+				u.POST("", userHandler.CreateUser)
+				u.GET("", userHandler.ListUsers)
+				u.GET("/:id", userHandler.GetUser)
+				u.PUT("/:id", userHandler.UpdateUser)
+				u.PATCH("/:id/role", userHandler.UpdateUserRole)
+				u.POST("/:id/deactivate", userHandler.DeactivateUser)
+				u.POST("/:id/activate", userHandler.ActivateUser)
+				u.POST("/:id/password-reset", passwordHandler.IssuePasswordReset)
+				u.DELETE("/:id/mfa", mfaHandler.ResetMFA)
+				u.POST("/:id/unlock", lockoutHandler.UnlockUser)
+			}
+
+			// Login audit and lockout routes
+			protected.GET("/login-attempts", middleware.RequirePermission(authz.SecurityAudit), lockoutHandler.ListAttempts)
+			protected.GET("/login-lockouts", middleware.RequirePermission(authz.SecurityAudit), lockoutHandler.ListLockouts)
+			protected.DELETE("/login-lockouts/ip", middleware.RequirePermission(authz.SecurityAudit), lockoutHandler.UnlockIP)
+
+			// Role permission routes
+			protected.GET("/permissions", middleware.RequirePermission(authz.RoleManage), permissionHandler.ListPermissions)
+			protected.GET("/roles", middleware.RequirePermission(authz.RoleManage), permissionHandler.ListRoles)
+			protected.PUT("/roles/:role/permissions", middleware.RequirePermission(authz.RoleManage), permissionHandler.UpdateRolePermissions)
+
+			// API key routes
+			k := protected.Group("/api-keys")
+			k.Use(middleware.RequirePermission(authz.APIKeyManage))
+			{
+				k.POST("", apiKeyHandler.CreateAPIKey)
+				k.GET("", apiKeyHandler.ListAPIKeys)
+				k.GET("/:id", apiKeyHandler.GetAPIKey)
+				k.POST("/:id/revoke", apiKeyHandler.RevokeAPIKey)
+				k.GET("/:id/requests", apiKeyHandler.ListAPIKeyRequests)
+			}
+
 			// Patient routes
-			p := authRoutes.Group("/patients")
+			p := protected.Group("/patients")
 			{
-				p.POST("", middleware.RoleMiddleware("receptionist"), patientHandler.CreatePatient)
-				p.GET("", middleware.RoleMiddleware("receptionist", "doctor"), patientHandler.ListPatients)
-				p.GET("/:id", middleware.RoleMiddleware("receptionist", "doctor"), patientHandler.GetPatient)
-				p.PUT("/:id", middleware.RoleMiddleware("receptionist", "doctor"), patientHandler.UpdatePatient)
-				p.PATCH("/:id/medical", middleware.RoleMiddleware("receptionist", "doctor"), patientHandler.UpdatePatientMedical)
-				p.DELETE("/:id", middleware.RoleMiddleware("receptionist"), patientHandler.DeletePatient)
+				p.POST("", middleware.RequirePermission(authz.PatientCreate), patientHandler.CreatePatient)
+				p.GET("", middleware.RequirePermission(authz.PatientRead), patientHandler.ListPatients)
+				p.GET("/search", middleware.RequirePermission(authz.PatientRead), patientHandler.SearchPatients)
+				p.GET("/by-mrn/:mrn", middleware.RequirePermission(authz.PatientRead), patientHandler.GetPatientByMRN)
+				p.GET("/by-identifier", middleware.RequirePermission(authz.PatientRead), identifierHandler.FindPatient)
+				p.GET("/trash", middleware.RequirePermission(authz.PatientRestore), patientHandler.ListDeletedPatients)
+				p.POST("/import", middleware.RequirePermission(authz.PatientImport), importHandler.ImportPatients)
+				p.GET("/export", middleware.RequirePermission(authz.PatientExport), exportHandler.ExportPatients)
+				p.GET("/:id", middleware.RequirePermission(authz.PatientRead), patientHandler.GetPatient)
+				p.PUT("/:id", middleware.RequirePermission(authz.PatientUpdate), patientHandler.UpdatePatient)
+				p.PATCH("/:id", middleware.RequirePermission(authz.PatientRead), patientHandler.PatchPatient)
+				p.PATCH("/:id/medical", middleware.RequirePermission(authz.PatientMedicalWrite), patientHandler.UpdatePatientMedical)
+				p.DELETE("/:id", middleware.RequirePermission(authz.PatientDelete), patientHandler.DeletePatient)
+				p.POST("/:id/restore", middleware.RequirePermission(authz.PatientRestore), patientHandler.RestorePatient)
+				p.POST("/:id/merge", middleware.RequirePermission(authz.PatientMerge), mergeHandler.MergePatients)
+
+				// History
+				p.GET("/:id/versions", middleware.RequirePermission(authz.PatientRead), versionHandler.ListVersions)
+				p.GET("/:id/versions/diff", middleware.RequirePermission(authz.PatientRead), versionHandler.DiffVersions)
+				p.GET("/:id/as-of", middleware.RequirePermission(authz.PatientRead), versionHandler.GetPatientAsOf)
+
+				// Emergency contacts
+				p.GET("/:id/emergency-contacts", middleware.RequirePermission(authz.PatientRead), contactHandler.ListContacts)
+				p.POST("/:id/emergency-contacts", middleware.RequirePermission(authz.PatientUpdate), contactHandler.AddContact)
+				p.PUT("/:id/emergency-contacts/:contact_id", middleware.RequirePermission(authz.PatientUpdate), contactHandler.UpdateContact)
+				p.DELETE("/:id/emergency-contacts/:contact_id", middleware.RequirePermission(authz.PatientUpdate), contactHandler.DeleteContact)
+
+				// External identifiers
+				p.GET("/:id/identifiers", middleware.RequirePermission(authz.PatientRead), identifierHandler.ListIdentifiers)
+				p.POST("/:id/identifiers", middleware.RequirePermission(authz.PatientUpdate), identifierHandler.AddIdentifier)
+				p.DELETE("/:id/identifiers/:identifier_id", middleware.RequirePermission(authz.PatientUpdate), identifierHandler.DeleteIdentifier)
 
 				// Prescription
-				p.POST("/:id/prescriptions", middleware.RoleMiddleware("doctor"), prescriptionHandler.CreatePrescription)
-				p.GET("/:id/prescriptions", middleware.RoleMiddleware("receptionist", "doctor"), prescriptionHandler.GetPatientPrescriptions)
+				p.POST("/:id/prescriptions", middleware.RequirePermission(authz.PrescriptionCreate), prescriptionHandler.CreatePrescription)
+				p.GET("/:id/prescriptions", middleware.RequirePermission(authz.PrescriptionRead), prescriptionHandler.GetPatientPrescriptions)
 
 				// Documents
-				p.POST("/:id/documents", middleware.RoleMiddleware("receptionist", "doctor"), docHandler.UploadDocument)
-				p.GET("/:id/documents", middleware.RoleMiddleware("receptionist", "doctor"), docHandler.GetPatientDocuments)
+				p.POST("/:id/documents", middleware.RequirePermission(authz.DocumentUpload), docHandler.UploadDocument)
+				p.GET("/:id/documents", middleware.RequirePermission(authz.DocumentRead), docHandler.GetPatientDocuments)
+
+				// Care team
+				p.GET("/:id/care-team", middleware.RequirePermission(authz.PatientRead), careTeamHandler.ListMembers)
+				p.POST("/:id/care-team", middleware.RequirePermission(authz.CareTeamManage), careTeamHandler.AddMember)
+				p.DELETE("/:id/care-team/:doctor_id", middleware.RequirePermission(authz.CareTeamManage), careTeamHandler.RemoveMember)
+
+				// Patient portal
+				p.POST("/:id/portal-invitation", middleware.RequirePermission(authz.PortalInvite), portalHandler.Invite)
+
+				// Break the glass
+				p.POST("/:id/emergency-access", middleware.UserOnlyMiddleware(), middleware.RequirePermission(authz.EmergencyAccess), emergencyHandler.BreakGlass)
+			}
+
+			// Patient portal, scoped to the caller's own record
+			pp := protected.Group("/portal/me")
+			pp.Use(middleware.RequirePermission(authz.PortalSelf))
+			{
+				pp.GET("", portalHandler.GetProfile)
+				pp.GET("/prescriptions", portalHandler.ListPrescriptions)
+				pp.GET("/documents", portalHandler.ListDocuments)
+			}
+
+			// Patient merges
+			pm := protected.Group("/patient-merges")
+			pm.Use(middleware.RequirePermission(authz.PatientMerge))
+			{
+				pm.GET("", mergeHandler.ListMerges)
+				pm.POST("/:id/undo", mergeHandler.UndoMerge)
+			}
+
+			// Patient imports
+			pi := protected.Group("/patient-imports")
+			pi.Use(middleware.RequirePermission(authz.PatientImport))
+			{
+				pi.GET("", importHandler.ListImports)
+				pi.GET("/:id", importHandler.GetImport)
+				pi.POST("/:id/resume", importHandler.ResumeImport)
+			}
+
+			// Patient exports, visible to the user who requested them
+			px := protected.Group("/patient-exports")
+			px.Use(middleware.RequirePermission(authz.PatientExport))
+			{
+				px.GET("/:id", exportHandler.GetExport)
+				px.GET("/:id/download", exportHandler.DownloadExport)
+			}
+
+			// Emergency access review queue
+			e := protected.Group("/emergency-access")
+			e.Use(middleware.RequirePermission(authz.EmergencyReview))
+			{
+				e.GET("", emergencyHandler.ListGrants)
+				e.POST("/:id/review", emergencyHandler.ReviewGrant)
 			}
 
 			// Standalone doc deletion
-			authRoutes.DELETE("/documents/:doc_id", middleware.RoleMiddleware("receptionist"), docHandler.DeleteDocument)
+			protected.DELETE("/documents/:doc_id", middleware.RequirePermission(authz.DocumentDelete), docHandler.DeleteDocument)
 		}
 	}
 
@@ -168,6 +399,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -192,3 +424,73 @@ func runMigrations(databaseURL string) {
 		log.Println("Migrations applied successfully.")
 	}
 }
+
+
+// newSSOService configures single sign-on from the OIDC settings
+func newSSOService(cfg *config.Config, users auth.Repository, sso auth.SSORepository, authSvc auth.Service) auth.SSOService {
+	mappings, err := auth.ParseRoleMappings(cfg.OIDCRoleMapping)
+	if err != nil {
+		log.Fatalf("Invalid OIDC_ROLE_MAPPING: %v", err)
+	}
+	if len(mappings) == 0 {
+		log.Println("OIDC_ROLE_MAPPING is empty; single sign-on logins will be rejected until groups are mapped to roles.")
+	}
+
+	scopes := cfg.OIDCScopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    cfg.OIDCIssuerURL,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       scopes,
+	})
+	return auth.NewSSOService(users, sso, authSvc, provider, auth.SSOOptions{
+		RoleClaim:     cfg.OIDCRoleClaim,
+		RoleMappings:  mappings,
+		AutoProvision: cfg.OIDCAutoProvision,
+		LinkByEmail:   cfg.OIDCLinkByEmail,
+	})
+}
+
+// loadJWTKeys loads the access token signing key and any additional
+// verification keys. Without a configured signing key an ephemeral one is
+// generated, which is only suitable for development: tokens stop verifying on
+// restart and cannot be verified by other instances.
+func loadJWTKeys(cfg *config.Config) *jwtkeys.KeySet {
+	var signing *jwtkeys.Key
+	var err error
+	if cfg.JWTSigningKeyFile != "" {
+		signing, err = jwtkeys.LoadPEMFile(cfg.JWTSigningKeyFile)
+		if err != nil {
+			log.Fatalf("Could not load JWT signing key: %v", err)
+		}
+	} else {
+		if cfg.GinMode == gin.ReleaseMode {
+			log.Fatal("FATAL: JWT_SIGNING_KEY_FILE must be set in release mode")
+		}
+		log.Println("JWT_SIGNING_KEY_FILE not set; using an ephemeral Ed25519 key. Tokens will not survive a restart.")
+		signing, err = jwtkeys.GenerateEd25519()
+		if err != nil {
+			log.Fatalf("Could not generate JWT signing key: %v", err)
+		}
+	}
+
+	var verification []*jwtkeys.Key
+	for _, path := range cfg.JWTVerificationKeyFiles {
+		key, err := jwtkeys.LoadPEMFile(path)
+		if err != nil {
+			log.Fatalf("Could not load JWT verification key: %v", err)
+		}
+		verification = append(verification, key)
+	}
+
+	keys, err := jwtkeys.NewKeySet(signing, verification...)
+	if err != nil {
+		log.Fatalf("Could not build JWT key set: %v", err)
+	}
+	log.Printf("Signing access tokens with key %s", keys.SigningKeyID())
+	return keys
+}
\ No newline at end of file
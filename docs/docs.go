@@ -24,133 +24,41 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/documents/{doc_id}": {
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Deletes a document from the system and cloud storage.",
-                "tags": [
-                    "Documents"
-                ],
-                "summary": "Delete a document",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Document ID",
-                        "name": "doc_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "400": {
-                        "description": "Invalid document ID",
-                        "schema": {
-                            "$ref": "#/definitions/document.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Document not found",
-                        "schema": {
-                            "$ref": "#/definitions/document.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/login": {
-            "post": {
-                "description": "Logs in a user and returns a JWT token.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Auth"
-                ],
-                "summary": "User Login",
-                "parameters": [
-                    {
-                        "description": "Login Credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth.LoginRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/auth.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request body",
-                        "schema": {
-                            "$ref": "#/definitions/auth.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Invalid credentials",
-                        "schema": {
-                            "$ref": "#/definitions/auth.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/auth.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/patients": {
+        "/api-keys": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a list of all patients in the system.",
+                "description": "Retrieves all API keys, including expired and revoked ones. Keys are identified by their prefix; the keys themselves are never returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "API Keys"
                 ],
-                "summary": "List all patients",
+                "summary": "List API keys (Admin only)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/patient.Patient"
+                                "$ref": "#/definitions/auth.APIKey"
                             }
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
@@ -161,7 +69,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Adds a new patient to the system.",
+                "description": "Issues an API key for a machine client such as a lab system. The key is granted exactly the listed scopes, which must be known permissions; administrative scopes and prescription:create cannot be granted. The key is returned only in this response; only its hash is stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -169,17 +77,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "API Keys"
                 ],
-                "summary": "Create a patient",
+                "summary": "Create an API key (Admin only)",
                 "parameters": [
                     {
-                        "description": "Patient data",
-                        "name": "patient",
+                        "description": "API key data",
+                        "name": "api_key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/patient.CreatePatientRequest"
+                            "$ref": "#/definitions/auth.CreateAPIKeyRequest"
                         }
                     }
                 ],
@@ -187,51 +95,51 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/patient.Patient"
+                            "$ref": "#/definitions/auth.CreatedAPIKeyResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Invalid request body or scopes",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/patients/search": {
+        "/api-keys/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Searches for patients by name (case-insensitive).",
+                "description": "Retrieves a single API key, including when it was last used.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "API Keys"
                 ],
-                "summary": "Search for patients",
+                "summary": "Get an API key by ID (Admin only)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Search query",
-                        "name": "q",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -239,156 +147,172 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/patient.Patient"
-                            }
+                            "$ref": "#/definitions/auth.APIKey"
                         }
                     },
                     "400": {
-                        "description": "Query parameter 'q' is required",
+                        "description": "Invalid API key ID",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/patients/{id}": {
+        "/api-keys/{id}/requests": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a single patient's details.",
+                "description": "Retrieves the request log of an API key, newest first. Every request authenticated with the key is recorded with its method, path, response status and client IP.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "API Keys"
                 ],
-                "summary": "Get a patient by ID",
+                "summary": "List requests made with an API key (Admin only)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Patient ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 100, max 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/patient.Patient"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.APIKeyRequest"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid patient ID",
+                        "description": "Invalid API key ID",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "API key not found",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api-keys/{id}/revoke": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates a patient's non-medical information.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Revokes an API key. Requests made with it are rejected immediately. The key and its request log are kept.",
                 "tags": [
-                    "Patients"
+                    "API Keys"
                 ],
-                "summary": "Update a patient",
+                "summary": "Revoke an API key (Admin only)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Patient ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Patient data",
-                        "name": "patient",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/patient.UpdatePatientRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/patient.Patient"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid request body or ID",
+                        "description": "Invalid API key ID",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "API key not found",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/documents/{doc_id}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a patient from the system.",
+                "description": "Deletes a document from the system and cloud storage.",
                 "tags": [
-                    "Patients"
+                    "Documents"
                 ],
-                "summary": "Delete a patient",
+                "summary": "Delete a document",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Patient ID",
-                        "name": "id",
+                        "description": "Document ID",
+                        "name": "doc_id",
                         "in": "path",
                         "required": true
                     }
@@ -398,48 +322,47 @@ const docTemplate = `{
                         "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid patient ID",
+                        "description": "Invalid document ID",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/document.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden",
+                        "description": "Forbidden or not on the patient's care team",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/document.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Patient not found",
+                        "description": "Document not found",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/document.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/patients/{id}/documents": {
+        "/emergency-access": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a list of all documents for a specific patient.",
+                "description": "Retrieves break-the-glass grants, newest first. By default only the review queue (grants not yet reviewed) is returned; pass status=all for the full history.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Emergency Access"
                 ],
-                "summary": "List documents for a patient",
+                "summary": "List emergency access grants",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Patient ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "pending (default) or all",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -448,87 +371,110 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/document.Document"
+                                "$ref": "#/definitions/careteam.Grant"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid patient ID",
+                        "description": "Invalid status",
                         "schema": {
-                            "$ref": "#/definitions/document.ErrorResponse"
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/document.ErrorResponse"
+                            "$ref": "#/definitions/careteam.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/emergency-access/{id}/review": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Uploads a file (pdf, jpg, etc.) associated with a patient.",
+                "description": "Marks a grant as reviewed and removes it from the review queue. Set revoke to end a grant that is still active.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Emergency Access"
                 ],
-                "summary": "Upload a document for a patient",
+                "summary": "Review an emergency access grant",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Patient ID",
+                        "description": "Grant ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "The document to upload",
-                        "name": "document",
-                        "in": "formData",
-                        "required": true
+                        "description": "Review outcome",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ReviewGrantRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/document.Document"
+                            "$ref": "#/definitions/careteam.Grant"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid request body",
                         "schema": {
-                            "$ref": "#/definitions/document.ErrorResponse"
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or own grant",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Grant not found",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Grant already reviewed",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/document.ErrorResponse"
+                            "$ref": "#/definitions/careteam.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/patients/{id}/medical": {
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Updates a patient's diagnosis and notes.",
+        "/login": {
+            "post": {
+                "description": "Logs in a user and returns a short-lived JWT access token and a refresh token. For accounts with MFA enabled it instead returns 202 with an MFA challenge to complete at /login/mfa.",
                 "consumes": [
                     "application/json"
                 ],
@@ -536,24 +482,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Patients"
+                    "Auth"
                 ],
-                "summary": "Update patient's medical info (Doctor only)",
+                "summary": "User Login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Patient ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Patient medical data",
-                        "name": "patient",
+                        "description": "Login Credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/patient.UpdatePatientMedicalRequest"
+                            "$ref": "#/definitions/auth.LoginRequest"
                         }
                     }
                 ],
@@ -561,52 +500,99 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/patient.Patient"
+                            "$ref": "#/definitions/auth.LoginResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "MFA code required",
+                        "schema": {
+                            "$ref": "#/definitions/auth.MFAChallengeResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or ID",
+                        "description": "Invalid request body",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden",
+                        "description": "Account deactivated",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Patient not found",
+                    "429": {
+                        "description": "Too many failed attempts; see the Retry-After header",
                         "schema": {
-                            "$ref": "#/definitions/patient.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/patients/{id}/prescriptions": {
+        "/login-attempts": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a list of all prescriptions for a specific patient.",
+                "description": "Retrieves the login audit log, newest first. Every password login attempt is recorded with its outcome, client IP and user agent.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Prescriptions"
+                    "Security"
                 ],
-                "summary": "List prescriptions for a patient",
+                "summary": "List login attempts (Admin only)",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Submitted username",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client IP address",
+                        "name": "ip_address",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only successful or only failed attempts",
+                        "name": "success",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, inclusive",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, exclusive",
+                        "name": "until",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Patient ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Maximum number of entries (default 100, max 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -615,90 +601,5277 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/prescription.Prescription"
+                                "$ref": "#/definitions/auth.LoginAttempt"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid patient ID",
+                        "description": "Invalid filter",
                         "schema": {
-                            "$ref": "#/definitions/prescription.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/prescription.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/login-lockouts": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new prescription for a patient. The doctor's ID is automatically taken from the JWT token.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieves all usernames and client IPs that are currently locked out of login.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Prescriptions"
+                    "Security"
                 ],
-                "summary": "Create a prescription (Doctor only)",
+                "summary": "List active lockouts (Admin only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.LoginLockout"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login-lockouts/ip": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Clears the failed login counter of a client IP address.",
+                "tags": [
+                    "Security"
+                ],
+                "summary": "Unlock a client IP (Admin only)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Patient ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Client IP address",
+                        "name": "ip",
+                        "in": "query",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Missing IP address",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa": {
+            "post": {
+                "description": "Redeems the MFA challenge returned by /login with a TOTP code or a one-time recovery code and returns the session tokens.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete an MFA login",
+                "parameters": [
                     {
-                        "description": "Prescription details",
-                        "name": "prescription",
+                        "description": "MFA challenge token and code",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/prescription.CreateRequest"
+                            "$ref": "#/definitions/auth.MFALoginRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/prescription.Prescription"
+                            "$ref": "#/definitions/auth.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request due to invalid patient ID or request body",
+                        "description": "Invalid request body",
                         "schema": {
-                            "$ref": "#/definitions/prescription.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid code or challenge",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden if user is not a doctor or token is invalid",
+                        "description": "Account deactivated",
                         "schema": {
-                            "$ref": "#/definitions/prescription.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/prescription.ErrorResponse"
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes the current session, its refresh tokens and the access token used for this request.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.ErrorResponse": {
+        },
+        "/logout/all": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes every session of the current user, invalidating all of their access and refresh tokens.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out everywhere",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports whether MFA is enabled for the signed-in user and how many recovery codes are left.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Get own MFA status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.MFAStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/activate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Confirms enrollment with a code from the authenticator app and returns one-time recovery codes. The codes are shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Activate MFA",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid code or enrollment not started",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generates a new TOTP secret and otpauth URI for the signed-in user. MFA is enforced only after activation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Start MFA enrollment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.MFAEnrollment"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces all recovery codes of the signed-in user. Requires a current TOTP code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MFA"
+                ],
+                "summary": "Regenerate recovery codes",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.MFACodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid code or MFA not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the signed-in user's password. Requires the current password and revokes all other sessions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Change own password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid request body or unchanged password",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Current password is incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Sets a new password using a one-time reset token. All existing sessions of the user are revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Reset password with a reset token",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid request body or reset token",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-exports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the status of an export the caller requested.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Exports"
+                ],
+                "summary": "Get a patient export",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Export ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Export"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid export ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-exports/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads the file of a completed export the caller requested.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf",
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Exports"
+                ],
+                "summary": "Download a patient export",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Export ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The export",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid export ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Export not completed",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-imports": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves every patient import, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Imports"
+                ],
+                "summary": "List patient imports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patient.Import"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-imports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the progress of a patient import.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Imports"
+                ],
+                "summary": "Get a patient import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Import"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid import ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Import not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-imports/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Commits the rows of a failed or interrupted import that were not committed yet.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Imports"
+                ],
+                "summary": "Resume a patient import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Import"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid import ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Import not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Import already completed",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-merges": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the merges the patient took part in as survivor or as duplicate, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Merges"
+                ],
+                "summary": "List a patient's merges",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "patient_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patient.Merge"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patient-merges/{id}/undo": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves the prescriptions, documents, care team members, identifiers, emergency contacts and portal account the merge moved back to the duplicate and restores it from the trash.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Merges"
+                ],
+                "summary": "Undo a patient merge",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Merge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Merge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid merge ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Merge not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Merge already undone or duplicate no longer in the trash",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a page of patients: every patient for callers with the patient:access:all permission, and the caller's own panel (patients on their care teams) for everyone else. Pass the returned next_cursor as cursor to fetch the following page; total counts every matching patient.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "List patients",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Minimum age in completed years",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age in completed years",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created on or after (YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created on or before (YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated on or after (YYYY-MM-DD)",
+                        "name": "updated_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated on or before (YYYY-MM-DD)",
+                        "name": "updated_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only patients with (true) or without (false) a diagnosis; requires patient:clinical:read",
+                        "name": "has_diagnosis",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only patients on this doctor's care team",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort key: name, date_of_birth, created_at or updated_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.Page-patient_ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, sort key or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or filtering by diagnosis without patient:clinical:read",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a new patient to the system. If the patient resembles existing patients (similar name, same phone number, same date of birth) nothing is created and 409 lists the probable duplicates; resend with confirm_duplicate set to create the patient anyway.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Create a patient",
+                "parameters": [
+                    {
+                        "description": "Patient data",
+                        "name": "patient",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.CreatePatientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or date of birth",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Probable duplicates, confirmation required",
+                        "schema": {
+                            "$ref": "#/definitions/patient.DuplicateResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/by-identifier": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the patient a value identifies in another system, ignoring case, with the same views as GET /patients/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Identifiers"
+                ],
+                "summary": "Get a patient by external identifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identifier system, e.g. national_id",
+                        "name": "system",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Identifier value",
+                        "name": "value",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the patient, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid identifier",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/by-mrn/{mrn}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a patient by medical record number, ignoring case, with the same views as GET /patients/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Get a patient by MRN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Medical record number",
+                        "name": "mrn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the patient, for If-Match"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Exports the patients the caller may list, with the filters and sort order of GET /patients, as CSV, XLSX or a PDF report. Diagnosis and notes are only included for callers with patient:clinical:read. Exports of up to 1000 patients are returned directly; larger ones are produced in the background and can be downloaded from the returned export once completed.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf",
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Exports"
+                ],
+                "summary": "Export the patient list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv, xlsx or pdf",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum age in completed years",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age in completed years",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created on or after (YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created on or before (YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated on or after (YYYY-MM-DD)",
+                        "name": "updated_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated on or before (YYYY-MM-DD)",
+                        "name": "updated_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only patients with (true) or without (false) a diagnosis; requires patient:clinical:read",
+                        "name": "has_diagnosis",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only patients on this doctor's care team",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort key: name, date_of_birth, created_at or updated_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The export",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Export queued; see Location",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Export"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format, filter or sort key",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or filtering by diagnosis without patient:clinical:read",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Checks every row of the file like a new patient and against existing patients. With dry_run only the report is returned. Otherwise the rows are committed in one transaction, or in batches that can be resumed if the import fails, as long as no row is invalid and, with duplicates=reject, none probably duplicates a patient.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Imports"
+                ],
+                "summary": "Import patients from a CSV or XLSX file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file, header first",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object from patient fields to column headers",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only check the file",
+                        "name": "dry_run",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "transaction (default) or batches",
+                        "name": "mode",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows per batch, 500 by default",
+                        "name": "batch_size",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "reject (default), skip or create probable duplicates",
+                        "name": "duplicates",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Go layout of dates not written as YYYY-MM-DD",
+                        "name": "date_format",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Import started; see import.status",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid file, options or column mapping",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid or duplicate rows",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ImportRejectedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ranked fuzzy search over name, phone number, MRN and address. Names also match by trigram similarity and by sound, so \"Jon Doe\" finds \"John Doe\"; phone numbers match on their digits. Each result lists the fields that matched, how (exact, fuzzy or phonetic), and the field value with the matching parts wrapped in \u003cmark\u003e tags. Doctors only search their own panel.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Search for patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results (1-50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "patient is the clinical or demographic view, as for GET /patients/{id}",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patient.SearchHit"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Query parameter 'q' is required",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/trash": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a page of the trash, most recently deleted first. Accepts the filters of GET /patients; deleted_at is an additional sort key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "List deleted patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "-deleted_at",
+                        "description": "Sort key: deleted_at, name, date_of_birth, created_at or updated_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.Page-patient_ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, sort key or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or filtering by diagnosis without patient:clinical:read",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a single patient's details. Diagnosis and notes are only included for callers with the patient:clinical:read permission (doctors); receptionists receive the demographic view.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Get a patient by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the patient, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates a patient's non-medical information. Send the ETag of the patient as read in If-Match to have the update rejected if someone else changed the patient in the meantime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Update a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the patient as last read",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Patient data",
+                        "name": "patient",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.UpdatePatientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, ID or date of birth",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Patient changed since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/patient.RevisionResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves a patient to the trash. The record, prescriptions and documents are hidden but kept, can be restored, and are permanently removed once the retention period has passed.",
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Delete a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Applies an RFC 7396 merge patch (application/merge-patch+json) or an RFC 6902 JSON Patch (application/json-patch+json) to a patient. Only the fields named by the patch change; removing phone_number or an optional detail clears it. Changing demographics needs patient:update and changing diagnosis or notes patient:medical:write. Honors If-Match like the update of a patient.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Partially update a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the patient as last read",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch or JSON Patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patch, ID or resulting patient",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not allowed to change a patched field or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Patient changed since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/patient.RevisionResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch media type",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/as-of": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the patient record as it was at the given time, projected for the caller like GET /patients/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient History"
+                ],
+                "summary": "View a patient as of a point in time",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, e.g. 2026-01-31T17:00:00Z",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID or timestamp",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient did not exist at that time",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/care-team": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the doctors assigned to a patient, primary physician first. Doctors can only see the care teams they belong to.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Care Team"
+                ],
+                "summary": "List a patient's care team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/careteam.Member"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a doctor to a patient's care team, or changes whether an existing member is the primary physician. A new primary physician replaces the previous one, who stays on the care team.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Care Team"
+                ],
+                "summary": "Assign a doctor to a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Doctor to assign",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/careteam.AddMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.Member"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or user is not an active doctor",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/care-team/{doctor_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unassigns a doctor from a patient. The doctor loses access to the patient's record.",
+                "tags": [
+                    "Care Team"
+                ],
+                "summary": "Remove a doctor from a patient's care team",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Doctor's user ID",
+                        "name": "doctor_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Doctor is not on the care team",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/documents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a page of the documents for a specific patient. Pass the returned next_cursor as cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "List documents for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "-uploaded_at",
+                        "description": "Sort key: file_name or uploaded_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.Page-document_Document"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID, sort key or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Uploads a file (pdf, jpg, etc.) associated with a patient.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Upload a document for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "The document to upload",
+                        "name": "document",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/document.Document"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/document.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/emergency-access": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Grants the calling doctor time-boxed access to a patient outside their care teams, e.g. in the emergency room. A reason is mandatory; every grant is recorded and queued for admin review.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Emergency Access"
+                ],
+                "summary": "Break the glass for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Justification",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/careteam.BreakGlassRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.Grant"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or missing reason",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/careteam.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/emergency-contacts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the patient's emergency contacts, primary contact first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Emergency Contacts"
+                ],
+                "summary": "List a patient's emergency contacts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patient.EmergencyContact"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an emergency contact to the patient. A patient has at most 5 contacts; a new primary contact replaces the previous one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Emergency Contacts"
+                ],
+                "summary": "Add an emergency contact",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Contact",
+                        "name": "contact",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.EmergencyContactRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/patient.EmergencyContact"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or contact",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Patient already has 5 contacts",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/emergency-contacts/{contact_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces one of the patient's emergency contacts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Emergency Contacts"
+                ],
+                "summary": "Replace an emergency contact",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Contact ID",
+                        "name": "contact_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Contact",
+                        "name": "contact",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.EmergencyContactRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/patient.EmergencyContact"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, ID or contact",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Contact not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes one of the patient's emergency contacts.",
+                "tags": [
+                    "Emergency Contacts"
+                ],
+                "summary": "Remove an emergency contact",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Contact ID",
+                        "name": "contact_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Contact not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/identifiers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the patient's identifiers in other systems, such as national ID and insurance member ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Identifiers"
+                ],
+                "summary": "List a patient's identifiers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patient.Identifier"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an identifier in another system to the patient. A value identifies one patient per system, ignoring case.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Identifiers"
+                ],
+                "summary": "Add an identifier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Identifier",
+                        "name": "identifier",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.IdentifierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Identifier"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or identifier",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Identifier already belongs to a patient",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/identifiers/{identifier_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes one of the patient's identifiers.",
+                "tags": [
+                    "Patient Identifiers"
+                ],
+                "summary": "Remove an identifier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Identifier ID",
+                        "name": "identifier_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Identifier not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/medical": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates a patient's diagnosis and notes. Honors If-Match like the update of a patient.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Update patient's medical info (Doctor only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the patient as last read",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Patient medical data",
+                        "name": "patient",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.UpdatePatientMedicalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Patient changed since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/patient.RevisionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves the prescriptions, documents, care team, identifiers, emergency contacts and portal account of the duplicate to this patient and moves the duplicate to the trash. The merge is recorded and can be undone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Merges"
+                ],
+                "summary": "Merge a duplicate patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Surviving patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Duplicate to merge",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/patient.MergeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Merge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or merge with itself",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on both patients' care teams",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Both patients have a portal account",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/portal-invitation": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues a single-use activation link and sends it to the patient's email address. Earlier unused invitations for the patient stop working. The token is never returned in the response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Portal"
+                ],
+                "summary": "Invite a patient to the portal",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Where to send the invitation",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/portal.InviteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/portal.InvitationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or ID",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Patient already has a portal account",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/prescriptions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a page of the prescriptions for a specific patient. Pass the returned next_cursor as cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prescriptions"
+                ],
+                "summary": "List prescriptions for a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort key: medication or created_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.Page-prescription_Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID, sort key or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new prescription for a patient. The doctor's ID is automatically taken from the JWT token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prescriptions"
+                ],
+                "summary": "Create a prescription (Doctor only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Prescription details",
+                        "name": "prescription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/prescription.CreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request due to invalid patient ID or request body",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden if user is not a doctor on the patient's care team, the caller is an API key or token is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/prescription.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Takes a patient out of the trash, together with their prescriptions and documents.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patients"
+                ],
+                "summary": "Restore a deleted patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ClinicalRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not in the trash",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves every version of the patient record, newest first: who changed it, when, and the old and new value of each changed field. Diagnosis and notes changes are only included for callers with patient:clinical:read; versions that changed nothing else are left out for everyone else.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient History"
+                ],
+                "summary": "List a patient's versions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patient.Version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Patient not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/patients/{id}/versions/diff": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the fields that differ between two versions of the patient record with their values in each.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient History"
+                ],
+                "summary": "Compare two versions of a patient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Patient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Older version",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Newer version",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/patient.Diff"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patient ID or version",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or not on the patient's care team",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Version not found",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/patient.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the catalog of permissions that can be granted to roles.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permissions"
+                ],
+                "summary": "List permissions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/authz.Permission"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/portal/activate": {
+            "post": {
+                "description": "Redeems an invitation and creates the patient's portal account with the chosen username and password. The patient then signs in through /login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Portal"
+                ],
+                "summary": "Activate a patient portal account",
+                "parameters": [
+                    {
+                        "description": "Invitation token and credentials",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/portal.ActivateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or invitation",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username taken or account already exists",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/portal/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the demographics of the patient linked to the signed-in portal account. Clinical notes are never included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Portal"
+                ],
+                "summary": "Get my patient record",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/portal.Profile"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a patient portal account",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/portal/me/documents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a page of the documents on file for the signed-in patient.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Portal"
+                ],
+                "summary": "List my documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "-uploaded_at",
+                        "description": "Sort key: file_name or uploaded_at; prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (1-200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.Page-document_Document"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort key or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a patient portal account",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/portal/me/prescriptions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the signed-in patient's prescriptions that have not ended.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Patient Portal"
+                ],
+                "summary": "List my active prescriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/prescription.Prescription"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Not a patient portal account",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/portal.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/roles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves every role together with the permissions granted to it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permissions"
+                ],
+                "summary": "List roles and their permissions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/authz.Role"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/roles/{role}/permissions": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the full set of permissions granted to a role. Takes effect for signed-in users within 30 seconds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permissions"
+                ],
+                "summary": "Replace a role's permissions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Permissions to grant",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/authz.UpdateRolePermissionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/authz.Role"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or unknown permission",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/authz.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sso/callback": {
+            "post": {
+                "description": "Redeems the code returned by the identity provider and returns the same session tokens as /login. The user's provider groups are mapped to a portal role; unknown users are linked by verified email or provisioned, depending on configuration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete a single sign-on login",
+                "parameters": [
+                    {
+                        "description": "Code and state from the provider redirect",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.SSOCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid state or provider login failed",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No portal role or account, or account deactivated",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sso/login": {
+            "get": {
+                "description": "Redirects the browser to the hospital identity provider (OpenID Connect authorization code flow with PKCE). The provider redirects back to the configured redirect URL with a code and state, which the frontend posts to /sso/callback.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Start a single sign-on login",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "500": {
+                        "description": "Identity provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Reusing a refresh token revokes its whole session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh tokens",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves all staff accounts, including deactivated ones.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List staff accounts (Admin only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.User"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new receptionist, doctor or admin account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create a staff account (Admin only)",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already taken",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a single staff account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get a staff account by ID (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates a staff account's username.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update a staff account (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already taken",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/activate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Re-enables login for a previously deactivated staff account.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Reactivate a staff account (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/deactivate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disables login for a staff account and revokes access for tokens it already holds.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Deactivate a staff account (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/mfa": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes the user's TOTP secret and recovery codes and revokes their sessions, e.g. after a lost device.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Reset a user's MFA (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/password-reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends a single-use, expiring reset link to the user and forces a password change at their next login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Issue a password reset (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/auth.PasswordResetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/role": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Assigns a new role to a staff account. Admins cannot change their own role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Change a staff account's role (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.UpdateUserRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or ID, or patient portal account",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Clears the failed login counter of the user's username, lifting a lockout.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unlock a staff account (Admin only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/auth.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "auth.APIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "auth.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "auth.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "role",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "receptionist",
+                        "doctor",
+                        "admin"
+                    ]
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                }
+            }
+        },
+        "auth.CreatedAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/auth.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginAttempt": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginLockout": {
+            "type": "object",
+            "properties": {
+                "failed_count": {
+                    "type": "integer"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_failed_at": {
+                    "type": "string"
+                },
+                "locked_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "password_change_required": {
+                    "type": "boolean"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MFAChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "mfa_required": {
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MFACodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MFAEnrollment": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MFALoginRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.MFAStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "enrolled_at": {
+                    "type": "string"
+                },
+                "recovery_codes_remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.PasswordResetResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "auth.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.SSOCallbackRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "state"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.UpdateUserRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                }
+            }
+        },
+        "auth.UpdateUserRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "receptionist",
+                        "doctor",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "auth.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "mfa_enabled": {
+                    "type": "boolean"
+                },
+                "must_change_password": {
+                    "type": "boolean"
+                },
+                "password_changed_at": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "authz.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "authz.Permission": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "authz.Role": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "authz.UpdateRolePermissionsRequest": {
+            "type": "object",
+            "required": [
+                "permissions"
+            ],
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "careteam.AddMemberRequest": {
+            "type": "object",
+            "required": [
+                "doctor_id"
+            ],
+            "properties": {
+                "doctor_id": {
+                    "type": "integer"
+                },
+                "primary": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "careteam.BreakGlassRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 10
+                }
+            }
+        },
+        "careteam.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "careteam.Grant": {
+            "type": "object",
+            "properties": {
+                "doctor_id": {
+                    "type": "integer"
+                },
+                "doctor_username": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "granted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "patient_name": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "review_note": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "integer"
+                }
+            }
+        },
+        "careteam.Member": {
+            "type": "object",
+            "properties": {
+                "assigned_at": {
+                    "type": "string"
+                },
+                "assigned_by": {
+                    "type": "integer"
+                },
+                "doctor_id": {
+                    "type": "integer"
+                },
+                "doctor_username": {
+                    "type": "string"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "patient_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "careteam.ReviewGrantRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "revoke": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "document.Document": {
+            "type": "object",
+            "properties": {
+                "file_name": {
+                    "type": "string"
+                },
+                "file_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "document.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "pagination.Page-document_Document": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/document.Document"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.Page-patient_ClinicalRecord": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/patient.ClinicalRecord"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.Page-prescription_Prescription": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/prescription.Prescription"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "patient.Age": {
+            "type": "object",
+            "properties": {
+                "unit": {
+                    "$ref": "#/definitions/patient.AgeUnit"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "patient.AgeUnit": {
+            "type": "string",
+            "enum": [
+                "years",
+                "months",
+                "weeks",
+                "days"
+            ],
+            "x-enum-varnames": [
+                "AgeYears",
+                "AgeMonths",
+                "AgeWeeks",
+                "AgeDays"
+            ]
+        },
+        "patient.Changes": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/patient.FieldChange"
+            }
+        },
+        "patient.ClinicalRecord": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "age": {
+                    "$ref": "#/definitions/patient.Age"
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "integer"
+                },
+                "diagnosis": {
+                    "description": "Only returned to callers with patient:clinical:read",
+                    "type": "string"
+                },
+                "dob_estimated": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "gender": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "marital_status": {
+                    "type": "string",
+                    "enum": [
+                        "single",
+                        "married",
+                        "partnered",
+                        "separated",
+                        "divorced",
+                        "widowed",
+                        "unknown"
+                    ]
+                },
+                "mrn": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "description": "Only returned to callers with patient:clinical:read",
+                    "type": "string"
+                },
+                "occupation": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "postcode": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "preferred_language": {
+                    "type": "string",
+                    "maxLength": 35
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "revision": {
+                    "type": "integer"
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "female",
+                        "male",
+                        "intersex",
+                        "unknown"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "patient.CreatePatientRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "date_of_birth",
+                "name"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "confirm_duplicate": {
+                    "type": "boolean"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "dob_estimated": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "gender": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "marital_status": {
+                    "type": "string",
+                    "enum": [
+                        "single",
+                        "married",
+                        "partnered",
+                        "separated",
+                        "divorced",
+                        "widowed",
+                        "unknown"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "occupation": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "postcode": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "preferred_language": {
+                    "type": "string",
+                    "maxLength": 35
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "female",
+                        "male",
+                        "intersex",
+                        "unknown"
+                    ]
+                }
+            }
+        },
+        "patient.Diff": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "$ref": "#/definitions/patient.Changes"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "patient.DuplicateHit": {
+            "type": "object",
+            "properties": {
+                "patient": {},
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "patient.DuplicateResponse": {
+            "type": "object",
+            "properties": {
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/patient.DuplicateHit"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "patient.EmergencyContact": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "relationship": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "patient.EmergencyContactRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "phone_number",
+                "relationship"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone_number": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "relationship": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "patient.ErrorResponse": {
             "type": "object",
             "properties": {
                 "error": {
@@ -706,118 +5879,294 @@ const docTemplate = `{
                 }
             }
         },
-        "auth.LoginRequest": {
+        "patient.Export": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
+                "clinical": {
+                    "type": "boolean"
+                },
+                "completed_at": {
                     "type": "string"
                 },
-                "username": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "requested_by": {
+                    "type": "integer"
+                },
+                "requested_by_api_key": {
+                    "type": "integer"
+                },
+                "row_count": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "auth.LoginResponse": {
+        "patient.FieldChange": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string"
+                "new": {
+                    "type": "object"
+                },
+                "old": {
+                    "type": "object"
                 }
             }
         },
-        "document.Document": {
+        "patient.Identifier": {
             "type": "object",
             "properties": {
-                "file_name": {
+                "created_at": {
                     "type": "string"
                 },
-                "file_url": {
-                    "type": "string"
+                "created_by": {
+                    "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "mime_type": {
-                    "type": "string"
-                },
                 "patient_id": {
                     "type": "integer"
                 },
-                "uploaded_at": {
+                "system": {
+                    "type": "string"
+                },
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "document.ErrorResponse": {
+        "patient.IdentifierRequest": {
             "type": "object",
+            "required": [
+                "system",
+                "value"
+            ],
             "properties": {
-                "error": {
-                    "type": "string"
+                "system": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "value": {
+                    "type": "string",
+                    "maxLength": 64
                 }
             }
         },
-        "patient.CreatePatientRequest": {
+        "patient.Import": {
             "type": "object",
-            "required": [
-                "address",
-                "age",
-                "name"
-            ],
             "properties": {
-                "address": {
+                "batch_size": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "age": {
+                "created_by": {
                     "type": "integer"
                 },
-                "name": {
+                "error": {
                     "type": "string"
                 },
-                "phone_number": {
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imported_rows": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total_rows": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "patient.ErrorResponse": {
+        "patient.ImportRejectedResponse": {
             "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
+                },
+                "report": {
+                    "$ref": "#/definitions/patient.ImportReport"
                 }
             }
         },
-        "patient.Patient": {
+        "patient.ImportReport": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "age": {
+                "duplicates": {
                     "type": "integer"
                 },
-                "created_at": {
+                "import": {
+                    "$ref": "#/definitions/patient.Import"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/patient.RowIssue"
+                    }
+                },
+                "total_rows": {
+                    "type": "integer"
+                },
+                "valid_rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "patient.Merge": {
+            "type": "object",
+            "properties": {
+                "care_team_doctor_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "contact_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "document_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "duplicate_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "identifier_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "merged_at": {
                     "type": "string"
                 },
-                "diagnosis": {
+                "merged_by": {
+                    "type": "integer"
+                },
+                "portal_user_id": {
+                    "type": "integer"
+                },
+                "prescription_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "survivor_id": {
+                    "type": "integer"
+                },
+                "undone_at": {
                     "type": "string"
                 },
-                "id": {
+                "undone_by": {
+                    "type": "integer"
+                }
+            }
+        },
+        "patient.MergeRequest": {
+            "type": "object",
+            "required": [
+                "duplicate_id"
+            ],
+            "properties": {
+                "duplicate_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "patient.RevisionResponse": {
+            "type": "object",
+            "properties": {
+                "current": {},
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "patient.RowIssue": {
+            "type": "object",
+            "properties": {
+                "duplicate_of": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "name": {
+                "error": {
                     "type": "string"
                 },
-                "notes": {
+                "field": {
                     "type": "string"
                 },
-                "phone_number": {
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "patient.SearchHit": {
+            "type": "object",
+            "properties": {
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/patient.SearchMatch"
+                    }
+                },
+                "patient": {},
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "patient.SearchMatch": {
+            "type": "object",
+            "properties": {
+                "field": {
                     "type": "string"
                 },
-                "updated_at": {
+                "highlight": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
@@ -840,14 +6189,174 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "address",
-                "age",
+                "date_of_birth",
                 "name"
             ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "dob_estimated": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "gender": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "marital_status": {
+                    "type": "string",
+                    "enum": [
+                        "single",
+                        "married",
+                        "partnered",
+                        "separated",
+                        "divorced",
+                        "widowed",
+                        "unknown"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "occupation": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "postcode": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "preferred_language": {
+                    "type": "string",
+                    "maxLength": 35
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "female",
+                        "male",
+                        "intersex",
+                        "unknown"
+                    ]
+                }
+            }
+        },
+        "patient.Version": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "integer"
+                },
+                "changed_by_api_key": {
+                    "type": "integer"
+                },
+                "changes": {
+                    "$ref": "#/definitions/patient.Changes"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "portal.ActivateRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                }
+            }
+        },
+        "portal.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "portal.InvitationResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "portal.InviteRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "portal.Profile": {
+            "type": "object",
             "properties": {
                 "address": {
                     "type": "string"
                 },
                 "age": {
+                    "$ref": "#/definitions/patient.Age"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
                 "name": {
@@ -869,6 +6378,12 @@ const docTemplate = `{
                 "dosage": {
                     "type": "string"
                 },
+                "duration_days": {
+                    "description": "DurationDays ends the prescription after the given number of days.\nWithout it the prescription stays active indefinitely.",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 1
+                },
                 "frequency": {
                     "type": "string"
                 },
@@ -900,6 +6415,9 @@ const docTemplate = `{
                 "dosage": {
                     "type": "string"
                 },
+                "ends_at": {
+                    "type": "string"
+                },
                 "frequency": {
                     "type": "string"
                 },
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// APIKeyHandler holds the dependencies for the API key management handlers
+type APIKeyHandler struct {
+	service APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key management handler
+func NewAPIKeyHandler(s APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: s}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key (Admin only)
+// @Description  Issues an API key for a machine client such as a lab system. The key is granted exactly the listed scopes, which must be known permissions; administrative scopes and prescription:create cannot be granted. The key is returned only in this response; only its hash is stored.
+// @Tags         API Keys
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        api_key body CreateAPIKeyRequest true "API key data"
+// @Success      201 {object} CreatedAPIKeyResponse
+// @Failure      400 {object} ErrorResponse "Invalid request body or scopes"
+// @Failure      403 {object} ErrorResponse "Forbidden"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	created, err := h.service.CreateAPIKey(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), req)
+	if err != nil {
+		writeAPIKeyError(c, err, "Failed to create API key")
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys (Admin only)
+// @Description  Retrieves all API keys, including expired and revoked ones. Keys are identified by their prefix; the keys themselves are never returned.
+// @Tags         API Keys
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   APIKey
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.service.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// GetAPIKey godoc
+// @Summary      Get an API key by ID (Admin only)
+// @Description  Retrieves a single API key, including when it was last used.
+// @Tags         API Keys
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  APIKey
+// @Failure      400  {object}  ErrorResponse "Invalid API key ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "API key not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /api-keys/{id} [get]
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	key, err := h.service.GetAPIKey(c.Request.Context(), id)
+	if err != nil {
+		writeAPIKeyError(c, err, "Failed to retrieve API key")
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key (Admin only)
+// @Description  Revokes an API key. Requests made with it are rejected immediately. The key and its request log are kept.
+// @Tags         API Keys
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "API key ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse "Invalid API key ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "API key not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /api-keys/{id}/revoke [post]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), id); err != nil {
+		writeAPIKeyError(c, err, "Failed to revoke API key")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListAPIKeyRequests godoc
+// @Summary      List requests made with an API key (Admin only)
+// @Description  Retrieves the request log of an API key, newest first. Every request authenticated with the key is recorded with its method, path, response status and client IP.
+// @Tags         API Keys
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id     path      int  true   "API key ID"
+// @Param        limit  query     int  false  "Maximum number of entries (default 100, max 500)"
+// @Success      200  {array}   APIKeyRequest
+// @Failure      400  {object}  ErrorResponse "Invalid API key ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "API key not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /api-keys/{id}/requests [get]
+func (h *APIKeyHandler) ListAPIKeyRequests(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	requests, err := h.service.ListRequests(c.Request.Context(), id, limit)
+	if err != nil {
+		writeAPIKeyError(c, err, "Failed to retrieve API key requests")
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// APIKeyAuditMiddleware records every request authenticated with an API key
+// in the key's request log, so that its actions remain attributable. It must
+// run after AuthMiddleware. Failing to record a request does not fail it.
+func APIKeyAuditMiddleware(s APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		identity, ok := middleware.GetIdentity(c)
+		if !ok || !identity.IsAPIKey() {
+			return
+		}
+		entry := &APIKeyRequest{
+			APIKeyID:  identity.APIKeyID,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			IPAddress: nonEmpty(c.ClientIP()),
+		}
+		if err := s.RecordRequest(c.Request.Context(), entry); err != nil {
+			log.Printf("Could not record request of API key %d: %v", identity.APIKeyID, err)
+		}
+	}
+}
+
+func writeAPIKeyError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUnknownScope), errors.Is(err, ErrScopeNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg + ": " + err.Error()})
+	}
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// APIKey is a credential for machine clients. Only the SHA-256 hash of the
+// key is stored; the key itself is shown once when it is created.
+type APIKey struct {
+	ID         int            `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Prefix     string         `json:"prefix" db:"prefix"`
+	KeyHash    string         `json:"-" db:"key_hash"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes" swaggertype:"array,string"`
+	CreatedBy  *int           `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy  *int           `json:"revoked_by,omitempty" db:"revoked_by"`
+}
+
+// APIKeyRequest is one entry of the request log kept for API keys
+type APIKeyRequest struct {
+	ID        int64     `json:"id" db:"id"`
+	APIKeyID  int       `json:"api_key_id" db:"api_key_id"`
+	Method    string    `json:"method" db:"method"`
+	Path      string    `json:"path" db:"path"`
+	Status    int       `json:"status" db:"status"`
+	IPAddress *string   `json:"ip_address,omitempty" db:"ip_address"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at, revoked_by`
+
+// APIKeyRepository defines the interface for API key storage
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *APIKey, ttl time.Duration) error
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	GetAPIKeyByID(ctx context.Context, id int) (*APIKey, error)
+	UseAPIKey(ctx context.Context, keyHash string) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id, revokedBy int) error
+	RecordAPIKeyRequest(ctx context.Context, r *APIKeyRequest) error
+	ListAPIKeyRequests(ctx context.Context, keyID, limit int) ([]APIKeyRequest, error)
+}
+
+type postgresAPIKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new repository for API keys
+func NewPostgresAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &postgresAPIKeyRepository{db: db}
+}
+
+// CreateAPIKey stores a new API key. A zero ttl creates a key that never expires.
+func (r *postgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key *APIKey, ttl time.Duration) error {
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), CASE WHEN $6 > 0 THEN NOW() + $6 * INTERVAL '1 second' END)
+		RETURNING id, created_at, expires_at`
+	return r.db.QueryRowContext(ctx, query, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedBy, int64(ttl.Seconds())).
+		Scan(&key.ID, &key.CreatedAt, &key.ExpiresAt)
+}
+
+// ListAPIKeys retrieves all API keys, including revoked and expired ones
+func (r *postgresAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.SelectContext(ctx, &keys, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	return keys, err
+}
+
+// GetAPIKeyByID retrieves an API key by its ID
+func (r *postgresAPIKeyRepository) GetAPIKeyByID(ctx context.Context, id int) (*APIKey, error) {
+	var key APIKey
+	err := r.db.GetContext(ctx, &key, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// UseAPIKey looks up a usable key by its hash and records that it was used,
+// in a single statement
+func (r *postgresAPIKeyRepository) UseAPIKey(ctx context.Context, keyHash string) (*APIKey, error) {
+	var key APIKey
+	query := `UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING ` + apiKeyColumns
+	err := r.db.GetContext(ctx, &key, query, keyHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey revokes an API key. Revoking an already revoked key keeps the
+// original revocation.
+func (r *postgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id, revokedBy int) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()), revoked_by = COALESCE(revoked_by, $2)
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id, revokedBy)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// RecordAPIKeyRequest appends an entry to the request log of an API key
+func (r *postgresAPIKeyRepository) RecordAPIKeyRequest(ctx context.Context, req *APIKeyRequest) error {
+	query := `INSERT INTO api_key_requests (api_key_id, method, path, status, ip_address, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, req.APIKeyID, req.Method, req.Path, req.Status, req.IPAddress).Scan(&req.ID, &req.CreatedAt)
+}
+
+// ListAPIKeyRequests retrieves the most recent requests made with an API key, newest first
+func (r *postgresAPIKeyRepository) ListAPIKeyRequests(ctx context.Context, keyID, limit int) ([]APIKeyRequest, error) {
+	var requests []APIKeyRequest
+	query := `SELECT id, api_key_id, method, path, status, ip_address, created_at FROM api_key_requests
+		WHERE api_key_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2`
+	err := r.db.SelectContext(ctx, &requests, query, keyID, limit)
+	return requests, err
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAPIKeyNotFound  = errors.New("API key not found")
+	ErrInvalidAPIKey   = fmt.Errorf("%w: API key is invalid, expired or revoked", middleware.ErrUnauthenticated)
+	ErrUnknownScope    = errors.New("unknown scope")
+	ErrScopeNotAllowed = errors.New("scope cannot be granted to an API key")
+)
+
+// APIKeyPrefix starts every API key, so keys can be told apart from access
+// tokens and recognized by secret scanners
+const APIKeyPrefix = "mpk_"
+
+const (
+	apiKeyBytes           = 32
+	apiKeyDisplayLength   = len(APIKeyPrefix) + 8
+	defaultAPIKeyRequests = 100
+	maxAPIKeyRequests     = 500
+)
+
+// API keys act on behalf of a system, not a person. Administrative scopes stay
+// with staff accounts, and prescriptions must be signed by a doctor.
+var forbiddenAPIKeyScopes = map[string]bool{
+	authz.UserManage:         true,
+	authz.SecurityAudit:      true,
+	authz.RoleManage:         true,
+	authz.APIKeyManage:       true,
+	authz.PrescriptionCreate: true,
+	authz.CareTeamManage:     true,
+	authz.EmergencyAccess:    true,
+	authz.EmergencyReview:    true,
+	authz.PortalSelf:         true,
+	authz.PortalInvite:       true,
+}
+
+// APIKeyService manages API keys and authenticates requests made with them
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, actorID int, req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	GetAPIKey(ctx context.Context, id int) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, actorID, id int) error
+	ListRequests(ctx context.Context, id, limit int) ([]APIKeyRequest, error)
+	VerifyAPIKey(ctx context.Context, key string) (*middleware.Identity, error)
+	RecordRequest(ctx context.Context, r *APIKeyRequest) error
+}
+
+type apiKeyService struct {
+	keys        APIKeyRepository
+	permissions authz.Service
+}
+
+// NewAPIKeyService creates a new API key service. Scopes are validated against
+// the permission catalog of the permission service.
+func NewAPIKeyService(keys APIKeyRepository, permissions authz.Service) APIKeyService {
+	return &apiKeyService{keys: keys, permissions: permissions}
+}
+
+// CreateAPIKey issues a new API key with the requested scopes. The returned
+// key is not stored and cannot be retrieved again.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, actorID int, req CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error) {
+	scopes, err := s.validateScopes(ctx, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken(apiKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	key := APIKeyPrefix + secret
+
+	apiKey := &APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashToken(key),
+		Scopes:    pq.StringArray(scopes),
+		CreatedBy: &actorID,
+	}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	if err := s.keys.CreateAPIKey(ctx, apiKey, ttl); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// ListAPIKeys retrieves all API keys
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	return s.keys.ListAPIKeys(ctx)
+}
+
+// GetAPIKey retrieves an API key by its ID
+func (s *apiKeyService) GetAPIKey(ctx context.Context, id int) (*APIKey, error) {
+	return s.keys.GetAPIKeyByID(ctx, id)
+}
+
+// RevokeAPIKey revokes an API key. Requests made with it are rejected from then on.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, actorID, id int) error {
+	return s.keys.RevokeAPIKey(ctx, id, actorID)
+}
+
+// ListRequests retrieves the most recent requests made with an API key
+func (s *apiKeyService) ListRequests(ctx context.Context, id, limit int) ([]APIKeyRequest, error) {
+	if _, err := s.keys.GetAPIKeyByID(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultAPIKeyRequests
+	}
+	if limit > maxAPIKeyRequests {
+		limit = maxAPIKeyRequests
+	}
+	return s.keys.ListAPIKeyRequests(ctx, id, limit)
+}
+
+// VerifyAPIKey checks that an API key exists, is neither expired nor revoked,
+// and returns an identity granted exactly the key's scopes
+func (s *apiKeyService) VerifyAPIKey(ctx context.Context, key string) (*middleware.Identity, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	apiKey, err := s.keys.UseAPIKey(ctx, hashToken(key))
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &middleware.Identity{
+		Username:    apiKey.Name,
+		APIKeyID:    apiKey.ID,
+		Permissions: []string(apiKey.Scopes),
+	}
+	if apiKey.ExpiresAt != nil {
+		identity.ExpiresAt = *apiKey.ExpiresAt
+	}
+	return identity, nil
+}
+
+// RecordRequest appends an entry to the request log of an API key
+func (s *apiKeyService) RecordRequest(ctx context.Context, r *APIKeyRequest) error {
+	return s.keys.RecordAPIKeyRequest(ctx, r)
+}
+
+// validateScopes checks requested scopes against the permission catalog and
+// returns them deduplicated and sorted
+func (s *apiKeyService) validateScopes(ctx context.Context, requested []string) ([]string, error) {
+	catalog, err := s.permissions.ListPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(catalog))
+	for _, p := range catalog {
+		known[p.Name] = true
+	}
+
+	seen := make(map[string]bool, len(requested))
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !known[scope] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+		if forbiddenAPIKeyScopes[scope] {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+type credentialVerifier struct {
+	tokens Service
+	keys   APIKeyService
+}
+
+// NewCredentialVerifier returns a verifier for AuthMiddleware that accepts
+// both access tokens and API keys, telling them apart by the API key prefix
+func NewCredentialVerifier(tokens Service, keys APIKeyService) middleware.TokenVerifier {
+	return &credentialVerifier{tokens: tokens, keys: keys}
+}
+
+func (v *credentialVerifier) VerifyAccessToken(ctx context.Context, token string) (*middleware.Identity, error) {
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return v.keys.VerifyAPIKey(ctx, token)
+	}
+	return v.tokens.VerifyAccessToken(ctx, token)
+}
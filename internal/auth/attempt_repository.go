@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Kinds of failed login counters
+const (
+	CounterUsername = "username"
+	CounterIP       = "ip"
+)
+
+// LoginAttempt is one entry of the login audit log
+type LoginAttempt struct {
+	ID            int64     `json:"id" db:"id"`
+	Username      string    `json:"username" db:"username"`
+	IPAddress     *string   `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent     *string   `json:"user_agent,omitempty" db:"user_agent"`
+	Success       bool      `json:"success" db:"success"`
+	FailureReason *string   `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// LoginAttemptFilter narrows down the login audit log
+type LoginAttemptFilter struct {
+	Username  string     `form:"username"`
+	IPAddress string     `form:"ip_address"`
+	Success   *bool      `form:"success"`
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit     int        `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// LoginCounterState is the current state of a failed login counter as seen by the database clock
+type LoginCounterState struct {
+	FailedCount          int   `db:"failed_count"`
+	SecondsSinceFailure  int64 `db:"seconds_since_failure"`
+	LockSecondsRemaining int64 `db:"lock_seconds_remaining"`
+}
+
+// LoginLockout is a counter that is currently locked
+type LoginLockout struct {
+	Kind         string    `json:"kind" db:"kind"`
+	Identifier   string    `json:"identifier" db:"identifier"`
+	FailedCount  int       `json:"failed_count" db:"failed_count"`
+	LastFailedAt time.Time `json:"last_failed_at" db:"last_failed_at"`
+	LockedUntil  time.Time `json:"locked_until" db:"locked_until"`
+}
+
+// AttemptRepository defines the interface for login attempt and lockout storage
+type AttemptRepository interface {
+	RecordAttempt(ctx context.Context, a *LoginAttempt) error
+	ListAttempts(ctx context.Context, filter LoginAttemptFilter) ([]LoginAttempt, error)
+	GetCounter(ctx context.Context, kind, identifier string) (*LoginCounterState, error)
+	RegisterFailure(ctx context.Context, kind, identifier string, window time.Duration, lockAfter int, lockDuration time.Duration) error
+	ResetCounter(ctx context.Context, kind, identifier string) error
+	ListLockouts(ctx context.Context) ([]LoginLockout, error)
+}
+
+type postgresAttemptRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAttemptRepository creates a new repository for login attempts and lockouts
+func NewPostgresAttemptRepository(db *sqlx.DB) AttemptRepository {
+	return &postgresAttemptRepository{db: db}
+}
+
+// RecordAttempt appends an entry to the login audit log
+func (r *postgresAttemptRepository) RecordAttempt(ctx context.Context, a *LoginAttempt) error {
+	query := `INSERT INTO login_attempts (username, ip_address, user_agent, success, failure_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, a.Username, a.IPAddress, a.UserAgent, a.Success, a.FailureReason).Scan(&a.ID, &a.CreatedAt)
+}
+
+// ListAttempts retrieves audit log entries matching the filter, newest first
+func (r *postgresAttemptRepository) ListAttempts(ctx context.Context, filter LoginAttemptFilter) ([]LoginAttempt, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(cond, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if filter.Username != "" {
+		add("username = ?", filter.Username)
+	}
+	if filter.IPAddress != "" {
+		add("ip_address = ?", filter.IPAddress)
+	}
+	if filter.Success != nil {
+		add("success = ?", *filter.Success)
+	}
+	if filter.Since != nil {
+		add("created_at >= ?", filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		add("created_at < ?", filter.Until.UTC())
+	}
+
+	query := `SELECT id, username, ip_address, user_agent, success, failure_reason, created_at FROM login_attempts`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += ` ORDER BY created_at DESC, id DESC LIMIT $` + strconv.Itoa(len(args))
+
+	var attempts []LoginAttempt
+	err := r.db.SelectContext(ctx, &attempts, query, args...)
+	return attempts, err
+}
+
+// GetCounter returns the state of a failed login counter, or a zero state if there is none
+func (r *postgresAttemptRepository) GetCounter(ctx context.Context, kind, identifier string) (*LoginCounterState, error) {
+	var state LoginCounterState
+	query := `SELECT failed_count,
+			EXTRACT(EPOCH FROM (NOW() - last_failed_at))::BIGINT AS seconds_since_failure,
+			COALESCE(GREATEST(CEIL(EXTRACT(EPOCH FROM (locked_until - NOW()))), 0), 0)::BIGINT AS lock_seconds_remaining
+		FROM login_counters WHERE kind = $1 AND identifier = $2`
+	err := r.db.GetContext(ctx, &state, query, kind, identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &LoginCounterState{}, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// RegisterFailure atomically counts a failed attempt. Counters whose last
+// failure is older than window start over; reaching lockAfter failures locks the
+// counter for lockDuration.
+func (r *postgresAttemptRepository) RegisterFailure(ctx context.Context, kind, identifier string, window time.Duration, lockAfter int, lockDuration time.Duration) error {
+	query := `INSERT INTO login_counters AS c (kind, identifier, failed_count, last_failed_at, locked_until)
+		VALUES ($1, $2, 1, NOW(), CASE WHEN $4 <= 1 THEN NOW() + $5 * INTERVAL '1 second' END)
+		ON CONFLICT (kind, identifier) DO UPDATE SET
+			failed_count = CASE WHEN c.last_failed_at < NOW() - $3 * INTERVAL '1 second' THEN 1 ELSE c.failed_count + 1 END,
+			last_failed_at = NOW(),
+			locked_until = CASE
+				WHEN (CASE WHEN c.last_failed_at < NOW() - $3 * INTERVAL '1 second' THEN 1 ELSE c.failed_count + 1 END) >= $4
+				THEN NOW() + $5 * INTERVAL '1 second'
+				ELSE c.locked_until END`
+	_, err := r.db.ExecContext(ctx, query, kind, identifier, int64(window.Seconds()), lockAfter, int64(lockDuration.Seconds()))
+	return err
+}
+
+// ResetCounter clears a failed login counter, lifting any lockout
+func (r *postgresAttemptRepository) ResetCounter(ctx context.Context, kind, identifier string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_counters WHERE kind = $1 AND identifier = $2`, kind, identifier)
+	return err
+}
+
+// ListLockouts retrieves all counters that are currently locked
+func (r *postgresAttemptRepository) ListLockouts(ctx context.Context) ([]LoginLockout, error) {
+	var lockouts []LoginLockout
+	query := `SELECT kind, identifier, failed_count, last_failed_at, locked_until FROM login_counters
+		WHERE locked_until > NOW() ORDER BY locked_until DESC`
+	err := r.db.SelectContext(ctx, &lockouts, query)
+	return lockouts, err
+}
@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
 )
 
 // Handler holds the dependencies for the auth handler
@@ -22,9 +26,26 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// LoginResponse represents the JSON body for a successful login
+// LoginResponse represents the JSON body for a successful login or token refresh
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token                  string `json:"token"`
+	RefreshToken           string `json:"refresh_token"`
+	TokenType              string `json:"token_type"`
+	ExpiresIn              int    `json:"expires_in"`
+	PasswordChangeRequired bool   `json:"password_change_required"`
+}
+
+// MFAChallengeResponse is returned by the password step of a login when the
+// account has MFA enabled. The mfa_token is redeemed at /login/mfa.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// RefreshRequest represents the JSON body for the token refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type ErrorResponse struct {
@@ -33,14 +54,17 @@ type ErrorResponse struct {
 
 // Login godoc
 // @Summary      User Login
-// @Description  Logs in a user and returns a JWT token.
+// @Description  Logs in a user and returns a short-lived JWT access token and a refresh token. For accounts with MFA enabled it instead returns 202 with an MFA challenge to complete at /login/mfa.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        credentials body LoginRequest true "Login Credentials"
 // @Success      200 {object} LoginResponse
+// @Success      202 {object} MFAChallengeResponse "MFA code required"
 // @Failure      400 {object} ErrorResponse "Invalid request body"
 // @Failure      401 {object} ErrorResponse "Invalid credentials"
+// @Failure      403 {object} ErrorResponse "Account deactivated"
+// @Failure      429 {object} ErrorResponse "Too many failed attempts; see the Retry-After header"
 // @Failure      500 {object} ErrorResponse "Internal server error"
 // @Router       /login [post]
 func (h *Handler) Login(c *gin.Context) {
@@ -50,15 +74,125 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.Login(c.Request.Context(), req.Username, req.Password)
+	client := ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	result, err := h.service.Login(c.Request.Context(), req.Username, req.Password, client)
 	if err != nil {
 		if err == ErrInvalidCredentials {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 			return
 		}
+		if err == ErrUserInactive {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
+			return
+		}
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts. Try again later."})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, LoginResponse{Token: token})
+	if result.MFAToken != "" {
+		c.JSON(http.StatusAccepted, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			ExpiresIn:   result.MFAExpiresIn,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(result.Tokens))
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new access token and a new refresh token. Reusing a refresh token revokes its whole session.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body RefreshRequest true "Refresh token"
+// @Success      200 {object} LoginResponse
+// @Failure      400 {object} ErrorResponse "Invalid request body"
+// @Failure      401 {object} ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /token/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrUserInactive) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(tokens))
+}
+
+// Logout godoc
+// @Summary      Log out
+// @Description  Revokes the current session, its refresh tokens and the access token used for this request.
+// @Tags         Auth
+// @Security     ApiKeyAuth
+// @Success      204 {object} nil
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity not found in context"})
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), identity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll godoc
+// @Summary      Log out everywhere
+// @Description  Revokes every session of the current user, invalidating all of their access and refresh tokens.
+// @Tags         Auth
+// @Security     ApiKeyAuth
+// @Success      204 {object} nil
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /logout/all [post]
+func (h *Handler) LogoutAll(c *gin.Context) {
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity not found in context"})
+		return
+	}
+
+	if err := h.service.LogoutAll(c.Request.Context(), identity.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func newLoginResponse(tokens *TokenPair) LoginResponse {
+	return LoginResponse{
+		Token:                  tokens.AccessToken,
+		RefreshToken:           tokens.RefreshToken,
+		TokenType:              "Bearer",
+		ExpiresIn:              tokens.ExpiresIn,
+		PasswordChangeRequired: tokens.PasswordChangeRequired,
+	}
 }
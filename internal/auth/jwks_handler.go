@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
+)
+
+// JWKSHandler serves the public keys that access tokens are signed with, so
+// other services can verify tokens without holding a signing key. Tokens name
+// their key in the "kid" header. It is mounted at /.well-known/jwks.json,
+// outside the API base path, so it is not part of the Swagger docs.
+func JWKSHandler(keys *jwtkeys.KeySet) gin.HandlerFunc {
+	jwks := keys.JWKS()
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwks)
+	}
+}
@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts; try again later")
+
+// LoginLockedError is returned while a username or client IP is throttled. It
+// matches ErrLoginLocked with errors.Is.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", ErrLoginLocked, e.RetryAfter)
+}
+
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// Failure reasons recorded in the login audit log
+const (
+	attemptInvalidCredentials = "invalid_credentials"
+	attemptInactive           = "inactive"
+	attemptLocked             = "locked"
+	attemptMFARequired        = "mfa_required"
+	attemptInvalidMFACode     = "invalid_mfa_code"
+)
+
+// LockoutPolicy configures login throttling. Failures are counted per submitted
+// username and per client IP; a counter whose last failure is older than Window
+// starts over.
+type LockoutPolicy struct {
+	// DelayAfter failures of a username, each further attempt has to wait
+	// BaseDelay, doubling per failure up to MaxDelay
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// LockAfter failures of a username lock it for LockDuration
+	LockAfter    int
+	LockDuration time.Duration
+	// IPLockAfter failures from one client IP, across all usernames, lock that IP
+	IPLockAfter int
+	Window      time.Duration
+}
+
+// DefaultLockoutPolicy returns the policy used when no overrides are configured
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		DelayAfter:   3,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		LockAfter:    10,
+		LockDuration: 15 * time.Minute,
+		IPLockAfter:  50,
+		Window:       15 * time.Minute,
+	}
+}
+
+// delay returns how long to wait after the last of failures consecutive failures
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	if p.DelayAfter <= 0 || failures < p.DelayAfter {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := p.DelayAfter; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// throttle holds the login counters shared by password logins and MFA
+// challenges
+type throttle struct {
+	attempts AttemptRepository
+	policy   LockoutPolicy
+}
+
+type throttledService struct {
+	Service
+	throttle
+}
+
+// NewThrottledService wraps an auth service so that password logins are
+// throttled and recorded in the login audit log. Counters are keyed by the
+// submitted username whether or not the account exists, so a lockout looks
+// the same for existing and unknown usernames.
+func NewThrottledService(inner Service, attempts AttemptRepository, policy LockoutPolicy) Service {
+	return &throttledService{Service: inner, throttle: throttle{attempts: attempts, policy: policy}}
+}
+
+// Login checks the username and IP counters before delegating to the wrapped
+// service. Bad passwords count as failures. A correct password only succeeds
+// and resets the username counter once tokens are issued; one that needs a
+// second factor is recorded as mfa_required and leaves the counters to the
+// throttled MFA service.
+func (s *throttledService) Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error) {
+	key := normalizeLoginName(username)
+
+	if err := s.checkThrottle(ctx, key, client.IPAddress); err != nil {
+		if errors.Is(err, ErrLoginLocked) {
+			if recErr := s.record(ctx, username, client, false, attemptLocked); recErr != nil {
+				return nil, recErr
+			}
+		}
+		return nil, err
+	}
+
+	result, err := s.Service.Login(ctx, username, password, client)
+	switch {
+	case err == nil && result.Tokens == nil:
+		if err := s.record(ctx, username, client, false, attemptMFARequired); err != nil {
+			return nil, err
+		}
+	case err == nil:
+		if err := s.attempts.ResetCounter(ctx, CounterUsername, key); err != nil {
+			return nil, err
+		}
+		if err := s.record(ctx, username, client, true, ""); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, ErrInvalidCredentials):
+		if err := s.registerFailure(ctx, key, client.IPAddress); err != nil {
+			return nil, err
+		}
+		if err := s.record(ctx, username, client, false, attemptInvalidCredentials); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, ErrUserInactive):
+		if err := s.record(ctx, username, client, false, attemptInactive); err != nil {
+			return nil, err
+		}
+	}
+	return result, err
+}
+
+type throttledMFAService struct {
+	MFAService
+	throttle
+	repo   Repository
+	tokens TokenRepository
+}
+
+// NewThrottledMFAService wraps an MFA service so that the second login step
+// counts against the same username and IP counters as password logins. A
+// wrong code is a failed attempt, so starting new challenges does not buy more
+// guesses than the lockout policy allows.
+func NewThrottledMFAService(inner MFAService, r Repository, tokens TokenRepository, attempts AttemptRepository, policy LockoutPolicy) MFAService {
+	return &throttledMFAService{MFAService: inner, throttle: throttle{attempts: attempts, policy: policy}, repo: r, tokens: tokens}
+}
+
+// CompleteLogin checks the counters of the challenged user before delegating
+// to the wrapped service and resets the username counter once tokens are issued
+func (s *throttledMFAService) CompleteLogin(ctx context.Context, mfaToken, code string, client ClientInfo) (*TokenPair, error) {
+	challenge, err := s.tokens.GetMFAChallenge(ctx, hashToken(mfaToken))
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+	key := normalizeLoginName(user.Username)
+
+	if err := s.checkThrottle(ctx, key, client.IPAddress); err != nil {
+		if errors.Is(err, ErrLoginLocked) {
+			if recErr := s.record(ctx, user.Username, client, false, attemptLocked); recErr != nil {
+				return nil, recErr
+			}
+		}
+		return nil, err
+	}
+
+	tokens, err := s.MFAService.CompleteLogin(ctx, mfaToken, code, client)
+	switch {
+	case err == nil:
+		if err := s.attempts.ResetCounter(ctx, CounterUsername, key); err != nil {
+			return nil, err
+		}
+		if err := s.record(ctx, user.Username, client, true, ""); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, ErrInvalidMFACode):
+		if err := s.registerFailure(ctx, key, client.IPAddress); err != nil {
+			return nil, err
+		}
+		if err := s.record(ctx, user.Username, client, false, attemptInvalidMFACode); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, ErrUserInactive):
+		if err := s.record(ctx, user.Username, client, false, attemptInactive); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, err
+}
+
+// checkThrottle returns a LoginLockedError if the username or the IP is locked
+// or the username is still inside its progressive delay
+func (s *throttle) checkThrottle(ctx context.Context, key, ip string) error {
+	user, err := s.attempts.GetCounter(ctx, CounterUsername, key)
+	if err != nil {
+		return err
+	}
+	if user.LockSecondsRemaining > 0 {
+		return &LoginLockedError{RetryAfter: time.Duration(user.LockSecondsRemaining) * time.Second}
+	}
+	if time.Duration(user.SecondsSinceFailure)*time.Second < s.policy.Window {
+		wait := s.policy.delay(user.FailedCount) - time.Duration(user.SecondsSinceFailure)*time.Second
+		if wait > 0 {
+			return &LoginLockedError{RetryAfter: wait}
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	byIP, err := s.attempts.GetCounter(ctx, CounterIP, ip)
+	if err != nil {
+		return err
+	}
+	if byIP.LockSecondsRemaining > 0 {
+		return &LoginLockedError{RetryAfter: time.Duration(byIP.LockSecondsRemaining) * time.Second}
+	}
+	return nil
+}
+
+func (s *throttle) registerFailure(ctx context.Context, key, ip string) error {
+	if err := s.attempts.RegisterFailure(ctx, CounterUsername, key, s.policy.Window, s.policy.LockAfter, s.policy.LockDuration); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.attempts.RegisterFailure(ctx, CounterIP, ip, s.policy.Window, s.policy.IPLockAfter, s.policy.LockDuration)
+}
+
+func (s *throttle) record(ctx context.Context, username string, client ClientInfo, success bool, reason string) error {
+	if len(username) > 255 {
+		username = username[:255]
+	}
+	return s.attempts.RecordAttempt(ctx, &LoginAttempt{
+		Username:      username,
+		IPAddress:     nonEmpty(client.IPAddress),
+		UserAgent:     nonEmpty(client.UserAgent),
+		Success:       success,
+		FailureReason: nonEmpty(reason),
+	})
+}
+
+// LockoutService lets admins inspect login attempts and lift lockouts
+type LockoutService interface {
+	ListAttempts(ctx context.Context, filter LoginAttemptFilter) ([]LoginAttempt, error)
+	ListLockouts(ctx context.Context) ([]LoginLockout, error)
+	UnlockUser(ctx context.Context, userID int) error
+	UnlockIP(ctx context.Context, ip string) error
+}
+
+type lockoutService struct {
+	repo     Repository
+	attempts AttemptRepository
+}
+
+// NewLockoutService creates a new lockout administration service
+func NewLockoutService(r Repository, attempts AttemptRepository) LockoutService {
+	return &lockoutService{repo: r, attempts: attempts}
+}
+
+// ListAttempts retrieves the login audit log
+func (s *lockoutService) ListAttempts(ctx context.Context, filter LoginAttemptFilter) ([]LoginAttempt, error) {
+	return s.attempts.ListAttempts(ctx, filter)
+}
+
+// ListLockouts retrieves all usernames and IPs that are currently locked
+func (s *lockoutService) ListLockouts(ctx context.Context) ([]LoginLockout, error) {
+	return s.attempts.ListLockouts(ctx)
+}
+
+// UnlockUser clears the failed login counter of a user's username
+func (s *lockoutService) UnlockUser(ctx context.Context, userID int) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.attempts.ResetCounter(ctx, CounterUsername, normalizeLoginName(user.Username))
+}
+
+// UnlockIP clears the failed login counter of a client IP
+func (s *lockoutService) UnlockIP(ctx context.Context, ip string) error {
+	return s.attempts.ResetCounter(ctx, CounterIP, ip)
+}
+
+// normalizeLoginName maps variants of a username onto the same counter
+func normalizeLoginName(username string) string {
+	name := strings.ToLower(strings.TrimSpace(username))
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
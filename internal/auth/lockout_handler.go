@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler holds the dependencies for the login audit and lockout handlers
+type LockoutHandler struct {
+	service LockoutService
+}
+
+// NewLockoutHandler creates a new lockout administration handler
+func NewLockoutHandler(s LockoutService) *LockoutHandler {
+	return &LockoutHandler{service: s}
+}
+
+// ListAttempts godoc
+// @Summary      List login attempts (Admin only)
+// @Description  Retrieves the login audit log, newest first. Every password login attempt is recorded with its outcome, client IP and user agent.
+// @Tags         Security
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        username    query     string  false  "Submitted username"
+// @Param        ip_address  query     string  false  "Client IP address"
+// @Param        success     query     bool    false  "Only successful or only failed attempts"
+// @Param        since       query     string  false  "RFC 3339 timestamp, inclusive"
+// @Param        until       query     string  false  "RFC 3339 timestamp, exclusive"
+// @Param        limit       query     int     false  "Maximum number of entries (default 100, max 500)"
+// @Success      200  {array}   LoginAttempt
+// @Failure      400  {object}  ErrorResponse "Invalid filter"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /login-attempts [get]
+func (h *LockoutHandler) ListAttempts(c *gin.Context) {
+	var filter LoginAttemptFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter: " + err.Error()})
+		return
+	}
+
+	attempts, err := h.service.ListAttempts(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
+
+// ListLockouts godoc
+// @Summary      List active lockouts (Admin only)
+// @Description  Retrieves all usernames and client IPs that are currently locked out of login.
+// @Tags         Security
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   LoginLockout
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /login-lockouts [get]
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+	lockouts, err := h.service.ListLockouts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// UnlockIP godoc
+// @Summary      Unlock a client IP (Admin only)
+// @Description  Clears the failed login counter of a client IP address.
+// @Tags         Security
+// @Security     ApiKeyAuth
+// @Param        ip   query     string  true  "Client IP address"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse "Missing IP address"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /login-lockouts/ip [delete]
+func (h *LockoutHandler) UnlockIP(c *gin.Context) {
+	ip := c.Query("ip")
+	if ip == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'ip' is required"})
+		return
+	}
+
+	if err := h.service.UnlockIP(c.Request.Context(), ip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock IP: " + err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockUser godoc
+// @Summary      Unlock a staff account (Admin only)
+// @Description  Clears the failed login counter of the user's username, lifting a lockout.
+// @Tags         Users
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse "Invalid user ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /users/{id}/unlock [post]
+func (h *LockoutHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.UnlockUser(c.Request.Context(), id); err != nil {
+		writeUserError(c, err, "Failed to unlock user")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
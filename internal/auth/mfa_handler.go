@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// MFAHandler holds the dependencies for the MFA handlers
+type MFAHandler struct {
+	service MFAService
+}
+
+// NewMFAHandler creates a new MFA handler
+func NewMFAHandler(s MFAService) *MFAHandler {
+	return &MFAHandler{service: s}
+}
+
+// CompleteLogin godoc
+// @Summary      Complete an MFA login
+// @Description  Redeems the MFA challenge returned by /login with a TOTP code or a one-time recovery code and returns the session tokens.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body MFALoginRequest true "MFA challenge token and code"
+// @Success      200 {object} LoginResponse
+// @Failure      400 {object} ErrorResponse "Invalid request body"
+// @Failure      401 {object} ErrorResponse "Invalid code or challenge"
+// @Failure      403 {object} ErrorResponse "Account deactivated"
+// @Failure      429 {object} ErrorResponse "Too many failed login attempts; see Retry-After"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /login/mfa [post]
+func (h *MFAHandler) CompleteLogin(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	client := ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	tokens, err := h.service.CompleteLogin(c.Request.Context(), req.MFAToken, req.Code, client)
+	if err != nil {
+		var locked *LoginLockedError
+		switch {
+		case errors.Is(err, ErrInvalidMFAChallenge), errors.Is(err, ErrInvalidMFACode):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUserInactive):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
+		case errors.As(err, &locked):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts. Try again later."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(tokens))
+}
+
+// GetStatus godoc
+// @Summary      Get own MFA status
+// @Description  Reports whether MFA is enabled for the signed-in user and how many recovery codes are left.
+// @Tags         MFA
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} MFAStatus
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /me/mfa [get]
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID))
+	if err != nil {
+		writeMFAError(c, err, "Failed to get MFA status")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll godoc
+// @Summary      Start MFA enrollment
+// @Description  Generates a new TOTP secret and otpauth URI for the signed-in user. MFA is enforced only after activation.
+// @Tags         MFA
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} MFAEnrollment
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      409 {object} ErrorResponse "MFA already enabled"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /me/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.service.Enroll(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID))
+	if err != nil {
+		writeMFAError(c, err, "Failed to start MFA enrollment")
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Activate godoc
+// @Summary      Activate MFA
+// @Description  Confirms enrollment with a code from the authenticator app and returns one-time recovery codes. The codes are shown only once.
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body body MFACodeRequest true "TOTP code"
+// @Success      200 {object} RecoveryCodesResponse
+// @Failure      400 {object} ErrorResponse "Invalid code or enrollment not started"
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      409 {object} ErrorResponse "MFA already enabled"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /me/mfa/activate [post]
+func (h *MFAHandler) Activate(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	codes, err := h.service.Activate(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), req.Code)
+	if err != nil {
+		writeMFAError(c, err, "Failed to activate MFA")
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replaces all recovery codes of the signed-in user. Requires a current TOTP code.
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body body MFACodeRequest true "TOTP code"
+// @Success      200 {object} RecoveryCodesResponse
+// @Failure      400 {object} ErrorResponse "Invalid code or MFA not enabled"
+// @Failure      401 {object} ErrorResponse "Unauthorized"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /me/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), req.Code)
+	if err != nil {
+		writeMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// ResetMFA godoc
+// @Summary      Reset a user's MFA (Admin only)
+// @Description  Removes the user's TOTP secret and recovery codes and revokes their sessions, e.g. after a lost device.
+// @Tags         Users
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse "Invalid user ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /users/{id}/mfa [delete]
+func (h *MFAHandler) ResetMFA(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.ResetMFA(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), id); err != nil {
+		writeMFAError(c, err, "Failed to reset MFA")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// writeMFAError maps MFA service errors onto HTTP responses
+func writeMFAError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrInvalidMFACode), errors.Is(err, ErrMFANotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		writeUserError(c, err, msg)
+	}
+}
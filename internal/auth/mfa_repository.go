@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MFARepository defines the interface for TOTP secret and recovery code storage
+type MFARepository interface {
+	GetMFAStatus(ctx context.Context, userID int) (*MFAStatus, error)
+	SetPendingMFASecret(ctx context.Context, userID int, secret string) error
+	EnableMFA(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	DisableMFA(ctx context.Context, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+}
+
+type postgresMFARepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresMFARepository creates a new repository for MFA data
+func NewPostgresMFARepository(db *sqlx.DB) MFARepository {
+	return &postgresMFARepository{db: db}
+}
+
+// GetMFAStatus reports whether MFA is enabled for a user and how many recovery codes are left
+func (r *postgresMFARepository) GetMFAStatus(ctx context.Context, userID int) (*MFAStatus, error) {
+	var status MFAStatus
+	query := `SELECT u.mfa_enabled, u.mfa_enrolled_at,
+			(SELECT COUNT(*) FROM mfa_recovery_codes rc WHERE rc.user_id = u.id AND rc.used_at IS NULL)
+		FROM users u WHERE u.id = $1`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&status.Enabled, &status.EnrolledAt, &status.RecoveryCodesRemaining)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetPendingMFASecret stores a secret that only takes effect once EnableMFA is called
+func (r *postgresMFARepository) SetPendingMFASecret(ctx context.Context, userID int, secret string) error {
+	query := `UPDATE users SET mfa_secret = $2, mfa_last_used_step = NULL WHERE id = $1 AND mfa_enabled = FALSE`
+	res, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableMFA turns on the pending secret and stores the user's first set of recovery codes
+func (r *postgresMFARepository) EnableMFA(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET mfa_enabled = TRUE, mfa_enrolled_at = NOW() WHERE id = $1 AND mfa_secret IS NOT NULL`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableMFA removes the secret and every recovery code of a user
+func (r *postgresMFARepository) DisableMFA(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET mfa_enabled = FALSE, mfa_secret = NULL, mfa_enrolled_at = NULL, mfa_last_used_step = NULL WHERE id = $1`
+	res, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkUserRowsAffected(res); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes invalidates all existing recovery codes of a user and stores new ones
+func (r *postgresMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode atomically marks an unused recovery code as used.
+// It returns ErrInvalidMFACode when the code does not exist or was already used.
+func (r *postgresMFARepository) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code. It reports false
+// when that step (or a later one) was already used, so a code cannot be replayed.
+func (r *postgresMFARepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE users SET mfa_last_used_step = $2
+		WHERE id = $1 AND (mfa_last_used_step IS NULL OR mfa_last_used_step < $2)`
+	res, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID int, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		query := `INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`
+		if _, err := tx.ExecContext(ctx, query, userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/pkg/totp"
+)
+
+var (
+	ErrInvalidMFAChallenge = errors.New("MFA challenge is invalid, expired or already used")
+	ErrInvalidMFACode      = errors.New("MFA code is invalid")
+	ErrMFAAlreadyEnabled   = errors.New("MFA is already enabled for this account")
+	ErrMFANotEnrolled      = errors.New("MFA enrollment has not been started")
+)
+
+const (
+	mfaChallengeTTL      = 5 * time.Minute
+	mfaMaxAttempts       = 5
+	recoveryCodeCount    = 10
+	totpSkew             = 1
+	defaultMFAIssuerName = "Medical Portal"
+)
+
+// MFAOptions configures TOTP enrollment
+type MFAOptions struct {
+	// Issuer is the account label shown in authenticator apps
+	Issuer string
+}
+
+// MFAService provides TOTP enrollment, recovery codes and the second login step
+type MFAService interface {
+	GetStatus(ctx context.Context, userID int) (*MFAStatus, error)
+	Enroll(ctx context.Context, userID int) (*MFAEnrollment, error)
+	Activate(ctx context.Context, userID int, code string) (*RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) (*RecoveryCodesResponse, error)
+	ResetMFA(ctx context.Context, actorID, userID int) error
+	CompleteLogin(ctx context.Context, mfaToken, code string, client ClientInfo) (*TokenPair, error)
+}
+
+type mfaService struct {
+	repo    Repository
+	mfa     MFARepository
+	tokens  TokenRepository
+	authSvc Service
+	opts    MFAOptions
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService(r Repository, mfa MFARepository, tokens TokenRepository, authSvc Service, opts MFAOptions) MFAService {
+	if opts.Issuer == "" {
+		opts.Issuer = defaultMFAIssuerName
+	}
+	return &mfaService{repo: r, mfa: mfa, tokens: tokens, authSvc: authSvc, opts: opts}
+}
+
+// GetStatus reports the MFA state of a user
+func (s *mfaService) GetStatus(ctx context.Context, userID int) (*MFAStatus, error) {
+	return s.mfa.GetMFAStatus(ctx, userID)
+}
+
+// Enroll generates a new pending TOTP secret. MFA is not enforced until the
+// user proves their authenticator works by calling Activate with a valid code.
+func (s *mfaService) Enroll(ctx context.Context, userID int) (*MFAEnrollment, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.SetPendingMFASecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.opts.Issuer, user.Username, secret),
+	}, nil
+}
+
+// Activate confirms a pending enrollment with a code from the authenticator app,
+// enables MFA and returns the first set of recovery codes
+func (s *mfaService) Activate(ctx context.Context, userID int, code string) (*RecoveryCodesResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.EnableMFA(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of a user. It requires a
+// current TOTP code so a hijacked session alone cannot mint new codes.
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) (*RecoveryCodesResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled || user.MFASecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// ResetMFA lets an admin remove a user's MFA, e.g. after a lost device. The
+// user's sessions are revoked and they can enroll again after signing in.
+func (s *mfaService) ResetMFA(ctx context.Context, actorID, userID int) error {
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+	if err := s.mfa.DisableMFA(ctx, userID); err != nil {
+		return err
+	}
+	return s.authSvc.LogoutAll(ctx, userID)
+}
+
+// CompleteLogin redeems an MFA challenge with either a TOTP code or a one-time
+// recovery code and opens the session. Each wrong code counts against the
+// challenge, which is burned after mfaMaxAttempts failures.
+func (s *mfaService) CompleteLogin(ctx context.Context, mfaToken, code string, client ClientInfo) (*TokenPair, error) {
+	challenge, err := s.tokens.GetMFAChallenge(ctx, hashToken(mfaToken))
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Attempts >= mfaMaxAttempts {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.repo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	if !user.MFAEnabled || user.MFASecret == nil {
+		// MFA was reset after the challenge was issued
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	if err := s.verifyLoginCode(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := s.tokens.RecordMFAChallengeFailure(ctx, challenge.ID, mfaMaxAttempts); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.tokens.ConsumeMFAChallenge(ctx, challenge.ID); err != nil {
+		return nil, err
+	}
+	return s.authSvc.StartSession(ctx, user, client)
+}
+
+// verifyLoginCode accepts a TOTP code or, failing the TOTP format, a recovery code
+func (s *mfaService) verifyLoginCode(ctx context.Context, user *User, code string) error {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return s.verifyTOTP(ctx, user, code)
+	}
+	return s.mfa.ConsumeRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// verifyTOTP checks a code against the user's secret and rejects replays of a
+// code that was already accepted
+func (s *mfaService) verifyTOTP(ctx context.Context, user *User, code string) error {
+	step, ok := totp.Validate(*user.MFASecret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	fresh, err := s.mfa.UseTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns a new set of recovery codes formatted as
+// "xxxxx-xxxxx" together with the hashes under which they are stored
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes tolerant of case, spaces and dashes
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package auth
+
+import "time"
+
+// Roles a staff account can hold
+const (
+	RoleReceptionist = "receptionist"
+	RoleDoctor       = "doctor"
+	RoleAdmin        = "admin"
+	// RolePatient is held by patient portal accounts, which are created
+	// through an invitation rather than by an admin
+	RolePatient = "patient"
+)
+
+// User represents a user in the system
+type User struct {
+	ID                 int        `json:"id" db:"id"`
+	Username           string     `json:"username" db:"username"`
+	Email              *string    `json:"email,omitempty" db:"email"`
+	PasswordHash       string     `json:"-" db:"password_hash"`
+	Role               string     `json:"role" db:"role"`
+	IsActive           bool       `json:"is_active" db:"is_active"`
+	MustChangePassword bool       `json:"must_change_password" db:"must_change_password"`
+	PasswordChangedAt  *time.Time `json:"password_changed_at,omitempty" db:"password_changed_at"`
+	MFAEnabled         bool       `json:"mfa_enabled" db:"mfa_enabled"`
+	MFASecret          *string    `json:"-" db:"mfa_secret"`
+	PatientID          *int       `json:"patient_id,omitempty" db:"patient_id"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateUserRequest is used by admins to create a new staff account
+type CreateUserRequest struct {
+	Username string  `json:"username" binding:"required,min=3,max=50"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Password string  `json:"password" binding:"required,min=8"`
+	Role     string  `json:"role" binding:"required,oneof=receptionist doctor admin"`
+}
+
+// UpdateUserRequest is used by admins to update a staff account's profile
+type UpdateUserRequest struct {
+	Username string  `json:"username" binding:"required,min=3,max=50"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+// UpdateUserRoleRequest is used by admins to change a staff account's role
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=receptionist doctor admin"`
+}
+
+// ChangePasswordRequest is used by a signed-in user to change their own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// ResetPasswordRequest is used to set a new password with a one-time reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+// PasswordResetResponse describes a reset token that was issued and delivered
+type PasswordResetResponse struct {
+	UserID    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MFALoginRequest completes a login that was answered with an MFA challenge
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFACodeRequest carries a code from the user's authenticator app
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAStatus describes the two-factor state of an account
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnrolledAt             *time.Time `json:"enrolled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollment is the pending TOTP secret returned when enrollment starts
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse lists freshly generated recovery codes. They are only ever shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// CreateAPIKeyRequest is used to issue a new API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// CreatedAPIKeyResponse is returned once when an API key is created. The key
+// cannot be retrieved again.
+type CreatedAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// PasswordHandler holds the dependencies for the password management handlers
+type PasswordHandler struct {
+	service PasswordService
+}
+
+// NewPasswordHandler creates a new password management handler
+func NewPasswordHandler(s PasswordService) *PasswordHandler {
+	return &PasswordHandler{service: s}
+}
+
+// ChangePassword godoc
+// @Summary      Change own password
+// @Description  Changes the signed-in user's password. Requires the current password and revokes all other sessions.
+// @Tags         Auth
+// @Accept       json
+// @Security     ApiKeyAuth
+// @Param        body body ChangePasswordRequest true "Current and new password"
+// @Success      204 {object} nil
+// @Failure      400 {object} ErrorResponse "Invalid request body or unchanged password"
+// @Failure      401 {object} ErrorResponse "Current password is incorrect"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /me/password [put]
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity not found in context"})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	err := h.service.ChangePassword(c.Request.Context(), identity, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		case errors.Is(err, ErrPasswordUnchanged):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password: " + err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// IssuePasswordReset godoc
+// @Summary      Issue a password reset (Admin only)
+// @Description  Sends a single-use, expiring reset link to the user and forces a password change at their next login.
+// @Tags         Users
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      202  {object}  PasswordResetResponse
+// @Failure      400  {object}  ErrorResponse "Invalid user ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /users/{id}/password-reset [post]
+func (h *PasswordHandler) IssuePasswordReset(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	reset, err := h.service.IssuePasswordReset(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue password reset: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, reset)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password with a reset token
+// @Description  Sets a new password using a one-time reset token. All existing sessions of the user are revoked.
+// @Tags         Auth
+// @Accept       json
+// @Param        body body ResetPasswordRequest true "Reset token and new password"
+// @Success      204 {object} nil
+// @Failure      400 {object} ErrorResponse "Invalid request body or reset token"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /password/reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req); err != nil {
+		if errors.Is(err, ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/notify"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("password reset token is invalid, expired or already used")
+	ErrPasswordUnchanged = errors.New("new password must differ from the current password")
+)
+
+// PasswordOptions configures password reset delivery
+type PasswordOptions struct {
+	// ResetURL is the frontend page that accepts a reset token as its "token" query parameter
+	ResetURL string
+	ResetTTL time.Duration
+}
+
+// PasswordService provides self-service password changes and admin-issued resets
+type PasswordService interface {
+	ChangePassword(ctx context.Context, identity *middleware.Identity, req ChangePasswordRequest) error
+	IssuePasswordReset(ctx context.Context, actorID, userID int) (*PasswordResetResponse, error)
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+}
+
+type passwordService struct {
+	repo    Repository
+	tokens  TokenRepository
+	authSvc Service
+	sender  notify.Sender
+	opts    PasswordOptions
+}
+
+// NewPasswordService creates a new password management service
+func NewPasswordService(r Repository, tokens TokenRepository, authSvc Service, sender notify.Sender, opts PasswordOptions) PasswordService {
+	return &passwordService{repo: r, tokens: tokens, authSvc: authSvc, sender: sender, opts: opts}
+}
+
+// ChangePassword verifies the current password and stores the new one. Every
+// other session of the user is revoked; the session making the change survives.
+func (s *passwordService) ChangePassword(ctx context.Context, identity *middleware.Identity, req ChangePasswordRequest) error {
+	user, err := s.repo.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !s.authSvc.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+	if req.CurrentPassword == req.NewPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hash, err := s.authSvc.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, hash, false); err != nil {
+		return err
+	}
+	return s.tokens.RevokeOtherSessions(ctx, user.ID, identity.SessionID)
+}
+
+// IssuePasswordReset creates a single-use reset token for a user, flags the
+// account for a forced password change and delivers the reset link through the
+// configured sender. The token itself is never returned to the admin.
+func (s *passwordService) IssuePasswordReset(ctx context.Context, actorID, userID int) (*PasswordResetResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := s.repo.CreatePasswordResetToken(ctx, user.ID, actorID, hashToken(token), s.opts.ResetTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetMustChangePassword(ctx, user.ID, true); err != nil {
+		return nil, err
+	}
+
+	if err := s.sender.Send(ctx, s.resetMessage(user, token)); err != nil {
+		return nil, fmt.Errorf("failed to deliver password reset: %w", err)
+	}
+
+	return &PasswordResetResponse{UserID: user.ID, ExpiresAt: expiresAt}, nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and revokes every
+// session of the user
+func (s *passwordService) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	userID, err := s.repo.ConsumePasswordResetToken(ctx, hashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.authSvc.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePassword(ctx, userID, hash, false); err != nil {
+		return err
+	}
+	return s.authSvc.LogoutAll(ctx, userID)
+}
+
+func (s *passwordService) resetMessage(user *User, token string) notify.Message {
+	to := user.Username
+	if user.Email != nil {
+		to = *user.Email
+	}
+
+	link := s.opts.ResetURL + "?token=" + token
+	if u, err := url.Parse(s.opts.ResetURL); err == nil {
+		q := u.Query()
+		q.Set("token", token)
+		u.RawQuery = q.Encode()
+		link = u.String()
+	}
+
+	return notify.Message{
+		To:      to,
+		Subject: "Reset your Medical Portal password",
+		Body: fmt.Sprintf("Hello %s,\n\nAn administrator has requested a password reset for your account. "+
+			"Use the link below to choose a new password. It can be used once and expires in %s.\n\n%s\n",
+			user.Username, s.opts.ResetTTL, link),
+	}
+}
@@ -4,21 +4,25 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-// User represents a user in the systemm
-type User struct {
-	ID           int    `db:"id"`
-	Username     string `db:"username"`
-	PasswordHash string `db:"password_hash"`
-	Role         string `db:"role"`
-}
-
 // Repository defines the interface for user data storage
 type Repository interface {
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserByID(ctx context.Context, id int) (*User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	CreateUser(ctx context.Context, user *User) error
+	UpdateUser(ctx context.Context, user *User) error
+	UpdateUserRole(ctx context.Context, id int, role string) error
+	SetUserActive(ctx context.Context, id int, active bool) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string, mustChange bool) error
+	SetMustChangePassword(ctx context.Context, id int, mustChange bool) error
+	CreatePasswordResetToken(ctx context.Context, userID, createdBy int, tokenHash string, ttl time.Duration) (time.Time, error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int, error)
 }
 
 type postgresRepository struct {
@@ -30,10 +34,12 @@ func NewPostgresRepository(db *sqlx.DB) Repository {
 	return &postgresRepository{db: db}
 }
 
+const userColumns = `id, username, email, password_hash, role, is_active, must_change_password, password_changed_at, mfa_enabled, mfa_secret, patient_id, created_at, updated_at`
+
 // GetUserByUsername retrieves a user by their username
 func (r *postgresRepository) GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	var user User
-	query := `SELECT id, username, password_hash, role FROM users WHERE username = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
 	err := r.db.GetContext(ctx, &user, query, username)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -43,3 +49,137 @@ func (r *postgresRepository) GetUserByUsername(ctx context.Context, username str
 	}
 	return &user, nil
 }
+
+// GetUserByID retrieves a user by their ID
+func (r *postgresRepository) GetUserByID(ctx context.Context, id int) (*User, error) {
+	var user User
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	err := r.db.GetContext(ctx, &user, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers retrieves all staff accounts ordered by username
+func (r *postgresRepository) ListUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY username ASC`
+	err := r.db.SelectContext(ctx, &users, query)
+	return users, err
+}
+
+// CreateUser inserts a new user and fills in the generated fields
+func (r *postgresRepository) CreateUser(ctx context.Context, u *User) error {
+	query := `INSERT INTO users (username, email, password_hash, role, is_active, must_change_password) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query, u.Username, u.Email, u.PasswordHash, u.Role, u.IsActive, u.MustChangePassword).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+	return mapUserWriteError(err)
+}
+
+// UpdateUser updates a user's profile fields
+func (r *postgresRepository) UpdateUser(ctx context.Context, u *User) error {
+	query := `UPDATE users SET username = $1, email = $2 WHERE id = $3`
+	res, err := r.db.ExecContext(ctx, query, u.Username, u.Email, u.ID)
+	if err != nil {
+		return mapUserWriteError(err)
+	}
+	return checkUserRowsAffected(res)
+}
+
+// UpdateUserRole changes the role assigned to a user
+func (r *postgresRepository) UpdateUserRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role = $1 WHERE id = $2`
+	res, err := r.db.ExecContext(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+	return checkUserRowsAffected(res)
+}
+
+// SetUserActive activates or deactivates a user
+func (r *postgresRepository) SetUserActive(ctx context.Context, id int, active bool) error {
+	query := `UPDATE users SET is_active = $1 WHERE id = $2`
+	res, err := r.db.ExecContext(ctx, query, active, id)
+	if err != nil {
+		return err
+	}
+	return checkUserRowsAffected(res)
+}
+
+// UpdatePassword stores a new password hash for a user
+func (r *postgresRepository) UpdatePassword(ctx context.Context, id int, passwordHash string, mustChange bool) error {
+	query := `UPDATE users SET password_hash = $1, must_change_password = $2, password_changed_at = NOW() WHERE id = $3`
+	res, err := r.db.ExecContext(ctx, query, passwordHash, mustChange, id)
+	if err != nil {
+		return err
+	}
+	return checkUserRowsAffected(res)
+}
+
+// SetMustChangePassword flags whether a user has to change their password at next login
+func (r *postgresRepository) SetMustChangePassword(ctx context.Context, id int, mustChange bool) error {
+	query := `UPDATE users SET must_change_password = $1 WHERE id = $2`
+	res, err := r.db.ExecContext(ctx, query, mustChange, id)
+	if err != nil {
+		return err
+	}
+	return checkUserRowsAffected(res)
+}
+
+// CreatePasswordResetToken stores a reset token hash, replacing any unused tokens
+// previously issued to the user, and returns its expiry
+func (r *postgresRepository) CreatePasswordResetToken(ctx context.Context, userID, createdBy int, tokenHash string, ttl time.Duration) (time.Time, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	var expiresAt time.Time
+	query := `INSERT INTO password_reset_tokens (user_id, token_hash, created_by, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second', NOW()) RETURNING expires_at`
+	if err := tx.QueryRowContext(ctx, query, userID, tokenHash, createdBy, int64(ttl.Seconds())).Scan(&expiresAt); err != nil {
+		return time.Time{}, err
+	}
+	return expiresAt, tx.Commit()
+}
+
+// ConsumePasswordResetToken atomically marks an unused, unexpired reset token as
+// used and returns the ID of the user it was issued for
+func (r *postgresRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+	query := `UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() RETURNING user_id`
+	err := r.db.GetContext(ctx, &userID, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidResetToken
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+func checkUserRowsAffected(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err == nil && rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// mapUserWriteError translates a unique violation on users.username or users.email into ErrUsernameTaken
+func mapUserWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrUsernameTaken
+	}
+	return err
+}
@@ -2,62 +2,258 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid username or password")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUserInactive        = fmt.Errorf("%w: user account is deactivated", middleware.ErrUnauthenticated)
+	ErrInvalidToken        = fmt.Errorf("%w: token is invalid or expired", middleware.ErrUnauthenticated)
+	ErrTokenRevoked        = fmt.Errorf("%w: token has been revoked", middleware.ErrUnauthenticated)
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used; session revoked")
 )
 
+// dummyPasswordHash is a bcrypt hash at the cost HashPassword uses, compared
+// against when a login names an unknown user
+const dummyPasswordHash = "$2a$14$nIstmnMWFT0TNn9nMrxgAujvzgSYsoSVFU1R7R2Kj35ta0an98vFe"
+
+// Options configures how the auth service issues tokens
+type Options struct {
+	// Keys signs new access tokens and verifies tokens from every key still in rotation
+	Keys *jwtkeys.KeySet
+	// Issuer is set as the "iss" claim and required when verifying, if not empty
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// ClientInfo describes the client a login or refresh request came from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// TokenPair is the set of tokens returned by a successful login or refresh
+type TokenPair struct {
+	AccessToken            string
+	RefreshToken           string
+	ExpiresIn              int
+	PasswordChangeRequired bool
+}
+
+// LoginResult is the outcome of the password step of a login. Users without
+// MFA get Tokens straight away; enrolled users get an MFAToken instead, which
+// has to be redeemed together with a second-factor code.
+type LoginResult struct {
+	Tokens       *TokenPair
+	MFAToken     string
+	MFAExpiresIn int
+}
+
 // Service provides authentication logic
 type Service interface {
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error)
+	StartSession(ctx context.Context, user *User, client ClientInfo) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, identity *middleware.Identity) error
+	LogoutAll(ctx context.Context, userID int) error
+	VerifyAccessToken(ctx context.Context, token string) (*middleware.Identity, error)
 	HashPassword(password string) (string, error)
 	CheckPasswordHash(password, hash string) bool
 }
 
 type service struct {
-	repo         Repository
-	jwtSecretKey string
+	repo   Repository
+	tokens TokenRepository
+	opts   Options
 }
 
 // NewService creates a new auth service
-func NewService(r Repository, jwtSecret string) Service {
-	return &service{repo: r, jwtSecretKey: jwtSecret}
+func NewService(r Repository, tokens TokenRepository, opts Options) Service {
+	return &service{repo: r, tokens: tokens, opts: opts}
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *service) Login(ctx context.Context, username, password string) (string, error) {
+// Login checks a user's password. Without MFA it opens a new session and returns
+// its first token pair; with MFA it only issues a short-lived challenge.
+func (s *service) Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error) {
 	user, err := s.repo.GetUserByUsername(ctx, username)
 	if err != nil {
 		if err == ErrUserNotFound {
-			return "", ErrInvalidCredentials
+			// Spend the same bcrypt time as for a real account so response
+			// times do not reveal which usernames exist
+			s.CheckPasswordHash(password, dummyPasswordHash)
+			return nil, ErrInvalidCredentials
 		}
-		return "", err
+		return nil, err
 	}
 
 	if !s.CheckPasswordHash(password, user.PasswordHash) {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
 
-	// Create JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  user.ID,
-		"user": user.Username,
-		"role": user.Role,
-		"exp":  time.Now().Add(time.Hour * 72).Unix(),
-	})
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
 
-	tokenString, err := token.SignedString([]byte(s.jwtSecretKey))
+	if user.MFAEnabled {
+		token, err := randomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.tokens.CreateMFAChallenge(ctx, user.ID, hashToken(token), mfaChallengeTTL); err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: token, MFAExpiresIn: int(mfaChallengeTTL.Seconds())}, nil
+	}
+
+	tokens, err := s.StartSession(ctx, user, client)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// StartSession opens a new session for an already authenticated user and
+// returns its first token pair
+func (s *service) StartSession(ctx context.Context, user *User, client ClientInfo) (*TokenPair, error) {
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		UserAgent: nonEmpty(client.UserAgent),
+		IPAddress: nonEmpty(client.IPAddress),
+	}
+	if err := s.tokens.CreateSession(ctx, session, s.opts.RefreshTokenTTL); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// Refresh rotates a refresh token. Each refresh token can be used exactly once;
+// presenting one that was already rotated revokes the whole session, since it
+// means either the client or an attacker holds a stolen copy.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	hash := hashToken(refreshToken)
+
+	stored, err := s.tokens.ConsumeRefreshToken(ctx, hash)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidRefreshToken) {
+			return nil, err
+		}
+		previous, err := s.tokens.GetRefreshToken(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if previous.UsedAt != nil {
+			if err := s.tokens.RevokeSession(ctx, previous.SessionID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
 	}
-	return tokenString, nil
+
+	session, err := s.tokens.GetActiveSession(ctx, stored.SessionID)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		if err := s.tokens.RevokeSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrUserInactive
+	}
+
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// Logout revokes the caller's session and the access token used for the request
+func (s *service) Logout(ctx context.Context, identity *middleware.Identity) error {
+	if err := s.tokens.RevokeSession(ctx, identity.SessionID); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAccessToken(ctx, identity.TokenID, time.Until(identity.ExpiresAt))
+}
+
+// LogoutAll revokes every session of a user, invalidating all of their tokens
+func (s *service) LogoutAll(ctx context.Context, userID int) error {
+	return s.tokens.RevokeUserSessions(ctx, userID)
+}
+
+// VerifyAccessToken validates the signature and expiry of an access token and
+// checks that neither the token nor its session has been revoked and that the
+// user is still active.
+func (s *service) VerifyAccessToken(ctx context.Context, tokenString string) (*middleware.Identity, error) {
+	parserOpts := []jwt.ParserOption{jwt.WithValidMethods(s.opts.Keys.Algorithms())}
+	if s.opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(s.opts.Issuer))
+	}
+	token, err := jwt.Parse(tokenString, s.opts.Keys.Keyfunc, parserOpts...)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	identity, err := identityFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.tokens.IsRevoked(ctx, identity.TokenID, identity.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	user, err := s.repo.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	identity.PasswordChangeRequired = user.MustChangePassword
+	if user.PatientID != nil {
+		identity.PatientID = *user.PatientID
+	}
+
+	return identity, nil
 }
 
 // HashPassword hashes a password using bcrypt
@@ -71,3 +267,106 @@ func (s *service) CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// issueTokens signs a new access token and stores a new refresh token for the session
+func (s *service) issueTokens(ctx context.Context, user *User, sessionID string) (*TokenPair, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":  user.ID,
+		"user": user.Username,
+		"role": user.Role,
+		"jti":  jti,
+		"sid":  sessionID,
+		"iat":  now.Unix(),
+		"exp":  now.Add(s.opts.AccessTokenTTL).Unix(),
+	}
+	if s.opts.Issuer != "" {
+		claims["iss"] = s.opts.Issuer
+	}
+
+	accessToken, err := s.opts.Keys.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.CreateRefreshToken(ctx, sessionID, hashToken(refreshToken), s.opts.RefreshTokenTTL); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:            accessToken,
+		RefreshToken:           refreshToken,
+		ExpiresIn:              int(s.opts.AccessTokenTTL.Seconds()),
+		PasswordChangeRequired: user.MustChangePassword,
+	}, nil
+}
+
+func identityFromClaims(claims jwt.MapClaims) (*middleware.Identity, error) {
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	if jti == "" || sid == "" {
+		return nil, ErrInvalidToken
+	}
+	role, _ := claims["role"].(string)
+	username, _ := claims["user"].(string)
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &middleware.Identity{
+		UserID:    int(sub),
+		Username:  username,
+		Role:      role,
+		TokenID:   jti,
+		SessionID: sid,
+		ExpiresAt: exp.Time,
+	}, nil
+}
+
+// randomToken returns n random bytes encoded as hex
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the SHA-256 hex digest under which opaque tokens are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewOpaqueToken returns a random single-use token for links issued by other
+// packages, such as patient portal invitations
+func NewOpaqueToken() (string, error) {
+	return randomToken(32)
+}
+
+// HashOpaqueToken returns the digest under which a token from NewOpaqueToken is stored
+func HashOpaqueToken(token string) string {
+	return hashToken(token)
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
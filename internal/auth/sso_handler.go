@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSOHandler holds the dependencies for the single sign-on handlers
+type SSOHandler struct {
+	service SSOService
+}
+
+// NewSSOHandler creates a new single sign-on handler
+func NewSSOHandler(s SSOService) *SSOHandler {
+	return &SSOHandler{service: s}
+}
+
+// SSOCallbackRequest carries the parameters the identity provider appended to
+// the redirect URL
+type SSOCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// StartLogin godoc
+// @Summary      Start a single sign-on login
+// @Description  Redirects the browser to the hospital identity provider (OpenID Connect authorization code flow with PKCE). The provider redirects back to the configured redirect URL with a code and state, which the frontend posts to /sso/callback.
+// @Tags         Auth
+// @Success      302
+// @Failure      500 {object} ErrorResponse "Identity provider unavailable"
+// @Router       /sso/login [get]
+func (h *SSOHandler) StartLogin(c *gin.Context) {
+	url, err := h.service.StartLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start single sign-on: " + err.Error()})
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
+
+// Callback godoc
+// @Summary      Complete a single sign-on login
+// @Description  Redeems the code returned by the identity provider and returns the same session tokens as /login. The user's provider groups are mapped to a portal role; unknown users are linked by verified email or provisioned, depending on configuration.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body SSOCallbackRequest true "Code and state from the provider redirect"
+// @Success      200 {object} LoginResponse
+// @Failure      400 {object} ErrorResponse "Invalid request body"
+// @Failure      401 {object} ErrorResponse "Invalid state or provider login failed"
+// @Failure      403 {object} ErrorResponse "No portal role or account, or account deactivated"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /sso/callback [post]
+func (h *SSOHandler) Callback(c *gin.Context) {
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	client := ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	tokens, err := h.service.CompleteLogin(c.Request.Context(), req.Code, req.State, client)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidSSOState), errors.Is(err, ErrSSOFailed):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrSSONoRole), errors.Is(err, ErrSSOAccountNotFound):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUserInactive):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(tokens))
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SSOLoginState is a pending single sign-on login
+type SSOLoginState struct {
+	StateHash    string    `db:"state_hash"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// SSORepository defines the interface for single sign-on storage
+type SSORepository interface {
+	CreateLoginState(ctx context.Context, stateHash, nonce, codeVerifier string, ttl time.Duration) error
+	ConsumeLoginState(ctx context.Context, stateHash string) (*SSOLoginState, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	LinkIdentity(ctx context.Context, userID int, issuer, subject string, email *string) error
+	TouchIdentity(ctx context.Context, issuer, subject string) error
+}
+
+type postgresSSORepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresSSORepository creates a new repository for single sign-on data
+func NewPostgresSSORepository(db *sqlx.DB) SSORepository {
+	return &postgresSSORepository{db: db}
+}
+
+// CreateLoginState stores a pending login that expires after ttl
+func (r *postgresSSORepository) CreateLoginState(ctx context.Context, stateHash, nonce, codeVerifier string, ttl time.Duration) error {
+	query := `INSERT INTO sso_login_states (state_hash, nonce, code_verifier, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second', NOW())`
+	_, err := r.db.ExecContext(ctx, query, stateHash, nonce, codeVerifier, int64(ttl.Seconds()))
+	return err
+}
+
+// ConsumeLoginState removes and returns a pending login, so every state can be
+// used once. Expired states are cleaned up on the way.
+func (r *postgresSSORepository) ConsumeLoginState(ctx context.Context, stateHash string) (*SSOLoginState, error) {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM sso_login_states WHERE expires_at <= NOW()`); err != nil {
+		return nil, err
+	}
+
+	var state SSOLoginState
+	query := `DELETE FROM sso_login_states WHERE state_hash = $1
+		RETURNING state_hash, nonce, code_verifier, expires_at, created_at`
+	err := r.db.GetContext(ctx, &state, query, stateHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidSSOState
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetUserByIdentity retrieves the user linked to an account at an OpenID provider
+func (r *postgresSSORepository) GetUserByIdentity(ctx context.Context, issuer, subject string) (*User, error) {
+	var user User
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE id = (SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2)`
+	err := r.db.GetContext(ctx, &user, query, issuer, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByEmail retrieves a staff user by their email address, ignoring case.
+// Patient portal accounts are never linked to the staff identity provider, and
+// neither are admins, whose accounts an email at the provider must not take over.
+func (r *postgresSSORepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	var user User
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE LOWER(email) = LOWER($1) AND role NOT IN ('patient', 'admin') AND patient_id IS NULL`
+	err := r.db.GetContext(ctx, &user, query, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkIdentity links a user to an account at an OpenID provider
+func (r *postgresSSORepository) LinkIdentity(ctx context.Context, userID int, issuer, subject string, email *string) error {
+	query := `INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())`
+	_, err := r.db.ExecContext(ctx, query, userID, issuer, subject, email)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrIdentityAlreadyLinked
+	}
+	return err
+}
+
+// TouchIdentity records a login through a linked identity
+func (r *postgresSSORepository) TouchIdentity(ctx context.Context, issuer, subject string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_identities SET last_login_at = NOW() WHERE issuer = $1 AND subject = $2`, issuer, subject)
+	return err
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/pkg/oidc"
+)
+
+var (
+	ErrInvalidSSOState       = errors.New("single sign-on login is invalid, expired or already completed")
+	ErrSSOFailed             = errors.New("single sign-on with the identity provider failed")
+	ErrSSONoRole             = errors.New("your identity provider account is not assigned to any portal role")
+	ErrSSOAccountNotFound    = errors.New("no portal account is linked to your identity provider account")
+	ErrIdentityAlreadyLinked = errors.New("identity provider account is already linked to a portal user")
+)
+
+const (
+	ssoStateTTL = 10 * time.Minute
+	// ssoPasswordHash is stored for accounts provisioned through single sign-on.
+	// It is not a valid bcrypt hash, so no password ever matches it.
+	ssoPasswordHash = "!sso"
+	// ssoUsernameAttempts bounds the retries with a random suffix when a
+	// provisioned username is already taken
+	ssoUsernameAttempts = 3
+)
+
+// RoleMapping maps a value of the role claim, typically a group, to a portal role
+type RoleMapping struct {
+	Value string
+	Role  string
+}
+
+// ParseRoleMappings parses "group=role" pairs separated by commas. Earlier
+// mappings take precedence when a user matches several.
+func ParseRoleMappings(s string) ([]RoleMapping, error) {
+	var mappings []RoleMapping
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid role mapping %q, expected value=role", pair)
+		}
+		value, role := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		switch role {
+		case RoleReceptionist, RoleDoctor, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("invalid role mapping %q: unknown role %q", pair, role)
+		}
+		mappings = append(mappings, RoleMapping{Value: value, Role: role})
+	}
+	return mappings, nil
+}
+
+// SSOOptions configures how identity provider accounts become portal users
+type SSOOptions struct {
+	// RoleClaim names the ID token claim holding the user's groups or roles
+	RoleClaim    string
+	RoleMappings []RoleMapping
+	// AutoProvision creates a portal account on the first login of an unknown user
+	AutoProvision bool
+	// LinkByEmail links an unknown identity to the existing staff account with
+	// the same email address, if the provider has verified that address.
+	// Admin and patient accounts are never linked this way.
+	LinkByEmail bool
+}
+
+// SSOService logs staff in through an OpenID Connect provider
+type SSOService interface {
+	StartLogin(ctx context.Context) (string, error)
+	CompleteLogin(ctx context.Context, code, state string, client ClientInfo) (*TokenPair, error)
+}
+
+type ssoService struct {
+	repo     Repository
+	sso      SSORepository
+	authSvc  Service
+	provider *oidc.Provider
+	opts     SSOOptions
+}
+
+// NewSSOService creates a new single sign-on service
+func NewSSOService(r Repository, sso SSORepository, authSvc Service, provider *oidc.Provider, opts SSOOptions) SSOService {
+	if opts.RoleClaim == "" {
+		opts.RoleClaim = "groups"
+	}
+	return &ssoService{repo: r, sso: sso, authSvc: authSvc, provider: provider, opts: opts}
+}
+
+// StartLogin stores a pending login and returns the provider URL to send the
+// user's browser to
+func (s *ssoService) StartLogin(ctx context.Context) (string, error) {
+	state, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.sso.CreateLoginState(ctx, hashToken(state), nonce, verifier, ssoStateTTL); err != nil {
+		return "", err
+	}
+	return s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+}
+
+// CompleteLogin redeems the authorization code the provider returned, maps the
+// user's groups to a portal role and opens a portal session. The provider is
+// trusted for the second factor, so portal MFA is not asked for.
+func (s *ssoService) CompleteLogin(ctx context.Context, code, state string, client ClientInfo) (*TokenPair, error) {
+	pending, err := s.sso.ConsumeLoginState(ctx, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.provider.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+	idToken, err := s.provider.VerifyIDToken(ctx, token.IDToken, pending.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+
+	role := s.mapRole(idToken)
+	if role == "" {
+		return nil, ErrSSONoRole
+	}
+
+	user, err := s.resolveUser(ctx, idToken, role)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	// The provider is the source of truth for the roles of the accounts it
+	// provisioned; tokens carrying the old role are revoked like on an admin
+	// role change. Linked accounts keep the role the portal gave them.
+	if user.Role != role && user.PasswordHash == ssoPasswordHash {
+		if err := s.repo.UpdateUserRole(ctx, user.ID, role); err != nil {
+			return nil, err
+		}
+		if err := s.authSvc.LogoutAll(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		user.Role = role
+	}
+
+	return s.authSvc.StartSession(ctx, user, client)
+}
+
+// mapRole returns the role of the first mapping matched by the role claim
+func (s *ssoService) mapRole(idToken *oidc.IDToken) string {
+	values := make(map[string]bool)
+	for _, v := range idToken.Strings(s.opts.RoleClaim) {
+		values[v] = true
+	}
+	for _, m := range s.opts.RoleMappings {
+		if values[m.Value] {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// resolveUser finds the portal account linked to the identity, linking or
+// provisioning one according to the options
+func (s *ssoService) resolveUser(ctx context.Context, idToken *oidc.IDToken, role string) (*User, error) {
+	user, err := s.sso.GetUserByIdentity(ctx, idToken.Issuer, idToken.Subject)
+	if err == nil {
+		return user, s.sso.TouchIdentity(ctx, idToken.Issuer, idToken.Subject)
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	var email *string
+	if e := strings.TrimSpace(idToken.String("email")); e != "" && idToken.Bool("email_verified") {
+		email = &e
+	}
+
+	if s.opts.LinkByEmail && email != nil {
+		user, err := s.sso.GetUserByEmail(ctx, *email)
+		if err == nil {
+			return user, s.sso.LinkIdentity(ctx, user.ID, idToken.Issuer, idToken.Subject, email)
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	if !s.opts.AutoProvision {
+		return nil, ErrSSOAccountNotFound
+	}
+	user, err = s.provision(ctx, idToken, role, email)
+	if err != nil {
+		return nil, err
+	}
+	return user, s.sso.LinkIdentity(ctx, user.ID, idToken.Issuer, idToken.Subject, email)
+}
+
+// provision creates a portal account for an identity. Its password cannot be
+// used to log in; the user signs in through the provider only.
+func (s *ssoService) provision(ctx context.Context, idToken *oidc.IDToken, role string, email *string) (*User, error) {
+	base := ssoUsername(idToken)
+	username := base
+	for attempt := 0; ; attempt++ {
+		u := &User{
+			Username:     username,
+			Email:        email,
+			PasswordHash: ssoPasswordHash,
+			Role:         role,
+			IsActive:     true,
+		}
+		err := s.repo.CreateUser(ctx, u)
+		if err == nil {
+			return u, nil
+		}
+		if !errors.Is(err, ErrUsernameTaken) || attempt == ssoUsernameAttempts {
+			return nil, err
+		}
+		// The email may be what collides; an account with it exists but was
+		// not linked, so provision without it
+		email = nil
+		suffix, err := randomToken(2)
+		if err != nil {
+			return nil, err
+		}
+		username = truncate(base, 45) + "-" + suffix
+	}
+}
+
+// ssoUsername picks a username for a provisioned account from the ID token
+func ssoUsername(idToken *oidc.IDToken) string {
+	name := strings.TrimSpace(idToken.String("preferred_username"))
+	if name == "" {
+		name = strings.TrimSpace(idToken.String("email"))
+	}
+	if name == "" {
+		name = "sso-" + idToken.Subject
+	}
+	for len([]rune(name)) < 3 {
+		name += "_"
+	}
+	return truncate(name, 50)
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
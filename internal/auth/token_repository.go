@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Session is a login session; all refresh tokens rotated from the same login share it
+type Session struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	UserAgent *string    `json:"user_agent,omitempty" db:"user_agent"`
+	IPAddress *string    `json:"ip_address,omitempty" db:"ip_address"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// RefreshToken is the stored (hashed) form of an issued refresh token
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	SessionID string     `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// MFAChallenge is a pending second login step, stored by the hash of its token
+type MFAChallenge struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	Attempts  int        `db:"attempts"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// TokenRepository defines the interface for session and token revocation storage
+type TokenRepository interface {
+	CreateSession(ctx context.Context, s *Session, ttl time.Duration) error
+	GetActiveSession(ctx context.Context, id string) (*Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeUserSessions(ctx context.Context, userID int) error
+	RevokeOtherSessions(ctx context.Context, userID int, keepSessionID string) error
+	CreateRefreshToken(ctx context.Context, sessionID, tokenHash string, ttl time.Duration) error
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti, sessionID string) (bool, error)
+	CreateMFAChallenge(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error
+	GetMFAChallenge(ctx context.Context, tokenHash string) (*MFAChallenge, error)
+	RecordMFAChallengeFailure(ctx context.Context, id, maxAttempts int) error
+	ConsumeMFAChallenge(ctx context.Context, id int) error
+}
+
+type postgresTokenRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresTokenRepository creates a new repository for sessions and tokens
+func NewPostgresTokenRepository(db *sqlx.DB) TokenRepository {
+	return &postgresTokenRepository{db: db}
+}
+
+// CreateSession stores a new session that expires after ttl
+func (r *postgresTokenRepository) CreateSession(ctx context.Context, s *Session, ttl time.Duration) error {
+	query := `INSERT INTO auth_sessions (id, user_id, user_agent, ip_address, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW() + $5 * INTERVAL '1 second') RETURNING created_at, expires_at`
+	return r.db.QueryRowContext(ctx, query, s.ID, s.UserID, s.UserAgent, s.IPAddress, int64(ttl.Seconds())).Scan(&s.CreatedAt, &s.ExpiresAt)
+}
+
+// GetActiveSession retrieves a session that is neither revoked nor expired
+func (r *postgresTokenRepository) GetActiveSession(ctx context.Context, id string) (*Session, error) {
+	var s Session
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, expires_at, revoked_at FROM auth_sessions
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+	err := r.db.GetContext(ctx, &s, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RevokeSession revokes a single session and therefore every token issued in it
+func (r *postgresTokenRepository) RevokeSession(ctx context.Context, id string) error {
+	query := `UPDATE auth_sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// RevokeUserSessions revokes every open session belonging to a user
+func (r *postgresTokenRepository) RevokeUserSessions(ctx context.Context, userID int) error {
+	query := `UPDATE auth_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// RevokeOtherSessions revokes every open session of a user except the given one
+func (r *postgresTokenRepository) RevokeOtherSessions(ctx context.Context, userID int, keepSessionID string) error {
+	query := `UPDATE auth_sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID, keepSessionID)
+	return err
+}
+
+// CreateRefreshToken stores the hash of a newly issued refresh token
+func (r *postgresTokenRepository) CreateRefreshToken(ctx context.Context, sessionID, tokenHash string, ttl time.Duration) error {
+	query := `INSERT INTO refresh_tokens (session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second', NOW())`
+	_, err := r.db.ExecContext(ctx, query, sessionID, tokenHash, int64(ttl.Seconds()))
+	return err
+}
+
+// ConsumeRefreshToken atomically marks an unused, unexpired refresh token as used.
+// It returns ErrInvalidRefreshToken when no such token could be consumed.
+func (r *postgresTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var t RefreshToken
+	query := `UPDATE refresh_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, session_id, token_hash, expires_at, used_at, created_at`
+	err := r.db.GetContext(ctx, &t, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetRefreshToken retrieves a refresh token by its hash regardless of its state
+func (r *postgresTokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var t RefreshToken
+	query := `SELECT id, session_id, token_hash, expires_at, used_at, created_at FROM refresh_tokens WHERE token_hash = $1`
+	err := r.db.GetContext(ctx, &t, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RevokeAccessToken denylists an access token until it would have expired anyway
+func (r *postgresTokenRepository) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	query := `INSERT INTO revoked_access_tokens (jti, expires_at, revoked_at)
+		VALUES ($1, NOW() + $2 * INTERVAL '1 second', NOW()) ON CONFLICT (jti) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, jti, int64(ttl.Seconds()))
+	return err
+}
+
+// IsRevoked reports whether the access token or the session it belongs to has been revoked
+func (r *postgresTokenRepository) IsRevoked(ctx context.Context, jti, sessionID string) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_access_tokens WHERE jti = $1)
+		OR NOT EXISTS (SELECT 1 FROM auth_sessions WHERE id = $2 AND revoked_at IS NULL AND expires_at > NOW())`
+	err := r.db.GetContext(ctx, &revoked, query, jti, sessionID)
+	return revoked, err
+}
+
+// CreateMFAChallenge stores the hash of a new MFA challenge token that expires after ttl
+func (r *postgresTokenRepository) CreateMFAChallenge(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error {
+	query := `INSERT INTO mfa_challenges (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second', NOW())`
+	_, err := r.db.ExecContext(ctx, query, userID, tokenHash, int64(ttl.Seconds()))
+	return err
+}
+
+// GetMFAChallenge retrieves an unused, unexpired MFA challenge by the hash of its token.
+// It returns ErrInvalidMFAChallenge when there is none.
+func (r *postgresTokenRepository) GetMFAChallenge(ctx context.Context, tokenHash string) (*MFAChallenge, error) {
+	var ch MFAChallenge
+	query := `SELECT id, user_id, token_hash, attempts, expires_at, used_at, created_at FROM mfa_challenges
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()`
+	err := r.db.GetContext(ctx, &ch, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// RecordMFAChallengeFailure counts a wrong code against a challenge and burns
+// the challenge once maxAttempts is reached
+func (r *postgresTokenRepository) RecordMFAChallengeFailure(ctx context.Context, id, maxAttempts int) error {
+	query := `UPDATE mfa_challenges SET attempts = attempts + 1,
+		used_at = CASE WHEN attempts + 1 >= $2 THEN NOW() ELSE used_at END
+		WHERE id = $1 AND used_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id, maxAttempts)
+	return err
+}
+
+// ConsumeMFAChallenge atomically marks a challenge as used. It returns
+// ErrInvalidMFAChallenge when the challenge was already used or has expired.
+func (r *postgresTokenRepository) ConsumeMFAChallenge(ctx context.Context, id int) error {
+	query := `UPDATE mfa_challenges SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvalidMFAChallenge
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// UserHandler holds the dependencies for the user management handlers
+type UserHandler struct {
+	service UserService
+}
+
+// NewUserHandler creates a new user management handler
+func NewUserHandler(s UserService) *UserHandler {
+	return &UserHandler{service: s}
+}
+
+// CreateUser godoc
+// @Summary      Create a staff account (Admin only)
+// @Description  Creates a new receptionist, doctor or admin account.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        user body CreateUserRequest true "User data"
+// @Success      201 {object} User
+// @Failure      400 {object} ErrorResponse "Invalid request body"
+// @Failure      403 {object} ErrorResponse "Forbidden"
+// @Failure      409 {object} ErrorResponse "Username already taken"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /users [post]
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.CreateUser(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// ListUsers godoc
+// @Summary      List staff accounts (Admin only)
+// @Description  Retrieves all staff accounts, including deactivated ones.
+// @Tags         Users
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   User
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	users, err := h.service.ListUsers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary      Get a staff account by ID (Admin only)
+// @Description  Retrieves a single staff account.
+// @Tags         Users
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  User
+// @Failure      400  {object}  ErrorResponse "Invalid user ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Router       /users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.service.GetUser(c.Request.Context(), id)
+	if err != nil {
+		writeUserError(c, err, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser godoc
+// @Summary      Update a staff account (Admin only)
+// @Description  Updates a staff account's username.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int                true  "User ID"
+// @Param        user body      UpdateUserRequest  true  "User data"
+// @Success      200  {object}  User
+// @Failure      400  {object}  ErrorResponse "Invalid request body or ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Failure      409  {object}  ErrorResponse "Username already taken"
+// @Router       /users/{id} [put]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.UpdateUser(c.Request.Context(), id, req)
+	if err != nil {
+		writeUserError(c, err, "Failed to update user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserRole godoc
+// @Summary      Change a staff account's role (Admin only)
+// @Description  Assigns a new role to a staff account. Admins cannot change their own role.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int                    true  "User ID"
+// @Param        role body      UpdateUserRoleRequest  true  "New role"
+// @Success      200  {object}  User
+// @Failure      400  {object}  ErrorResponse "Invalid request body or ID, or patient portal account"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Router       /users/{id}/role [patch]
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.UpdateUserRole(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), id, req)
+	if err != nil {
+		writeUserError(c, err, "Failed to update user role")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeactivateUser godoc
+// @Summary      Deactivate a staff account (Admin only)
+// @Description  Disables login for a staff account and revokes access for tokens it already holds.
+// @Tags         Users
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse "Invalid user ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Router       /users/{id}/deactivate [post]
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.DeactivateUser(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), id); err != nil {
+		writeUserError(c, err, "Failed to deactivate user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ActivateUser godoc
+// @Summary      Reactivate a staff account (Admin only)
+// @Description  Re-enables login for a previously deactivated staff account.
+// @Tags         Users
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  ErrorResponse "Invalid user ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "User not found"
+// @Router       /users/{id}/activate [post]
+func (h *UserHandler) ActivateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.ActivateUser(c.Request.Context(), id); err != nil {
+		writeUserError(c, err, "Failed to activate user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// writeUserError maps user service errors onto HTTP responses
+func writeUserError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUsernameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCannotModifySelf):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientAccount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg + ": " + err.Error()})
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrUsernameTaken    = errors.New("username or email is already taken")
+	ErrCannotModifySelf = errors.New("admins cannot deactivate or re-role their own account")
+	ErrPatientAccount   = errors.New("patient portal accounts cannot be given a staff role")
+)
+
+// UserService provides staff account management on top of the user repository
+type UserService interface {
+	CreateUser(ctx context.Context, req CreateUserRequest) (*User, error)
+	GetUser(ctx context.Context, id int) (*User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	UpdateUser(ctx context.Context, id int, req UpdateUserRequest) (*User, error)
+	UpdateUserRole(ctx context.Context, actorID, id int, req UpdateUserRoleRequest) (*User, error)
+	DeactivateUser(ctx context.Context, actorID, id int) error
+	ActivateUser(ctx context.Context, id int) error
+}
+
+type userService struct {
+	repo    Repository
+	authSvc Service
+}
+
+// NewUserService creates a new user management service
+func NewUserService(r Repository, authSvc Service) UserService {
+	return &userService{repo: r, authSvc: authSvc}
+}
+
+// CreateUser hashes the initial password and stores a new active account. The
+// admin chose the initial password, so the user must change it at first login.
+func (s *userService) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	hash, err := s.authSvc.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &User{
+		Username:           req.Username,
+		Email:              req.Email,
+		PasswordHash:       hash,
+		Role:               req.Role,
+		IsActive:           true,
+		MustChangePassword: true,
+	}
+	if err := s.repo.CreateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, id int) (*User, error) {
+	return s.repo.GetUserByID(ctx, id)
+}
+
+func (s *userService) ListUsers(ctx context.Context) ([]User, error) {
+	return s.repo.ListUsers(ctx)
+}
+
+func (s *userService) UpdateUser(ctx context.Context, id int, req UpdateUserRequest) (*User, error) {
+	u := &User{ID: id, Username: req.Username, Email: req.Email}
+	if err := s.repo.UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByID(ctx, id)
+}
+
+// UpdateUserRole changes a user's role. Admins may not change their own role so
+// that the last administrator cannot accidentally lock everyone out. The user's
+// sessions are revoked so tokens carrying the old role stop working. Patient
+// portal accounts are tied to a patient record and keep the patient role.
+func (s *userService) UpdateUserRole(ctx context.Context, actorID, id int, req UpdateUserRoleRequest) (*User, error) {
+	if actorID == id {
+		return nil, ErrCannotModifySelf
+	}
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == RolePatient {
+		return nil, ErrPatientAccount
+	}
+	if err := s.repo.UpdateUserRole(ctx, id, req.Role); err != nil {
+		return nil, err
+	}
+	if err := s.authSvc.LogoutAll(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByID(ctx, id)
+}
+
+// DeactivateUser disables an account and revokes all of its sessions
+func (s *userService) DeactivateUser(ctx context.Context, actorID, id int) error {
+	if actorID == id {
+		return ErrCannotModifySelf
+	}
+	if err := s.repo.SetUserActive(ctx, id, false); err != nil {
+		return err
+	}
+	return s.authSvc.LogoutAll(ctx, id)
+}
+
+func (s *userService) ActivateUser(ctx context.Context, id int) error {
+	return s.repo.SetUserActive(ctx, id, true)
+}
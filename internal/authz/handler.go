@@ -0,0 +1,107 @@
+package authz
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// Handler holds the dependencies for the role permission handlers
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new role permission handler
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ListRoles godoc
+// @Summary      List roles and their permissions
+// @Description  Retrieves every role together with the permissions granted to it.
+// @Tags         Permissions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   Role
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /roles [get]
+func (h *Handler) ListRoles(c *gin.Context) {
+	roles, err := h.service.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// ListPermissions godoc
+// @Summary      List permissions
+// @Description  Retrieves the catalog of permissions that can be granted to roles.
+// @Tags         Permissions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   Permission
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /permissions [get]
+func (h *Handler) ListPermissions(c *gin.Context) {
+	permissions, err := h.service.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+// UpdateRolePermissions godoc
+// @Summary      Replace a role's permissions
+// @Description  Replaces the full set of permissions granted to a role. Takes effect for signed-in users within 30 seconds.
+// @Tags         Permissions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        role  path      string                        true  "Role name"
+// @Param        body  body      UpdateRolePermissionsRequest  true  "Permissions to grant"
+// @Success      200   {object}  Role
+// @Failure      400   {object}  ErrorResponse "Invalid request body or unknown permission"
+// @Failure      403   {object}  ErrorResponse "Forbidden"
+// @Failure      404   {object}  ErrorResponse "Role not found"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /roles/{role}/permissions [put]
+func (h *Handler) UpdateRolePermissions(c *gin.Context) {
+	var req UpdateRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	identity, ok := middleware.GetIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity not found in context"})
+		return
+	}
+
+	role, err := h.service.UpdateRolePermissions(c.Request.Context(), identity.Role, c.Param("role"), req.Permissions)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownPermission):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrRoleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrCannotRemoveOwnAdmin):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role permissions: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
@@ -0,0 +1,49 @@
+package authz
+
+// Named permissions checked by RequirePermission. Roles are granted
+// permissions through the role_permissions table.
+const (
+	PatientCreate       = "patient:create"
+	PatientRead         = "patient:read"
+	PatientClinicalRead = "patient:clinical:read"
+	PatientUpdate       = "patient:update"
+	PatientMedicalWrite = "patient:medical:write"
+	PatientDelete       = "patient:delete"
+	PatientRestore      = "patient:restore"
+	PatientMerge        = "patient:merge"
+	PatientImport       = "patient:import"
+	PatientExport       = "patient:export"
+	PatientAccessAll    = "patient:access:all"
+	CareTeamManage      = "careteam:manage"
+	EmergencyAccess     = "patient:emergency_access"
+	EmergencyReview     = "emergency_access:review"
+	PortalSelf          = "portal:self"
+	PortalInvite        = "portal:invite"
+	PrescriptionCreate  = "prescription:create"
+	PrescriptionRead    = "prescription:read"
+	DocumentUpload      = "document:upload"
+	DocumentRead        = "document:read"
+	DocumentDelete      = "document:delete"
+	UserManage          = "user:manage"
+	SecurityAudit       = "security:audit"
+	RoleManage          = "role:manage"
+	APIKeyManage        = "apikey:manage"
+)
+
+// Permission describes a permission that can be granted to a role
+type Permission struct {
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+}
+
+// Role is a role together with the permissions granted to it
+type Role struct {
+	Name        string   `json:"name" db:"name"`
+	Description string   `json:"description" db:"description"`
+	Permissions []string `json:"permissions" db:"-"`
+}
+
+// UpdateRolePermissionsRequest replaces the full set of permissions of a role
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
@@ -0,0 +1,104 @@
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the interface for role and permission storage
+type Repository interface {
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListPermissions(ctx context.Context) ([]Permission, error)
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	SetRolePermissions(ctx context.Context, role string, permissions []string) error
+}
+
+type postgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository creates a new repository for roles and permissions
+func NewPostgresRepository(db *sqlx.DB) Repository {
+	return &postgresRepository{db: db}
+}
+
+// ListRoles retrieves every role together with its granted permissions
+func (r *postgresRepository) ListRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	if err := r.db.SelectContext(ctx, &roles, `SELECT name, description FROM roles ORDER BY name ASC`); err != nil {
+		return nil, err
+	}
+
+	var grants []struct {
+		Role       string `db:"role"`
+		Permission string `db:"permission"`
+	}
+	if err := r.db.SelectContext(ctx, &grants, `SELECT role, permission FROM role_permissions ORDER BY permission ASC`); err != nil {
+		return nil, err
+	}
+
+	byRole := make(map[string][]string)
+	for _, g := range grants {
+		byRole[g.Role] = append(byRole[g.Role], g.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return roles, nil
+}
+
+// ListPermissions retrieves the catalog of known permissions
+func (r *postgresRepository) ListPermissions(ctx context.Context) ([]Permission, error) {
+	var permissions []Permission
+	err := r.db.SelectContext(ctx, &permissions, `SELECT name, description FROM permissions ORDER BY name ASC`)
+	return permissions, err
+}
+
+// GetRolePermissions retrieves the permissions granted to a role. It returns
+// ErrRoleNotFound for roles that do not exist.
+func (r *postgresRepository) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)`, role); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRoleNotFound
+	}
+
+	permissions := []string{}
+	err := r.db.SelectContext(ctx, &permissions, `SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission ASC`, role)
+	return permissions, err
+}
+
+// SetRolePermissions replaces all permissions granted to a role
+func (r *postgresRepository) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var name string
+	if err := tx.GetContext(ctx, &name, `SELECT name FROM roles WHERE name = $1 FOR UPDATE`, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role = $1`, role); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO role_permissions (role, permission) VALUES ($1, $2)`, role, p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrUnknownPermission    = errors.New("unknown permission")
+	ErrCannotRemoveOwnAdmin = errors.New("you cannot remove the role:manage permission from your own role")
+)
+
+// cacheTTL bounds how long other server instances keep serving a role's
+// permissions after an admin changed them
+const cacheTTL = 30 * time.Second
+
+// Service resolves and manages role permissions. It implements
+// middleware.PermissionResolver.
+type Service interface {
+	RolePermissions(ctx context.Context, role string) ([]string, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListPermissions(ctx context.Context) ([]Permission, error)
+	UpdateRolePermissions(ctx context.Context, actorRole, role string, permissions []string) (*Role, error)
+}
+
+type cachedPermissions struct {
+	permissions []string
+	loadedAt    time.Time
+}
+
+type service struct {
+	repo Repository
+
+	mu    sync.RWMutex
+	cache map[string]cachedPermissions
+}
+
+// NewService creates a new permission service with the given repository
+func NewService(r Repository) Service {
+	return &service{repo: r, cache: make(map[string]cachedPermissions)}
+}
+
+// RolePermissions returns the permissions granted to a role, served from a
+// short-lived cache since it is called on every authenticated request
+func (s *service) RolePermissions(ctx context.Context, role string) ([]string, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[role]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.permissions, nil
+	}
+
+	permissions, err := s.repo.GetRolePermissions(ctx, role)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			// A role without a row in roles is granted nothing
+			permissions = []string{}
+		} else {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[role] = cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return permissions, nil
+}
+
+// ListRoles retrieves every role with its permissions
+func (s *service) ListRoles(ctx context.Context) ([]Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+// ListPermissions retrieves the catalog of permissions that can be granted
+func (s *service) ListPermissions(ctx context.Context) ([]Permission, error) {
+	return s.repo.ListPermissions(ctx)
+}
+
+// UpdateRolePermissions replaces the permissions of a role. Admins cannot take
+// role:manage away from their own role, which would lock them out of this endpoint.
+func (s *service) UpdateRolePermissions(ctx context.Context, actorRole, role string, permissions []string) (*Role, error) {
+	catalog, err := s.repo.ListPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(catalog))
+	for _, p := range catalog {
+		known[p.Name] = true
+	}
+
+	seen := make(map[string]bool, len(permissions))
+	unique := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if !known[p] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	sort.Strings(unique)
+
+	if role == actorRole && !seen[RoleManage] {
+		return nil, ErrCannotRemoveOwnAdmin
+	}
+
+	if err := s.repo.SetRolePermissions(ctx, role, unique); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, role)
+	s.mu.Unlock()
+
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		if roles[i].Name == role {
+			return &roles[i], nil
+		}
+	}
+	return nil, ErrRoleNotFound
+}
@@ -0,0 +1,141 @@
+package careteam
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// EmergencyHandler holds the dependencies for the emergency access handlers
+type EmergencyHandler struct {
+	service EmergencyService
+}
+
+// NewEmergencyHandler creates a new emergency access handler
+func NewEmergencyHandler(s EmergencyService) *EmergencyHandler {
+	return &EmergencyHandler{service: s}
+}
+
+// BreakGlass godoc
+// @Summary      Break the glass for a patient
+// @Description  Grants the calling doctor time-boxed access to a patient outside their care teams, e.g. in the emergency room. A reason is mandatory; every grant is recorded and queued for admin review.
+// @Tags         Emergency Access
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      int                true  "Patient ID"
+// @Param        body  body      BreakGlassRequest  true  "Justification"
+// @Success      201   {object}  Grant
+// @Failure      400   {object}  ErrorResponse "Invalid request body or missing reason"
+// @Failure      403   {object}  ErrorResponse "Forbidden"
+// @Failure      404   {object}  ErrorResponse "Patient not found"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/emergency-access [post]
+func (h *EmergencyHandler) BreakGlass(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req BreakGlassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	grant, err := h.service.BreakGlass(c.Request.Context(), caller, patientID, req, c.ClientIP())
+	if err != nil {
+		writeEmergencyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, grant)
+}
+
+// ListGrants godoc
+// @Summary      List emergency access grants
+// @Description  Retrieves break-the-glass grants, newest first. By default only the review queue (grants not yet reviewed) is returned; pass status=all for the full history.
+// @Tags         Emergency Access
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        status  query     string  false  "pending (default) or all"
+// @Success      200     {array}   Grant
+// @Failure      400     {object}  ErrorResponse "Invalid status"
+// @Failure      403     {object}  ErrorResponse "Forbidden"
+// @Failure      500     {object}  ErrorResponse "Internal server error"
+// @Router       /emergency-access [get]
+func (h *EmergencyHandler) ListGrants(c *gin.Context) {
+	var pendingOnly bool
+	switch c.DefaultQuery("status", "pending") {
+	case "pending":
+		pendingOnly = true
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'pending' or 'all'"})
+		return
+	}
+
+	grants, err := h.service.ListGrants(c.Request.Context(), pendingOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, grants)
+}
+
+// ReviewGrant godoc
+// @Summary      Review an emergency access grant
+// @Description  Marks a grant as reviewed and removes it from the review queue. Set revoke to end a grant that is still active.
+// @Tags         Emergency Access
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      int                 true  "Grant ID"
+// @Param        body  body      ReviewGrantRequest  true  "Review outcome"
+// @Success      200   {object}  Grant
+// @Failure      400   {object}  ErrorResponse "Invalid request body"
+// @Failure      403   {object}  ErrorResponse "Forbidden or own grant"
+// @Failure      404   {object}  ErrorResponse "Grant not found"
+// @Failure      409   {object}  ErrorResponse "Grant already reviewed"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /emergency-access/{id}/review [post]
+func (h *EmergencyHandler) ReviewGrant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid grant ID"})
+		return
+	}
+
+	var req ReviewGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	reviewerID := c.GetInt(middleware.ContextKeyUserID)
+	grant, err := h.service.ReviewGrant(c.Request.Context(), reviewerID, id, req)
+	if err != nil {
+		writeEmergencyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, grant)
+}
+
+func writeEmergencyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAccessDenied), errors.Is(err, ErrSelfReview):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound), errors.Is(err, ErrGrantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrGrantAlreadyReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,115 @@
+package careteam
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// EmergencyRepository defines the interface for break-the-glass grant storage
+type EmergencyRepository interface {
+	CreateGrant(ctx context.Context, g *Grant, ttl time.Duration) error
+	GetGrant(ctx context.Context, id int) (*Grant, error)
+	HasActiveGrant(ctx context.Context, patientID, doctorID int) (bool, error)
+	ListGrants(ctx context.Context, pendingOnly bool) ([]Grant, error)
+	ReviewGrant(ctx context.Context, id, reviewerID int, note *string, revoke bool) error
+}
+
+type postgresEmergencyRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresEmergencyRepository creates a new repository for emergency access grants
+func NewPostgresEmergencyRepository(db *sqlx.DB) EmergencyRepository {
+	return &postgresEmergencyRepository{db: db}
+}
+
+const grantSelect = `SELECT g.id, g.patient_id, p.name AS patient_name, g.doctor_id, u.username AS doctor_username,
+	g.reason, g.ip_address, g.granted_at, g.expires_at, g.reviewed_at, g.reviewed_by, g.review_note
+	FROM emergency_access_grants g
+	JOIN patients p ON p.id = g.patient_id
+	JOIN users u ON u.id = g.doctor_id`
+
+// CreateGrant stores a grant that expires after ttl
+func (r *postgresEmergencyRepository) CreateGrant(ctx context.Context, g *Grant, ttl time.Duration) error {
+	query := `INSERT INTO emergency_access_grants (patient_id, doctor_id, reason, ip_address, granted_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW() + $5 * INTERVAL '1 second') RETURNING id`
+	err := r.db.QueryRowContext(ctx, query, g.PatientID, g.DoctorID, g.Reason, g.IPAddress, int64(ttl.Seconds())).Scan(&g.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "fk_patient" {
+			return ErrPatientNotFound
+		}
+		return err
+	}
+
+	created, err := r.GetGrant(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+	*g = *created
+	return nil
+}
+
+// GetGrant retrieves a grant by its ID
+func (r *postgresEmergencyRepository) GetGrant(ctx context.Context, id int) (*Grant, error) {
+	var g Grant
+	err := r.db.GetContext(ctx, &g, grantSelect+` WHERE g.id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGrantNotFound
+		}
+		return nil, err
+	}
+	return &g, nil
+}
+
+// HasActiveGrant reports whether the doctor holds an unexpired grant for the patient
+func (r *postgresEmergencyRepository) HasActiveGrant(ctx context.Context, patientID, doctorID int) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS (SELECT 1 FROM emergency_access_grants
+		WHERE doctor_id = $1 AND patient_id = $2 AND expires_at > NOW())`
+	err := r.db.GetContext(ctx, &active, query, doctorID, patientID)
+	return active, err
+}
+
+// ListGrants retrieves grants, newest first. With pendingOnly it returns the
+// review queue: grants no admin has reviewed yet.
+func (r *postgresEmergencyRepository) ListGrants(ctx context.Context, pendingOnly bool) ([]Grant, error) {
+	grants := []Grant{}
+	query := grantSelect
+	if pendingOnly {
+		query += ` WHERE g.reviewed_at IS NULL`
+	}
+	query += ` ORDER BY g.granted_at DESC`
+	err := r.db.SelectContext(ctx, &grants, query)
+	return grants, err
+}
+
+// ReviewGrant marks a grant as reviewed, ending it early when revoke is set.
+// A grant can only be reviewed once.
+func (r *postgresEmergencyRepository) ReviewGrant(ctx context.Context, id, reviewerID int, note *string, revoke bool) error {
+	query := `UPDATE emergency_access_grants
+		SET reviewed_at = NOW(), reviewed_by = $2, review_note = $3,
+			expires_at = CASE WHEN $4 AND expires_at > NOW() THEN NOW() ELSE expires_at END
+		WHERE id = $1 AND reviewed_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, id, reviewerID, note, revoke)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		if _, err := r.GetGrant(ctx, id); err != nil {
+			return err
+		}
+		return ErrGrantAlreadyReviewed
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package careteam
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+var (
+	ErrGrantNotFound        = errors.New("emergency access grant not found")
+	ErrGrantAlreadyReviewed = errors.New("emergency access grant has already been reviewed")
+	ErrSelfReview           = errors.New("you cannot review your own emergency access")
+	ErrReasonRequired       = errors.New("a reason is required for emergency access")
+)
+
+// DefaultEmergencyAccessTTL is used when no grant duration is configured
+const DefaultEmergencyAccessTTL = 4 * time.Hour
+
+// EmergencyService grants break-the-glass access and keeps the review queue
+type EmergencyService interface {
+	BreakGlass(ctx context.Context, caller *middleware.Identity, patientID int, req BreakGlassRequest, ipAddress string) (*Grant, error)
+	ListGrants(ctx context.Context, pendingOnly bool) ([]Grant, error)
+	ReviewGrant(ctx context.Context, reviewerID, id int, req ReviewGrantRequest) (*Grant, error)
+}
+
+type emergencyService struct {
+	repo EmergencyRepository
+	ttl  time.Duration
+}
+
+// NewEmergencyService creates a new emergency access service. Grants last ttl.
+func NewEmergencyService(r EmergencyRepository, ttl time.Duration) EmergencyService {
+	if ttl <= 0 {
+		ttl = DefaultEmergencyAccessTTL
+	}
+	return &emergencyService{repo: r, ttl: ttl}
+}
+
+// BreakGlass grants the caller access to the patient for the configured time.
+// The grant is recorded with its reason and queued for admin review.
+func (s *emergencyService) BreakGlass(ctx context.Context, caller *middleware.Identity, patientID int, req BreakGlassRequest, ipAddress string) (*Grant, error) {
+	if caller == nil || caller.UserID == 0 {
+		return nil, ErrAccessDenied
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	g := &Grant{
+		PatientID: patientID,
+		DoctorID:  caller.UserID,
+		Reason:    reason,
+	}
+	if ipAddress != "" {
+		g.IPAddress = &ipAddress
+	}
+	if err := s.repo.CreateGrant(ctx, g, s.ttl); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *emergencyService) ListGrants(ctx context.Context, pendingOnly bool) ([]Grant, error) {
+	return s.repo.ListGrants(ctx, pendingOnly)
+}
+
+// ReviewGrant closes a grant in the review queue. Doctors who are also admins
+// cannot sign off on their own emergency access.
+func (s *emergencyService) ReviewGrant(ctx context.Context, reviewerID, id int, req ReviewGrantRequest) (*Grant, error) {
+	g, err := s.repo.GetGrant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if g.DoctorID == reviewerID {
+		return nil, ErrSelfReview
+	}
+
+	if err := s.repo.ReviewGrant(ctx, id, reviewerID, req.Note, req.Revoke); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGrant(ctx, id)
+}
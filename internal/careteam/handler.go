@@ -0,0 +1,135 @@
+package careteam
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// Handler holds the dependencies for the care team handlers
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new care team handler
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ListMembers godoc
+// @Summary      List a patient's care team
+// @Description  Retrieves the doctors assigned to a patient, primary physician first. Doctors can only see the care teams they belong to.
+// @Tags         Care Team
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Patient ID"
+// @Success      200  {array}   Member
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Patient not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/care-team [get]
+func (h *Handler) ListMembers(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	members, err := h.service.ListMembers(c.Request.Context(), caller, patientID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember godoc
+// @Summary      Assign a doctor to a patient
+// @Description  Adds a doctor to a patient's care team, or changes whether an existing member is the primary physician. A new primary physician replaces the previous one, who stays on the care team.
+// @Tags         Care Team
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      int               true  "Patient ID"
+// @Param        body  body      AddMemberRequest  true  "Doctor to assign"
+// @Success      201   {object}  Member
+// @Failure      400   {object}  ErrorResponse "Invalid request body or user is not an active doctor"
+// @Failure      403   {object}  ErrorResponse "Forbidden"
+// @Failure      404   {object}  ErrorResponse "Patient not found"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/care-team [post]
+func (h *Handler) AddMember(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	actorID := c.GetInt(middleware.ContextKeyUserID)
+	member, err := h.service.AddMember(c.Request.Context(), actorID, patientID, req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// RemoveMember godoc
+// @Summary      Remove a doctor from a patient's care team
+// @Description  Unassigns a doctor from a patient. The doctor loses access to the patient's record.
+// @Tags         Care Team
+// @Security     ApiKeyAuth
+// @Param        id         path  int  true  "Patient ID"
+// @Param        doctor_id  path  int  true  "Doctor's user ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse "Invalid ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "Doctor is not on the care team"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/care-team/{doctor_id} [delete]
+func (h *Handler) RemoveMember(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+	doctorID, err := strconv.Atoi(c.Param("doctor_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), patientID, doctorID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound), errors.Is(err, ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotADoctor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
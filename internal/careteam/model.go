@@ -0,0 +1,49 @@
+package careteam
+
+import "time"
+
+// Member is a doctor assigned to a patient's care team
+type Member struct {
+	PatientID      int       `json:"patient_id" db:"patient_id"`
+	DoctorID       int       `json:"doctor_id" db:"doctor_id"`
+	DoctorUsername string    `json:"doctor_username" db:"doctor_username"`
+	IsPrimary      bool      `json:"is_primary" db:"is_primary"`
+	AssignedBy     *int      `json:"assigned_by,omitempty" db:"assigned_by"`
+	AssignedAt     time.Time `json:"assigned_at" db:"assigned_at"`
+}
+
+// AddMemberRequest assigns a doctor to a patient's care team. Assigning a new
+// primary physician demotes the previous one to a regular member.
+type AddMemberRequest struct {
+	DoctorID int  `json:"doctor_id" binding:"required"`
+	Primary  bool `json:"primary"`
+}
+
+// Grant is a break-the-glass grant giving a doctor time-boxed access to a
+// patient outside their care teams
+type Grant struct {
+	ID             int        `json:"id" db:"id"`
+	PatientID      int        `json:"patient_id" db:"patient_id"`
+	PatientName    string     `json:"patient_name" db:"patient_name"`
+	DoctorID       int        `json:"doctor_id" db:"doctor_id"`
+	DoctorUsername string     `json:"doctor_username" db:"doctor_username"`
+	Reason         string     `json:"reason" db:"reason"`
+	IPAddress      *string    `json:"ip_address,omitempty" db:"ip_address"`
+	GrantedAt      time.Time  `json:"granted_at" db:"granted_at"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewedBy     *int       `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote     *string    `json:"review_note,omitempty" db:"review_note"`
+}
+
+// BreakGlassRequest asks for emergency access to a patient
+type BreakGlassRequest struct {
+	Reason string `json:"reason" binding:"required,min=10,max=1000"`
+}
+
+// ReviewGrantRequest closes an emergency access grant in the review queue.
+// Revoke ends a grant that is still active.
+type ReviewGrantRequest struct {
+	Note   *string `json:"note"`
+	Revoke bool    `json:"revoke"`
+}
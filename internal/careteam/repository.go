@@ -0,0 +1,113 @@
+package careteam
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the interface for care team storage
+type Repository interface {
+	ListMembers(ctx context.Context, patientID int) ([]Member, error)
+	IsMember(ctx context.Context, patientID, doctorID int) (bool, error)
+	IsActiveDoctor(ctx context.Context, userID int) (bool, error)
+	AddMember(ctx context.Context, m *Member) error
+	RemoveMember(ctx context.Context, patientID, doctorID int) error
+}
+
+type postgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository creates a new repository for care teams
+func NewPostgresRepository(db *sqlx.DB) Repository {
+	return &postgresRepository{db: db}
+}
+
+const memberColumns = `m.patient_id, m.doctor_id, u.username AS doctor_username, m.is_primary, m.assigned_by, m.assigned_at`
+
+// ListMembers retrieves a patient's care team, primary physician first. It
+// returns ErrPatientNotFound for patients that do not exist or are deleted.
+func (r *postgresRepository) ListMembers(ctx context.Context, patientID int) ([]Member, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM patients WHERE id = $1 AND deleted_at IS NULL)`, patientID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPatientNotFound
+	}
+
+	members := []Member{}
+	query := `SELECT ` + memberColumns + ` FROM care_team_members m JOIN users u ON u.id = m.doctor_id
+		WHERE m.patient_id = $1 ORDER BY m.is_primary DESC, u.username ASC`
+	err := r.db.SelectContext(ctx, &members, query, patientID)
+	return members, err
+}
+
+// IsMember reports whether a doctor is on a patient's care team
+func (r *postgresRepository) IsMember(ctx context.Context, patientID, doctorID int) (bool, error) {
+	var member bool
+	query := `SELECT EXISTS (SELECT 1 FROM care_team_members WHERE patient_id = $1 AND doctor_id = $2)`
+	err := r.db.GetContext(ctx, &member, query, patientID, doctorID)
+	return member, err
+}
+
+// IsActiveDoctor reports whether a user is an active account with the doctor role
+func (r *postgresRepository) IsActiveDoctor(ctx context.Context, userID int) (bool, error) {
+	var doctor bool
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND role = 'doctor' AND is_active)`
+	err := r.db.GetContext(ctx, &doctor, query, userID)
+	return doctor, err
+}
+
+// AddMember assigns a doctor to a care team, or updates the primary flag of an
+// existing member. A new primary physician replaces the previous one.
+func (r *postgresRepository) AddMember(ctx context.Context, m *Member) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.IsPrimary {
+		if _, err := tx.ExecContext(ctx, `UPDATE care_team_members SET is_primary = FALSE WHERE patient_id = $1 AND is_primary`, m.PatientID); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO care_team_members (patient_id, doctor_id, is_primary, assigned_by, assigned_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (patient_id, doctor_id) DO UPDATE SET is_primary = EXCLUDED.is_primary`
+	if _, err := tx.ExecContext(ctx, query, m.PatientID, m.DoctorID, m.IsPrimary, m.AssignedBy); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "fk_patient" {
+			return ErrPatientNotFound
+		}
+		return err
+	}
+
+	err = tx.GetContext(ctx, m, `SELECT `+memberColumns+` FROM care_team_members m JOIN users u ON u.id = m.doctor_id
+		WHERE m.patient_id = $1 AND m.doctor_id = $2`, m.PatientID, m.DoctorID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveMember removes a doctor from a care team
+func (r *postgresRepository) RemoveMember(ctx context.Context, patientID, doctorID int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM care_team_members WHERE patient_id = $1 AND doctor_id = $2`, patientID, doctorID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package careteam
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+var (
+	ErrAccessDenied    = errors.New("you are not on this patient's care team")
+	ErrNotADoctor      = errors.New("care team members must be active doctors")
+	ErrMemberNotFound  = errors.New("doctor is not on this patient's care team")
+	ErrPatientNotFound = errors.New("patient not found")
+)
+
+// AccessChecker decides whether a caller may see and act on a patient's record
+type AccessChecker interface {
+	CheckPatientAccess(ctx context.Context, caller *middleware.Identity, patientID int) error
+}
+
+// CanAccessAll reports whether the caller may access every patient rather
+// than only those on their care teams
+func CanAccessAll(caller *middleware.Identity) bool {
+	return caller != nil && caller.HasPermission(authz.PatientAccessAll)
+}
+
+// Service manages care team assignments
+type Service interface {
+	AccessChecker
+	ListMembers(ctx context.Context, caller *middleware.Identity, patientID int) ([]Member, error)
+	AddMember(ctx context.Context, actorID, patientID int, req AddMemberRequest) (*Member, error)
+	RemoveMember(ctx context.Context, patientID, doctorID int) error
+}
+
+type service struct {
+	repo   Repository
+	grants EmergencyRepository
+}
+
+// NewService creates a new care team service. Active emergency access grants
+// are honored alongside care team membership.
+func NewService(r Repository, grants EmergencyRepository) Service {
+	return &service{repo: r, grants: grants}
+}
+
+// CheckPatientAccess returns ErrAccessDenied unless the caller may access
+// every patient, is on the patient's care team or holds an active emergency
+// access grant for the patient. API keys have no care teams, so they need the
+// broader permission.
+func (s *service) CheckPatientAccess(ctx context.Context, caller *middleware.Identity, patientID int) error {
+	if caller == nil {
+		return ErrAccessDenied
+	}
+	if CanAccessAll(caller) {
+		return nil
+	}
+	if caller.UserID == 0 {
+		return ErrAccessDenied
+	}
+
+	member, err := s.repo.IsMember(ctx, patientID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	if member {
+		return nil
+	}
+
+	if !caller.HasPermission(authz.EmergencyAccess) {
+		return ErrAccessDenied
+	}
+	granted, err := s.grants.HasActiveGrant(ctx, patientID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+func (s *service) ListMembers(ctx context.Context, caller *middleware.Identity, patientID int) ([]Member, error) {
+	if err := s.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, patientID)
+}
+
+func (s *service) AddMember(ctx context.Context, actorID, patientID int, req AddMemberRequest) (*Member, error) {
+	doctor, err := s.repo.IsActiveDoctor(ctx, req.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	if !doctor {
+		return nil, ErrNotADoctor
+	}
+
+	m := &Member{
+		PatientID:  patientID,
+		DoctorID:   req.DoctorID,
+		IsPrimary:  req.Primary,
+		AssignedBy: &actorID,
+	}
+	if err := s.repo.AddMember(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *service) RemoveMember(ctx context.Context, patientID, doctorID int) error {
+	return s.repo.RemoveMember(ctx, patientID, doctorID)
+}
@@ -0,0 +1,31 @@
+package document
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// CloudinaryFileStore deletes document files from Cloudinary
+type CloudinaryFileStore struct {
+	cloudinary *cloudinary.Cloudinary
+}
+
+// NewCloudinaryFileStore creates a file store backed by Cloudinary
+func NewCloudinaryFileStore(cld *cloudinary.Cloudinary) *CloudinaryFileStore {
+	return &CloudinaryFileStore{cloudinary: cld}
+}
+
+// DeleteFile removes a stored file. Files that no longer exist are not an error.
+func (s *CloudinaryFileStore) DeleteFile(ctx context.Context, publicID string) error {
+	res, err := s.cloudinary.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicID})
+	if err != nil {
+		return err
+	}
+	if res.Error.Message != "" {
+		return fmt.Errorf("cloudinary: %s", res.Error.Message)
+	}
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
 type Handler struct {
@@ -31,6 +34,8 @@ type ErrorResponse struct {
 // @Param        document formData file true "The document to upload"
 // @Success      201 {object} Document
 // @Failure      400 {object} ErrorResponse "Bad request"
+// @Failure      403 {object} ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404 {object} ErrorResponse "Patient not found"
 // @Failure      500 {object} ErrorResponse "Internal server error"
 // @Router       /patients/{id}/documents [post]
 func (h *Handler) UploadDocument(c *gin.Context) {
@@ -46,8 +51,17 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 		return
 	}
 
-	doc, err := h.service.UploadDocument(c.Request.Context(), patientID, file)
+	caller, _ := middleware.GetIdentity(c)
+	doc, err := h.service.UploadDocument(c.Request.Context(), caller, patientID, file)
 	if err != nil {
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload document: " + err.Error()})
 		return
 	}
@@ -57,13 +71,17 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 
 // GetPatientDocuments 
 // @Summary      List documents for a patient
-// @Description  Retrieves a list of all documents for a specific patient.
+// @Description  Retrieves a page of the documents for a specific patient. Pass the returned next_cursor as cursor to fetch the following page.
 // @Tags         Documents
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        id   path      int  true  "Patient ID"
-// @Success      200  {array}   Document
-// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Param        id      path      int     true   "Patient ID"
+// @Param        sort    query     string  false  "Sort key: file_name or uploaded_at; prefix with - for descending" default(-uploaded_at)
+// @Param        limit   query     int     false  "Page size (1-200)" default(50)
+// @Param        cursor  query     string  false  "next_cursor of the previous page"
+// @Success      200  {object}  pagination.Page[Document]
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID, sort key or cursor"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      500  {object}  ErrorResponse "Internal server error"
 // @Router       /patients/{id}/documents [get]
 func (h *Handler) GetPatientDocuments(c *gin.Context) {
@@ -73,13 +91,28 @@ func (h *Handler) GetPatientDocuments(c *gin.Context) {
 		return
 	}
 
-	docs, err := h.service.GetDocumentsForPatient(c.Request.Context(), patientID)
+	var req pagination.Request
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	page, err := h.service.GetDocumentsForPatient(c.Request.Context(), caller, patientID, req)
 	if err != nil {
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, pagination.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve documetns: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, docs)
+	c.JSON(http.StatusOK, page)
 }
 
 // DeleteDocument godoc
@@ -90,6 +123,7 @@ func (h *Handler) GetPatientDocuments(c *gin.Context) {
 // @Param        doc_id   path      int  true  "Document ID"
 // @Success      204  {object}  nil
 // @Failure      400  {object}  ErrorResponse "Invalid document ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      404  {object}  ErrorResponse "Document not found"
 // @Router       /documents/{doc_id} [delete]
 func (h *Handler) DeleteDocument(c *gin.Context) {
@@ -99,12 +133,17 @@ func (h *Handler) DeleteDocument(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteDocument(c.Request.Context(), docID)
+	caller, _ := middleware.GetIdentity(c)
+	err = h.service.DeleteDocument(c.Request.Context(), caller, docID)
 	if err != nil {
 		if errors.Is(err, ErrDocumentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document: " + err.Error()})
 		return
 	}
@@ -4,16 +4,25 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrPatientNotFound  = errors.New("patient not found")
 )
 
-var ErrDocumentNotFound = errors.New("document not found")
+// activePatient excludes the documents of deleted patients
+const activePatient = `patient_id IN (SELECT id FROM patients WHERE deleted_at IS NULL)`
 
 type Repository interface {
 	Create(ctx context.Context, doc *Document) error
 	GetByID(ctx context.Context, id int) (*Document, error)
-	GetByPatientID(ctx context.Context, patientID int) ([]Document, error)
+	GetByPatientID(ctx context.Context, patientID int, req pagination.Request) (*pagination.Page[Document], error)
 	Delete(ctx context.Context, id int) error
 }
 
@@ -26,8 +35,13 @@ func NewPostgresRepository(db *sqlx.DB) Repository {
 }
 
 func (r *postgresRepository) Create(ctx context.Context, doc *Document) error {
-	query := `INSERT INTO patient_documents (patient_id, file_name, file_url, public_id, mime_type, uploaded_at) VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, uploaded_at`
-	return r.db.QueryRowContext(ctx, query, doc.PatientID, doc.FileName, doc.FileURL, doc.PublicID, doc.MimeType).Scan(&doc.ID, &doc.UploadedAt)
+	query := `INSERT INTO patient_documents (patient_id, file_name, file_url, public_id, mime_type, uploaded_at)
+		SELECT id, $2, $3, $4, $5, NOW() FROM patients WHERE id = $1 AND deleted_at IS NULL RETURNING id, uploaded_at`
+	err := r.db.QueryRowContext(ctx, query, doc.PatientID, doc.FileName, doc.FileURL, doc.PublicID, doc.MimeType).Scan(&doc.ID, &doc.UploadedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPatientNotFound
+	}
+	return err
 }
 
 func (r *postgresRepository) GetByID(ctx context.Context, id int) (*Document, error) {
@@ -43,11 +57,39 @@ func (r *postgresRepository) GetByID(ctx context.Context, id int) (*Document, er
 	return &doc, nil
 }
 
-func (r *postgresRepository) GetByPatientID(ctx context.Context, patientID int) ([]Document, error) {
+// documentSorter lists the sort keys of a patient's documents, newest first by default
+var documentSorter = pagination.Sorter[Document]{
+	Keys: map[string]pagination.Column[Document]{
+		"file_name":   {Expr: "file_name", Cast: "text", Value: func(d *Document) string { return d.FileName }},
+		"uploaded_at": {Expr: "uploaded_at", Cast: "timestamp", Value: func(d *Document) string { return pagination.FormatTime(d.UploadedAt) }},
+	},
+	Default:  "-uploaded_at",
+	IDColumn: "id",
+	ID:       func(d *Document) int { return d.ID },
+}
+
+func (r *postgresRepository) GetByPatientID(ctx context.Context, patientID int, req pagination.Request) (*pagination.Page[Document], error) {
+	q, err := documentSorter.Prepare(req, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM patient_documents WHERE patient_id = $1 AND `+activePatient, patientID); err != nil {
+		return nil, err
+	}
+
 	var docs []Document
-	query := `SELECT id, patient_id, file_name, file_url, mime_type, uploaded_at FROM patient_documents WHERE patient_id = $1`
-	err := r.db.SelectContext(ctx, &docs, query, patientID)
-	return docs, err
+	query := `SELECT id, patient_id, file_name, file_url, public_id, mime_type, uploaded_at FROM patient_documents WHERE patient_id = $1 AND ` + activePatient
+	if q.Where != "" {
+		query += ` AND ` + q.Where
+	}
+	query += fmt.Sprintf(` ORDER BY %s LIMIT %d`, q.OrderBy, q.Limit)
+	args := append([]interface{}{patientID}, q.Args...)
+	if err := r.db.SelectContext(ctx, &docs, query, args...); err != nil {
+		return nil, err
+	}
+	return documentSorter.Page(q, docs, total), nil
 }
 
 func (r *postgresRepository) Delete(ctx context.Context, id int) error {
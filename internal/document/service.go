@@ -8,24 +8,32 @@ import (
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
 type Service interface {
-	UploadDocument(ctx context.Context, patientID int, fileHeader *multipart.FileHeader) (*Document, error)
-	GetDocumentsForPatient(ctx context.Context, patientID int) ([]Document, error)
-	DeleteDocument(ctx context.Context, id int) error
+	UploadDocument(ctx context.Context, caller *middleware.Identity, patientID int, fileHeader *multipart.FileHeader) (*Document, error)
+	GetDocumentsForPatient(ctx context.Context, caller *middleware.Identity, patientID int, req pagination.Request) (*pagination.Page[Document], error)
+	DeleteDocument(ctx context.Context, caller *middleware.Identity, id int) error
 }
 
 type service struct {
 	repo Repository
 	cloudinary *cloudinary.Cloudinary
+	access careteam.AccessChecker
 }
 
-func NewService(r Repository, cld *cloudinary.Cloudinary) Service {
-	return &service{repo: r, cloudinary: cld}
+func NewService(r Repository, cld *cloudinary.Cloudinary, access careteam.AccessChecker) Service {
+	return &service{repo: r, cloudinary: cld, access: access}
 }
 
-func (s *service) UploadDocument(ctx context.Context, patientID int, fileHeader *multipart.FileHeader) (*Document, error) {
+func (s *service) UploadDocument(ctx context.Context, caller *middleware.Identity, patientID int, fileHeader *multipart.FileHeader) (*Document, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+
 	log.Println("📥 Starting document upload for patient:", patientID)
 	log.Println("📄 File received:", fileHeader.Filename)
 
@@ -71,15 +79,21 @@ func (s *service) UploadDocument(ctx context.Context, patientID int, fileHeader
 	return doc, nil
 }
 
-func (s *service) GetDocumentsForPatient(ctx context.Context, patientID int) ([]Document, error) {
-	return s.repo.GetByPatientID(ctx, patientID)
+func (s *service) GetDocumentsForPatient(ctx context.Context, caller *middleware.Identity, patientID int, req pagination.Request) (*pagination.Page[Document], error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByPatientID(ctx, patientID, req)
 }
 
-func (s *service) DeleteDocument(ctx context.Context, id int) error {
+func (s *service) DeleteDocument(ctx context.Context, caller *middleware.Identity, id int) error {
 	doc, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := s.access.CheckPatientAccess(ctx, caller, doc.PatientID); err != nil {
+		return err
+	}
 
 	_, err = s.cloudinary.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: doc.PublicID})
 	if err != nil {
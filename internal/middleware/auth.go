@@ -1,57 +1,137 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // ContextKeyUser is the key for the user role in the context
 const (
 	ContextKeyUserRole = "userRole"
 	ContextKeyUserID   = "userID"
+	ContextKeyIdentity = "identity"
 )
 
-// AuthMiddleware creates a gin middleware for JWT authentication
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// ErrUnauthenticated is wrapped by TokenVerifier implementations when the presented
+// credential is rejected. Any other error is treated as a server-side failure.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Identity describes the authenticated caller of a request
+type Identity struct {
+	UserID                 int
+	Username               string
+	Role                   string
+	TokenID                string
+	SessionID              string
+	ExpiresAt              time.Time
+	PasswordChangeRequired bool
+	// Permissions granted to the caller's role, filled in by LoadPermissions.
+	// For API keys these are the key's scopes.
+	Permissions []string
+	// APIKeyID is set when the caller authenticated with an API key instead of
+	// as a user. UserID is zero in that case.
+	APIKeyID int
+	// PatientID is set for patient portal accounts to the patient record the
+	// account belongs to
+	PatientID int
+}
+
+// IsAPIKey reports whether the caller authenticated with an API key
+func (i *Identity) IsAPIKey() bool {
+	return i.APIKeyID != 0
+}
+
+// HasPermission reports whether the identity was granted the named permission
+func (i *Identity) HasPermission(permission string) bool {
+	for _, p := range i.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenVerifier validates a bearer token and returns the identity it was issued to
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (*Identity, error)
+}
+
+// AuthMiddleware creates a gin middleware for JWT authentication. Token validation,
+// including revocation and account status checks, is delegated to the verifier.
+// API keys may be sent as a Bearer token or in the X-API-Key header.
+func AuthMiddleware(verifier TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			return
+		token := c.GetHeader("X-API-Key")
+		if token == "" {
+			authHeader := c.GetHeader("Authorization")
+			if authHeader == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+				return
+			}
+			token = parts[1]
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+		identity, err := verifier.VerifyAccessToken(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, ErrUnauthenticated) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secretKey), nil
-		})
+		c.Set(ContextKeyIdentity, identity)
+		c.Set(ContextKeyUserRole, identity.Role)
+		c.Set(ContextKeyUserID, identity.UserID)
+		c.Next()
+	}
+}
 
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+// GetIdentity returns the identity stored in the context by AuthMiddleware
+func GetIdentity(c *gin.Context) (*Identity, bool) {
+	val, exists := c.Get(ContextKeyIdentity)
+	if !exists {
+		return nil, false
+	}
+	identity, ok := val.(*Identity)
+	return identity, ok
+}
+
+// PasswordChangeMiddleware blocks requests from users who must change their
+// password before they may use the rest of the API
+func PasswordChangeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if ok && identity.PasswordChangeRequired {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Password change required before accessing this resource"})
 			return
 		}
+		c.Next()
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set(ContextKeyUserRole, claims["role"])
-			if userIDFloat, ok := claims["sub"].(float64); ok {
-				c.Set(ContextKeyUserID, int(userIDFloat))
-			}
-			c.Next()
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+// UserOnlyMiddleware rejects callers that authenticated with an API key, for
+// endpoints that act on the caller's own account or session
+func UserOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if ok && identity.IsAPIKey() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint is not available to API keys"})
+			return
 		}
+		c.Next()
 	}
 }
 
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionResolver returns the permissions granted to a role
+type PermissionResolver interface {
+	RolePermissions(ctx context.Context, role string) ([]string, error)
+}
+
+// LoadPermissions resolves the permissions of the authenticated caller's role
+// and stores them on the identity. It must run after AuthMiddleware. API keys
+// have no role; they keep the scopes their verifier granted.
+func LoadPermissions(resolver PermissionResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Identity not found in context"})
+			return
+		}
+		if identity.IsAPIKey() {
+			c.Next()
+			return
+		}
+
+		permissions, err := resolver.RolePermissions(c.Request.Context(), identity.Role)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve permissions"})
+			return
+		}
+		identity.Permissions = permissions
+		c.Next()
+	}
+}
+
+// RequirePermission creates a gin middleware that only lets callers through
+// whose role has been granted the named permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok || !identity.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this resource"})
+			return
+		}
+		c.Next()
+	}
+}
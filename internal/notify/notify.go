@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Message is a notification addressed to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers notifications. Implementations may send email, SMS, or
+// anything else; the rest of the application only depends on this interface.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender writes notifications to the application log. It is intended for
+// local development where no delivery channel is configured.
+type LogSender struct{}
+
+// NewLogSender creates a sender that logs every message
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send logs the message
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("📨 Notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// MemorySender keeps every message in memory so tests can inspect what was sent
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemorySender creates an empty in-memory sender
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send records the message
+func (s *MemorySender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every message sent so far
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
@@ -0,0 +1,85 @@
+package patient
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout is the format of dates of birth in requests and responses
+const DateLayout = "2006-01-02"
+
+// MaxAgeYears is the oldest age accepted for a date of birth
+const MaxAgeYears = 130
+
+var ErrInvalidDateOfBirth = fmt.Errorf("date of birth must be a %s date, not in the future and at most %d years ago", DateLayout, MaxAgeYears)
+
+// AgeUnit is the unit an age is given in
+type AgeUnit string
+
+const (
+	AgeYears  AgeUnit = "years"
+	AgeMonths AgeUnit = "months"
+	AgeWeeks  AgeUnit = "weeks"
+	AgeDays   AgeUnit = "days"
+)
+
+// Age is a patient's age computed from their date of birth. Patients under
+// two are aged in months, under a month in weeks and under a week in days.
+type Age struct {
+	Value int     `json:"value"`
+	Unit  AgeUnit `json:"unit"`
+}
+
+func (a Age) String() string {
+	return fmt.Sprintf("%d %s", a.Value, a.Unit)
+}
+
+// AgeAt returns the age on the day of now of someone born on dob
+func AgeAt(dob, now time.Time) Age {
+	dob, now = dateOf(dob), dateOf(now)
+	months := (now.Year()-dob.Year())*12 + int(now.Month()-dob.Month())
+	if now.Day() < dob.Day() {
+		months--
+	}
+	switch {
+	case months >= 24:
+		return Age{Value: months / 12, Unit: AgeYears}
+	case months >= 1:
+		return Age{Value: months, Unit: AgeMonths}
+	}
+	days := int(now.Sub(dob).Hours() / 24)
+	if days >= 7 {
+		return Age{Value: days / 7, Unit: AgeWeeks}
+	}
+	return Age{Value: days, Unit: AgeDays}
+}
+
+// AgeInYears returns the completed years on the day of now of someone born on dob
+func AgeInYears(dob, now time.Time) int {
+	dob, now = dateOf(dob), dateOf(now)
+	years := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
+
+// ParseDateOfBirth parses a date of birth and rejects dates in the future and
+// implausible ages
+func ParseDateOfBirth(s string, now time.Time) (time.Time, error) {
+	dob, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDateOfBirth, err)
+	}
+	if dob.After(dateOf(now)) || AgeInYears(dob, now) > MaxAgeYears {
+		return time.Time{}, ErrInvalidDateOfBirth
+	}
+	return dob, nil
+}
+
+// dateOf returns the calendar day of t as midnight UTC, the form dates are
+// read from the database in
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
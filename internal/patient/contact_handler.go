@@ -0,0 +1,181 @@
+package patient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// ContactHandler holds the dependencies for the emergency contact handlers
+type ContactHandler struct {
+	service ContactService
+}
+
+// NewContactHandler creates a new emergency contact handler
+func NewContactHandler(s ContactService) *ContactHandler {
+	return &ContactHandler{service: s}
+}
+
+// ListContacts godoc
+// @Summary      List a patient's emergency contacts
+// @Description  Retrieves the patient's emergency contacts, primary contact first.
+// @Tags         Emergency Contacts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Patient ID"
+// @Success      200  {array}   EmergencyContact
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Patient not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/emergency-contacts [get]
+func (h *ContactHandler) ListContacts(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	contacts, err := h.service.ListContacts(c.Request.Context(), caller, patientID)
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, contacts)
+}
+
+// AddContact godoc
+// @Summary      Add an emergency contact
+// @Description  Adds an emergency contact to the patient. A patient has at most 5 contacts; a new primary contact replaces the previous one.
+// @Tags         Emergency Contacts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      int                      true  "Patient ID"
+// @Param        contact  body      EmergencyContactRequest  true  "Contact"
+// @Success      201      {object}  EmergencyContact
+// @Failure      400      {object}  ErrorResponse "Invalid request body or contact"
+// @Failure      403      {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404      {object}  ErrorResponse "Patient not found"
+// @Failure      409      {object}  ErrorResponse "Patient already has 5 contacts"
+// @Failure      500      {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/emergency-contacts [post]
+func (h *ContactHandler) AddContact(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	contact, err := h.service.AddContact(c.Request.Context(), caller, patientID, req)
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, contact)
+}
+
+// UpdateContact godoc
+// @Summary      Replace an emergency contact
+// @Description  Replaces one of the patient's emergency contacts.
+// @Tags         Emergency Contacts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id          path      int                      true  "Patient ID"
+// @Param        contact_id  path      int                      true  "Contact ID"
+// @Param        contact     body      EmergencyContactRequest  true  "Contact"
+// @Success      200         {object}  EmergencyContact
+// @Failure      400         {object}  ErrorResponse "Invalid request body, ID or contact"
+// @Failure      403         {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404         {object}  ErrorResponse "Contact not found"
+// @Failure      500         {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/emergency-contacts/{contact_id} [put]
+func (h *ContactHandler) UpdateContact(c *gin.Context) {
+	patientID, contactID, ok := contactParams(c)
+	if !ok {
+		return
+	}
+
+	var req EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	contact, err := h.service.UpdateContact(c.Request.Context(), caller, patientID, contactID, req)
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, contact)
+}
+
+// DeleteContact godoc
+// @Summary      Remove an emergency contact
+// @Description  Removes one of the patient's emergency contacts.
+// @Tags         Emergency Contacts
+// @Security     ApiKeyAuth
+// @Param        id          path  int  true  "Patient ID"
+// @Param        contact_id  path  int  true  "Contact ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse "Invalid ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Contact not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/emergency-contacts/{contact_id} [delete]
+func (h *ContactHandler) DeleteContact(c *gin.Context) {
+	patientID, contactID, ok := contactParams(c)
+	if !ok {
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	if err := h.service.DeleteContact(c.Request.Context(), caller, patientID, contactID); err != nil {
+		writeContactError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func contactParams(c *gin.Context) (patientID, contactID int, ok bool) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return 0, 0, false
+	}
+	contactID, err = strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return 0, 0, false
+	}
+	return patientID, contactID, true
+}
+
+func writeContactError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidContact), errors.Is(err, ErrInvalidContactPhone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, careteam.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound), errors.Is(err, ErrContactNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTooManyContacts):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
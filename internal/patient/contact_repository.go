@@ -0,0 +1,123 @@
+package patient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ContactRepository defines the interface for emergency contact storage
+type ContactRepository interface {
+	ListContacts(ctx context.Context, patientID int) ([]EmergencyContact, error)
+	CreateContact(ctx context.Context, c *EmergencyContact) error
+	UpdateContact(ctx context.Context, c *EmergencyContact) error
+	DeleteContact(ctx context.Context, patientID, id int) error
+}
+
+type postgresContactRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresContactRepository creates a new repository for emergency contacts
+func NewPostgresContactRepository(db *sqlx.DB) ContactRepository {
+	return &postgresContactRepository{db: db}
+}
+
+const contactColumns = `id, patient_id, name, relationship, phone_number, email, is_primary, created_at, updated_at`
+
+// ListContacts retrieves a patient's emergency contacts, primary contact
+// first. It returns ErrPatientNotFound for patients that do not exist or are
+// deleted.
+func (r *postgresContactRepository) ListContacts(ctx context.Context, patientID int) ([]EmergencyContact, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM patients WHERE id = $1 AND deleted_at IS NULL)`, patientID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPatientNotFound
+	}
+
+	contacts := []EmergencyContact{}
+	query := `SELECT ` + contactColumns + ` FROM patient_emergency_contacts WHERE patient_id = $1 ORDER BY is_primary DESC, id ASC`
+	err := r.db.SelectContext(ctx, &contacts, query, patientID)
+	return contacts, err
+}
+
+// CreateContact adds an emergency contact to an active patient. A new primary
+// contact replaces the previous one.
+func (r *postgresContactRepository) CreateContact(ctx context.Context, c *EmergencyContact) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if c.IsPrimary {
+		if err := unsetPrimaryContact(ctx, tx, c.PatientID); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO patient_emergency_contacts (patient_id, name, relationship, phone_number, email, is_primary, created_at, updated_at)
+		SELECT id, $2, $3, $4, $5, $6, NOW(), NOW() FROM patients WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + contactColumns
+	err = tx.GetContext(ctx, c, query, c.PatientID, c.Name, c.Relationship, c.PhoneNumber, c.Email, c.IsPrimary)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPatientNotFound
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateContact replaces an emergency contact of a patient
+func (r *postgresContactRepository) UpdateContact(ctx context.Context, c *EmergencyContact) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if c.IsPrimary {
+		if err := unsetPrimaryContact(ctx, tx, c.PatientID); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE patient_emergency_contacts
+		SET name = $3, relationship = $4, phone_number = $5, email = $6, is_primary = $7, updated_at = NOW()
+		WHERE id = $1 AND patient_id = $2
+		RETURNING ` + contactColumns
+	err = tx.GetContext(ctx, c, query, c.ID, c.PatientID, c.Name, c.Relationship, c.PhoneNumber, c.Email, c.IsPrimary)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrContactNotFound
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func unsetPrimaryContact(ctx context.Context, tx *sqlx.Tx, patientID int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE patient_emergency_contacts SET is_primary = FALSE WHERE patient_id = $1 AND is_primary`, patientID)
+	return err
+}
+
+// DeleteContact removes an emergency contact of a patient
+func (r *postgresContactRepository) DeleteContact(ctx context.Context, patientID, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM patient_emergency_contacts WHERE id = $1 AND patient_id = $2`, id, patientID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrContactNotFound
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// MaxEmergencyContacts is the number of emergency contacts a patient may have
+const MaxEmergencyContacts = 5
+
+var (
+	ErrContactNotFound     = errors.New("emergency contact not found")
+	ErrTooManyContacts     = errors.New("a patient can have at most 5 emergency contacts")
+	ErrInvalidContact      = errors.New("emergency contact name and relationship must not be blank")
+	ErrInvalidContactPhone = errors.New("emergency contact phone number must have at least 6 digits")
+)
+
+// ContactService manages the emergency contacts of patients the caller may access
+type ContactService interface {
+	ListContacts(ctx context.Context, caller *middleware.Identity, patientID int) ([]EmergencyContact, error)
+	AddContact(ctx context.Context, caller *middleware.Identity, patientID int, req EmergencyContactRequest) (*EmergencyContact, error)
+	UpdateContact(ctx context.Context, caller *middleware.Identity, patientID, id int, req EmergencyContactRequest) (*EmergencyContact, error)
+	DeleteContact(ctx context.Context, caller *middleware.Identity, patientID, id int) error
+}
+
+type contactService struct {
+	repo   ContactRepository
+	access careteam.AccessChecker
+}
+
+// NewContactService creates a new emergency contact service
+func NewContactService(r ContactRepository, access careteam.AccessChecker) ContactService {
+	return &contactService{repo: r, access: access}
+}
+
+func (s *contactService) ListContacts(ctx context.Context, caller *middleware.Identity, patientID int) ([]EmergencyContact, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListContacts(ctx, patientID)
+}
+
+// AddContact adds an emergency contact, up to MaxEmergencyContacts per patient
+func (s *contactService) AddContact(ctx context.Context, caller *middleware.Identity, patientID int, req EmergencyContactRequest) (*EmergencyContact, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	c, err := newContact(patientID, req)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ListContacts(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= MaxEmergencyContacts {
+		return nil, ErrTooManyContacts
+	}
+	if err := s.repo.CreateContact(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *contactService) UpdateContact(ctx context.Context, caller *middleware.Identity, patientID, id int, req EmergencyContactRequest) (*EmergencyContact, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	c, err := newContact(patientID, req)
+	if err != nil {
+		return nil, err
+	}
+	c.ID = id
+	if err := s.repo.UpdateContact(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *contactService) DeleteContact(ctx context.Context, caller *middleware.Identity, patientID, id int) error {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return err
+	}
+	return s.repo.DeleteContact(ctx, patientID, id)
+}
+
+// newContact validates a request and returns the contact it describes
+func newContact(patientID int, req EmergencyContactRequest) (*EmergencyContact, error) {
+	name, relationship := strings.TrimSpace(req.Name), strings.TrimSpace(req.Relationship)
+	if name == "" || relationship == "" {
+		return nil, ErrInvalidContact
+	}
+	phone := strings.TrimSpace(req.PhoneNumber)
+	if len(phoneDigits(phone)) < 6 {
+		return nil, ErrInvalidContactPhone
+	}
+	c := &EmergencyContact{
+		PatientID:    patientID,
+		Name:         name,
+		Relationship: strings.ToLower(relationship),
+		PhoneNumber:  phone,
+		Email:        trimmed(req.Email),
+		IsPrimary:    req.IsPrimary,
+	}
+	if c.Email != nil {
+		email := strings.ToLower(*c.Email)
+		c.Email = &email
+	}
+	return c, nil
+}
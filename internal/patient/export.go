@@ -0,0 +1,300 @@
+package patient
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Export formats
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+	ExportPDF  = "pdf"
+)
+
+// Export statuses
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// ExportSyncLimit is the largest export streamed in the response; larger
+// exports are produced in the background
+const ExportSyncLimit = 1000
+
+// ExportRequest is an export of the patient list in the given format, with
+// the filters and sort order of the list. Limit and cursor are ignored.
+type ExportRequest struct {
+	Format string `form:"format" binding:"required,oneof=csv xlsx pdf"`
+	ListOptions
+}
+
+// Export is an export of the patient list produced in the background. It can
+// be downloaded once completed, until it expires, by the user or API key that
+// requested it.
+type Export struct {
+	ID                int           `json:"id" db:"id"`
+	Format            string        `json:"format" db:"format"`
+	Filters           ExportFilters `json:"-" db:"filters"`
+	Clinical          bool          `json:"clinical" db:"clinical"`
+	PanelOf           int           `json:"-" db:"panel_of"`
+	Status            string        `json:"status" db:"status"`
+	RowCount          *int          `json:"row_count,omitempty" db:"row_count"`
+	Error             *string       `json:"error,omitempty" db:"error"`
+	RequestedBy       *int          `json:"requested_by,omitempty" db:"requested_by"`
+	RequestedByAPIKey *int          `json:"requested_by_api_key,omitempty" db:"requested_by_api_key"`
+	CreatedAt         time.Time     `json:"created_at" db:"created_at"`
+	StartedAt         *time.Time    `json:"-" db:"started_at"`
+	CompletedAt       *time.Time    `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt         *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// ExportFilters are the list filters of an export, stored as JSON
+type ExportFilters ListOptions
+
+func (f ExportFilters) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+func (f *ExportFilters) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported type %T for export filters", src)
+	}
+	return json.Unmarshal(b, f)
+}
+
+// ExportContentType returns the media type of an export format
+func ExportContentType(format string) string {
+	switch format {
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ExportPDF:
+		return "application/pdf"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// exportColumn is a column of an export and its width in PDF reports, in mm.
+// Columns without a width are left out of PDF reports to fit landscape A4.
+type exportColumn struct {
+	Title string
+	Width float64
+	Value func(p *Patient, now time.Time) string
+}
+
+var demographicColumns = []exportColumn{
+	{"ID", 12, func(p *Patient, _ time.Time) string { return strconv.Itoa(p.ID) }},
+	{"MRN", 22, func(p *Patient, _ time.Time) string { return deref(p.MRN) }},
+	{"Name", 42, func(p *Patient, _ time.Time) string { return p.Name }},
+	{"Date of birth", 22, func(p *Patient, _ time.Time) string { return p.DateOfBirth.Format(DateLayout) }},
+	{"Age", 18, func(p *Patient, now time.Time) string { return AgeAt(p.DateOfBirth, now).String() }},
+	{"Sex", 14, func(p *Patient, _ time.Time) string { return deref(p.Sex) }},
+	{"Phone number", 26, func(p *Patient, _ time.Time) string { return deref(p.PhoneNumber) }},
+	{"Email", 0, func(p *Patient, _ time.Time) string { return deref(p.Email) }},
+	{"Address", 44, func(p *Patient, _ time.Time) string { return p.Address }},
+	{"City", 0, func(p *Patient, _ time.Time) string { return deref(p.City) }},
+	{"Postcode", 0, func(p *Patient, _ time.Time) string { return deref(p.Postcode) }},
+	{"Country", 0, func(p *Patient, _ time.Time) string { return deref(p.Country) }},
+	{"Registered", 0, func(p *Patient, _ time.Time) string { return p.CreatedAt.Format(DateLayout) }},
+}
+
+var clinicalColumns = []exportColumn{
+	{"Diagnosis", 35, func(p *Patient, _ time.Time) string { return deref(p.Diagnosis) }},
+	{"Notes", 42, func(p *Patient, _ time.Time) string { return deref(p.Notes) }},
+}
+
+// exportColumns returns the columns of an export; diagnosis and notes only
+// with clinical access
+func exportColumns(clinical bool) []exportColumn {
+	columns := append([]exportColumn{}, demographicColumns...)
+	if clinical {
+		columns = append(columns, clinicalColumns...)
+	}
+	return columns
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// exportSource calls emit for every patient of an export, in order
+type exportSource func(emit func(p *Patient) error) error
+
+// writeExport writes the patients of source to w in the given format and
+// returns how many were written
+func writeExport(w io.Writer, format string, clinical bool, source exportSource) (int, error) {
+	columns := exportColumns(clinical)
+	now := time.Now()
+	switch format {
+	case ExportCSV:
+		return writeCSV(w, columns, source, now)
+	case ExportXLSX:
+		return writeXLSX(w, columns, source, now)
+	case ExportPDF:
+		return writePDF(w, columns, source, now)
+	}
+	return 0, fmt.Errorf("unsupported export format %q", format)
+}
+
+func writeCSV(w io.Writer, columns []exportColumn, source exportSource, now time.Time) (int, error) {
+	out := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.Title
+	}
+	if err := out.Write(record); err != nil {
+		return 0, err
+	}
+	n := 0
+	err := source(func(p *Patient) error {
+		for i, c := range columns {
+			record[i] = c.Value(p, now)
+		}
+		n++
+		if err := out.Write(record); err != nil {
+			return err
+		}
+		// Hand every full page to the client as it is produced
+		if n%exportPageSize == 0 {
+			out.Flush()
+			return out.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	out.Flush()
+	return n, out.Error()
+}
+
+func writeXLSX(w io.Writer, columns []exportColumn, source exportSource, now time.Time) (int, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	const sheet = "Patients"
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		return 0, err
+	}
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return 0, err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return 0, err
+	}
+
+	row := make([]interface{}, len(columns))
+	for i, c := range columns {
+		row[i] = excelize.Cell{StyleID: bold, Value: c.Title}
+	}
+	if err := sw.SetRow("A1", row, excelize.RowOpts{Height: 18}); err != nil {
+		return 0, err
+	}
+	n := 0
+	err = source(func(p *Patient) error {
+		for i, c := range columns {
+			row[i] = c.Value(p, now)
+		}
+		n++
+		cell, err := excelize.CoordinatesToCellName(1, n+1)
+		if err != nil {
+			return err
+		}
+		return sw.SetRow(cell, row)
+	})
+	if err != nil {
+		return n, err
+	}
+	if err := sw.Flush(); err != nil {
+		return n, err
+	}
+	return n, f.Write(w)
+}
+
+// writePDF writes a landscape report with the column titles repeated and the
+// page number on every page. Long values are cut to their column.
+func writePDF(w io.Writer, columns []exportColumn, source exportSource, now time.Time) (int, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetAutoPageBreak(true, 14)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	printed := columns[:0:0]
+	for _, c := range columns {
+		if c.Width > 0 {
+			printed = append(printed, c)
+		}
+	}
+	columns = printed
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, "Patient list", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, tr("Generated "+now.Format("2006-01-02 15:04 MST")), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 7)
+		pdf.SetFillColor(230, 230, 230)
+		for _, c := range columns {
+			pdf.CellFormat(c.Width, 6, tr(c.Title), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 7)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	n := 0
+	err := source(func(p *Patient) error {
+		for _, c := range columns {
+			pdf.CellFormat(c.Width, 5, fitText(pdf, tr(c.Value(p, now)), c.Width-2), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+		n++
+		return pdf.Error()
+	})
+	if err != nil {
+		return n, err
+	}
+	if n == 0 {
+		pdf.CellFormat(0, 6, "No patients match the filters.", "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return n, err
+	}
+	_, err = buf.WriteTo(w)
+	return n, err
+}
+
+// fitText cuts s to the given width in the current font
+func fitText(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,170 @@
+package patient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+// ExportHandler holds the dependencies for the patient export handlers
+type ExportHandler struct {
+	service ExportService
+}
+
+// NewExportHandler creates a new patient export handler
+func NewExportHandler(s ExportService) *ExportHandler {
+	return &ExportHandler{service: s}
+}
+
+// ExportPatients godoc
+// @Summary      Export the patient list
+// @Description  Exports the patients the caller may list, with the filters and sort order of GET /patients, as CSV, XLSX or a PDF report. Diagnosis and notes are only included for callers with patient:clinical:read. Exports of up to 1000 patients are returned directly; larger ones are produced in the background and can be downloaded from the returned export once completed.
+// @Tags         Patient Exports
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      application/pdf
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        format         query     string  true   "csv, xlsx or pdf"
+// @Param        age_min        query     int     false  "Minimum age in completed years"
+// @Param        age_max        query     int     false  "Maximum age in completed years"
+// @Param        created_from   query     string  false  "Created on or after (YYYY-MM-DD)"
+// @Param        created_to     query     string  false  "Created on or before (YYYY-MM-DD)"
+// @Param        updated_from   query     string  false  "Updated on or after (YYYY-MM-DD)"
+// @Param        updated_to     query     string  false  "Updated on or before (YYYY-MM-DD)"
+// @Param        has_diagnosis  query     bool    false  "Only patients with (true) or without (false) a diagnosis; requires patient:clinical:read"
+// @Param        doctor_id      query     int     false  "Only patients on this doctor's care team"
+// @Param        sort           query     string  false  "Sort key: name, date_of_birth, created_at or updated_at; prefix with - for descending" default(-created_at)
+// @Success      200  {file}    file    "The export"
+// @Success      202  {object}  Export  "Export queued; see Location"
+// @Failure      400  {object}  ErrorResponse "Invalid format, filter or sort key"
+// @Failure      403  {object}  ErrorResponse "Forbidden or filtering by diagnosis without patient:clinical:read"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/export [get]
+func (h *ExportHandler) ExportPatients(c *gin.Context) {
+	var req ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	w := &exportWriter{c: c, filename: fmt.Sprintf("patients-%s.%s", time.Now().Format("20060102"), req.Format), format: req.Format}
+	e, err := h.service.ExportPatients(c.Request.Context(), caller, req, w)
+	if err != nil {
+		if w.started {
+			// The status is sent; all that is left is to cut the file short
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		writeExportError(c, err)
+		return
+	}
+	if e != nil {
+		c.Header("Location", fmt.Sprintf("/api/v1/patient-exports/%d", e.ID))
+		c.JSON(http.StatusAccepted, e)
+	}
+}
+
+// exportWriter sends the headers of an export file before its first bytes,
+// so that errors found before anything is written still get a JSON response
+type exportWriter struct {
+	c        *gin.Context
+	filename string
+	format   string
+	started  bool
+}
+
+func (w *exportWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", ExportContentType(w.format))
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	n, err := w.c.Writer.Write(b)
+	w.c.Writer.Flush()
+	return n, err
+}
+
+// GetExport godoc
+// @Summary      Get a patient export
+// @Description  Retrieves the status of an export the caller requested.
+// @Tags         Patient Exports
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Export ID"
+// @Success      200  {object}  Export
+// @Failure      400  {object}  ErrorResponse "Invalid export ID"
+// @Failure      404  {object}  ErrorResponse "Export not found"
+// @Router       /patient-exports/{id} [get]
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	e, err := h.service.GetExport(c.Request.Context(), caller, id)
+	if err != nil {
+		writeExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// DownloadExport godoc
+// @Summary      Download a patient export
+// @Description  Downloads the file of a completed export the caller requested.
+// @Tags         Patient Exports
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      application/pdf
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Export ID"
+// @Success      200  {file}    file "The export"
+// @Failure      400  {object}  ErrorResponse "Invalid export ID"
+// @Failure      404  {object}  ErrorResponse "Export not found or expired"
+// @Failure      409  {object}  ErrorResponse "Export not completed"
+// @Router       /patient-exports/{id}/download [get]
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	e, content, err := h.service.DownloadExport(c.Request.Context(), caller, id)
+	if err != nil {
+		writeExportError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("patients-%s.%s", e.CreatedAt.Format("20060102"), e.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, ExportContentType(e.Format), content)
+}
+
+func writeExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pagination.ErrInvalidSort):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrFilterForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrExportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrExportNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
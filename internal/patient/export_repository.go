@@ -0,0 +1,114 @@
+package patient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ExportRepository defines the interface for exports of the patient list
+// produced in the background
+type ExportRepository interface {
+	CreateExport(ctx context.Context, e *Export) error
+	GetExport(ctx context.Context, id int) (*Export, error)
+	GetExportContent(ctx context.Context, id int) ([]byte, error)
+	ClaimExport(ctx context.Context, staleAfter time.Duration) (*Export, error)
+	CompleteExport(ctx context.Context, id, rowCount int, content []byte, ttl time.Duration) error
+	FailExport(ctx context.Context, id int, reason string, ttl time.Duration) error
+	DeleteExpiredExports(ctx context.Context) (int, error)
+}
+
+type postgresExportRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresExportRepository creates a new repository for patient exports
+func NewPostgresExportRepository(db *sqlx.DB) ExportRepository {
+	return &postgresExportRepository{db: db}
+}
+
+// exportColumnList leaves out the content, which is only read for downloads
+const exportColumnList = `id, format, filters, clinical, panel_of, status, row_count, error, requested_by, requested_by_api_key, created_at, started_at, completed_at, expires_at`
+
+func (r *postgresExportRepository) CreateExport(ctx context.Context, e *Export) error {
+	query := `INSERT INTO patient_exports (format, filters, clinical, panel_of, status, requested_by, requested_by_api_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+	return r.db.QueryRowxContext(ctx, query, e.Format, e.Filters, e.Clinical, e.PanelOf, e.Status, e.RequestedBy, e.RequestedByAPIKey).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *postgresExportRepository) GetExport(ctx context.Context, id int) (*Export, error) {
+	var e Export
+	err := r.db.GetContext(ctx, &e, `SELECT `+exportColumnList+` FROM patient_exports WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *postgresExportRepository) GetExportContent(ctx context.Context, id int) ([]byte, error) {
+	var content []byte
+	err := r.db.GetContext(ctx, &content, `SELECT content FROM patient_exports WHERE id = $1 AND status = 'completed'`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	return content, err
+}
+
+// ClaimExport marks the oldest pending export as running and returns it, or
+// nil if none is waiting. An export left running for longer than staleAfter,
+// by a server that stopped, is claimed again. Concurrent servers never claim
+// the same export.
+func (r *postgresExportRepository) ClaimExport(ctx context.Context, staleAfter time.Duration) (*Export, error) {
+	query := `UPDATE patient_exports SET status = 'running', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM patient_exports
+			WHERE status = 'pending' OR (status = 'running' AND started_at < NOW() - $1 * INTERVAL '1 second')
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + exportColumnList
+	var e Export
+	err := r.db.GetContext(ctx, &e, query, staleAfter.Seconds())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *postgresExportRepository) CompleteExport(ctx context.Context, id, rowCount int, content []byte, ttl time.Duration) error {
+	query := `UPDATE patient_exports SET status = 'completed', row_count = $2, content = $3, error = NULL,
+			completed_at = NOW(), expires_at = NOW() + $4 * INTERVAL '1 second'
+		WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, rowCount, content, ttl.Seconds())
+	return err
+}
+
+func (r *postgresExportRepository) FailExport(ctx context.Context, id int, reason string, ttl time.Duration) error {
+	query := `UPDATE patient_exports SET status = 'failed', error = $2,
+			completed_at = NOW(), expires_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, reason, ttl.Seconds())
+	return err
+}
+
+// DeleteExpiredExports deletes finished exports past their expiry and
+// returns how many were deleted
+func (r *postgresExportRepository) DeleteExpiredExports(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM patient_exports WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,221 @@
+package patient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportNotReady = errors.New("export is not completed")
+)
+
+// exportPageSize is the number of patients read per query while exporting
+const exportPageSize = pagination.MaxLimit
+
+// exportStaleAfter is how long an export may run before it is considered
+// abandoned by a server that stopped and is produced again
+const exportStaleAfter = 30 * time.Minute
+
+// ExportService exports the patient list to CSV, XLSX and PDF
+type ExportService interface {
+	ExportPatients(ctx context.Context, caller *middleware.Identity, req ExportRequest, w io.Writer) (*Export, error)
+	GetExport(ctx context.Context, caller *middleware.Identity, id int) (*Export, error)
+	DownloadExport(ctx context.Context, caller *middleware.Identity, id int) (*Export, []byte, error)
+	ProcessPending(ctx context.Context) (bool, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type exportService struct {
+	repo     ExportRepository
+	patients Repository
+	ttl      time.Duration
+}
+
+// NewExportService creates a new patient export service. Exports produced in
+// the background can be downloaded for ttl after they complete.
+func NewExportService(r ExportRepository, patients Repository, ttl time.Duration) ExportService {
+	return &exportService{repo: r, patients: patients, ttl: ttl}
+}
+
+// ExportPatients exports the patients the caller may list, with the filters
+// and sort order of the request. Diagnosis and notes are only included for
+// callers with clinical access. An export of up to ExportSyncLimit patients
+// is written to w and nil is returned; a larger one is queued and returned
+// without writing anything.
+func (s *exportService) ExportPatients(ctx context.Context, caller *middleware.Identity, req ExportRequest, w io.Writer) (*Export, error) {
+	if req.HasDiagnosis != nil && !HasClinicalAccess(caller) {
+		return nil, ErrFilterForbidden
+	}
+	opts := req.ListOptions
+	opts.Deleted = false
+	opts.Cursor = ""
+	opts.Limit = exportPageSize
+	opts.PanelOf = 0
+	if !careteam.CanAccessAll(caller) {
+		if caller == nil || caller.UserID == 0 {
+			_, err := writeExport(w, req.Format, false, func(func(*Patient) error) error { return nil })
+			return nil, err
+		}
+		opts.PanelOf = caller.UserID
+	}
+	clinical := HasClinicalAccess(caller)
+
+	first, err := s.patients.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if first.Total <= ExportSyncLimit {
+		_, err := writeExport(w, req.Format, clinical, s.source(ctx, opts, first))
+		return nil, err
+	}
+
+	by := actorOf(caller)
+	e := &Export{
+		Format:            req.Format,
+		Filters:           ExportFilters(opts),
+		Clinical:          clinical,
+		PanelOf:           opts.PanelOf,
+		Status:            ExportPending,
+		RequestedBy:       by.UserID,
+		RequestedByAPIKey: by.APIKeyID,
+	}
+	if err := s.repo.CreateExport(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// source returns the patients of a listing page by page, starting with the
+// first page already read
+func (s *exportService) source(ctx context.Context, opts ListOptions, first *pagination.Page[Patient]) exportSource {
+	return func(emit func(p *Patient) error) error {
+		page := first
+		for {
+			for i := range page.Items {
+				if err := emit(&page.Items[i]); err != nil {
+					return err
+				}
+			}
+			if page.NextCursor == "" {
+				return nil
+			}
+			opts.Cursor = page.NextCursor
+			var err error
+			if page, err = s.patients.List(ctx, opts); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetExport returns an export to the user or API key that requested it
+func (s *exportService) GetExport(ctx context.Context, caller *middleware.Identity, id int) (*Export, error) {
+	e, err := s.repo.GetExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	by := actorOf(caller)
+	switch {
+	case by.UserID != nil:
+		if e.RequestedBy == nil || *e.RequestedBy != *by.UserID {
+			return nil, ErrExportNotFound
+		}
+	case by.APIKeyID != nil:
+		if e.RequestedByAPIKey == nil || *e.RequestedByAPIKey != *by.APIKeyID {
+			return nil, ErrExportNotFound
+		}
+	default:
+		return nil, ErrExportNotFound
+	}
+	return e, nil
+}
+
+// DownloadExport returns a completed export and its file to the user or API
+// key that requested it
+func (s *exportService) DownloadExport(ctx context.Context, caller *middleware.Identity, id int) (*Export, []byte, error) {
+	e, err := s.GetExport(ctx, caller, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Status != ExportCompleted {
+		return nil, nil, ErrExportNotReady
+	}
+	content, err := s.repo.GetExportContent(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, content, nil
+}
+
+// ProcessPending produces the oldest pending export and reports whether
+// there was one. An export that cannot be produced is marked failed.
+func (s *exportService) ProcessPending(ctx context.Context) (bool, error) {
+	e, err := s.repo.ClaimExport(ctx, exportStaleAfter)
+	if err != nil || e == nil {
+		return false, err
+	}
+
+	opts := ListOptions(e.Filters)
+	opts.Deleted = false
+	opts.Cursor = ""
+	opts.Limit = exportPageSize
+	opts.PanelOf = e.PanelOf
+
+	var buf bytes.Buffer
+	n, err := s.produce(ctx, &buf, e, opts)
+	if err != nil {
+		return true, s.repo.FailExport(ctx, e.ID, err.Error(), s.ttl)
+	}
+	return true, s.repo.CompleteExport(ctx, e.ID, n, buf.Bytes(), s.ttl)
+}
+
+func (s *exportService) produce(ctx context.Context, w io.Writer, e *Export, opts ListOptions) (int, error) {
+	first, err := s.patients.List(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	return writeExport(w, e.Format, e.Clinical, s.source(ctx, opts, first))
+}
+
+// Run produces pending exports and deletes expired ones every interval until
+// ctx is cancelled
+func (s *exportService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Background patient exports are disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.repo.DeleteExpiredExports(ctx); err != nil {
+			log.Printf("WARN: Deleting expired patient exports failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Deleted %d expired patient exports", n)
+		}
+		for {
+			ok, err := s.ProcessPending(ctx)
+			if err != nil {
+				log.Printf("WARN: Patient export failed: %v", err)
+			}
+			if !ok || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
 // Handler holds dependencies for patient handlers
@@ -22,17 +26,32 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// DuplicateResponse is returned when a new patient resembles existing patients
+type DuplicateResponse struct {
+	Error      string         `json:"error"`
+	Duplicates []DuplicateHit `json:"duplicates"`
+}
+
+// RevisionResponse is returned when an If-Match precondition fails. Current
+// is the patient as it is now, in the view the caller is allowed to see; its
+// ETag header holds the current revision.
+type RevisionResponse struct {
+	Error   string      `json:"error"`
+	Current interface{} `json:"current"`
+}
+
 // CreatePatient godoc
 // @Summary      Create a patient
-// @Description  Adds a new patient to the system.
+// @Description  Adds a new patient to the system. If the patient resembles existing patients (similar name, same phone number, same date of birth) nothing is created and 409 lists the probable duplicates; resend with confirm_duplicate set to create the patient anyway.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        patient body CreatePatientRequest true "Patient data"
-// @Success      201 {object} Patient
-// @Failure      400 {object} ErrorResponse "Invalid request body"
+// @Success      201 {object} ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400 {object} ErrorResponse "Invalid request body or date of birth"
 // @Failure      403 {object} ErrorResponse "Forbidden"
+// @Failure      409 {object} DuplicateResponse "Probable duplicates, confirmation required"
 // @Failure      500 {object} ErrorResponse "Internal server error"
 // @Router       /patients [post]
 func (h *Handler) CreatePatient(c *gin.Context) {
@@ -42,25 +61,32 @@ func (h *Handler) CreatePatient(c *gin.Context) {
 		return
 	}
 
-	patient, err := h.service.CreatePatient(c.Request.Context(), req)
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.CreatePatient(c.Request.Context(), caller, req)
 	if err != nil {
+		var dup *DuplicateError
+		if errors.As(err, &dup) {
+			c.JSON(http.StatusConflict, DuplicateResponse{Error: err.Error(), Duplicates: ProjectDuplicates(caller, dup.Candidates)})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create patient: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, patient)
+	c.JSON(http.StatusCreated, Project(caller, patient))
 }
 
 // GetPatient godoc
 // @Summary      Get a patient by ID
-// @Description  Retrieves a single patient's details.
+// @Description  Retrieves a single patient's details. Diagnosis and notes are only included for callers with the patient:clinical:read permission (doctors); receptionists receive the demographic view.
 // @Tags         Patients
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id   path      int  true  "Patient ID"
-// @Success      200  {object}  Patient
+// @Success      200  {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Header       200  {string}  ETag "Revision of the patient, for If-Match"
 // @Failure      400  {object}  ErrorResponse "Invalid patient ID"
-// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      404  {object}  ErrorResponse "Patient not found"
 // @Router       /patients/{id} [get]
 func (h *Handler) GetPatient(c *gin.Context) {
@@ -70,51 +96,118 @@ func (h *Handler) GetPatient(c *gin.Context) {
 		return
 	}
 
-	patient, err := h.service.GetPatient(c.Request.Context(), id)
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.GetPatient(c.Request.Context(), caller, id)
 	if err != nil {
 		if errors.Is(err, ErrPatientNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, patient)
+	c.Header("ETag", ETag(patient.Revision))
+	c.JSON(http.StatusOK, Project(caller, patient))
 }
 
-// ListPatients godoc
-// @Summary      List all patients
-// @Description  Retrieves a list of all patients in the system.
+// GetPatientByMRN godoc
+// @Summary      Get a patient by MRN
+// @Description  Retrieves a patient by medical record number, ignoring case, with the same views as GET /patients/{id}.
 // @Tags         Patients
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Success      200  {array}   Patient
+// @Param        mrn  path      string  true  "Medical record number"
+// @Success      200  {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Header       200  {string}  ETag "Revision of the patient, for If-Match"
 // @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      404  {object}  ErrorResponse "Patient not found or not on the patient's care team"
+// @Router       /patients/by-mrn/{mrn} [get]
+func (h *Handler) GetPatientByMRN(c *gin.Context) {
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.GetPatientByMRN(c.Request.Context(), caller, c.Param("mrn"))
+	if err != nil {
+		if errors.Is(err, ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", ETag(patient.Revision))
+	c.JSON(http.StatusOK, Project(caller, patient))
+}
+
+// ListPatients godoc
+// @Summary      List patients
+// @Description  Retrieves a page of patients: every patient for callers with the patient:access:all permission, and the caller's own panel (patients on their care teams) for everyone else. Pass the returned next_cursor as cursor to fetch the following page; total counts every matching patient.
+// @Tags         Patients
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        age_min        query     int     false  "Minimum age in completed years"
+// @Param        age_max        query     int     false  "Maximum age in completed years"
+// @Param        created_from   query     string  false  "Created on or after (YYYY-MM-DD)"
+// @Param        created_to     query     string  false  "Created on or before (YYYY-MM-DD)"
+// @Param        updated_from   query     string  false  "Updated on or after (YYYY-MM-DD)"
+// @Param        updated_to     query     string  false  "Updated on or before (YYYY-MM-DD)"
+// @Param        has_diagnosis  query     bool    false  "Only patients with (true) or without (false) a diagnosis; requires patient:clinical:read"
+// @Param        doctor_id      query     int     false  "Only patients on this doctor's care team"
+// @Param        sort           query     string  false  "Sort key: name, date_of_birth, created_at or updated_at; prefix with - for descending" default(-created_at)
+// @Param        limit          query     int     false  "Page size (1-200)" default(50)
+// @Param        cursor         query     string  false  "next_cursor of the previous page"
+// @Success      200  {object}  pagination.Page[ClinicalRecord] "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400  {object}  ErrorResponse "Invalid filter, sort key or cursor"
+// @Failure      403  {object}  ErrorResponse "Forbidden or filtering by diagnosis without patient:clinical:read"
 // @Failure      500  {object}  ErrorResponse "Internal server error"
 // @Router       /patients [get]
 func (h *Handler) ListPatients(c *gin.Context) {
-	patients, err := h.service.ListAllPatients(c.Request.Context())
+	var opts ListOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	page, err := h.service.ListPatients(c.Request.Context(), caller, opts)
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, pagination.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrFilterForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, patients)
+	c.JSON(http.StatusOK, ProjectPage(caller, page))
 }
 
 // UpdatePatient godoc
 // @Summary      Update a patient
-// @Description  Updates a patient's non-medical information.
+// @Description  Updates a patient's non-medical information. Send the ETag of the patient as read in If-Match to have the update rejected if someone else changed the patient in the meantime.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id      path      int                  true  "Patient ID"
+// @Param        If-Match header   string               false "ETag of the patient as last read"
 // @Param        patient body      UpdatePatientRequest true  "Patient data"
-// @Success      200     {object}  Patient
-// @Failure      400     {object}  ErrorResponse "Invalid request body or ID"
-// @Failure      403     {object}  ErrorResponse "Forbidden"
+// @Success      200     {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400     {object}  ErrorResponse "Invalid request body, ID or date of birth"
+// @Failure      403     {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      404     {object}  ErrorResponse "Patient not found"
+// @Failure      412     {object}  RevisionResponse "Patient changed since it was read"
 // @Router       /patients/{id} [put]
 func (h *Handler) UpdatePatient(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -128,33 +221,122 @@ func (h *Handler) UpdatePatient(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
 		return
 	}
+	var ok bool
+	if req.IfRevision, ok = ParseIfMatch(c.GetHeader("If-Match")); !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": ErrRevisionMismatch.Error()})
+		return
+	}
 
-	patient, err := h.service.UpdatePatient(c.Request.Context(), id, req)
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.UpdatePatient(c.Request.Context(), caller, id, req)
 	if err != nil {
+		var rev *RevisionError
+		if errors.As(err, &rev) {
+			c.Header("ETag", ETag(rev.Current.Revision))
+			c.JSON(http.StatusPreconditionFailed, RevisionResponse{Error: err.Error(), Current: Project(caller, rev.Current)})
+			return
+		}
+		if errors.Is(err, ErrInvalidDateOfBirth) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, ErrPatientNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update patient: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, patient)
+	c.Header("ETag", ETag(patient.Revision))
+	c.JSON(http.StatusOK, Project(caller, patient))
+}
+
+// PatchPatient godoc
+// @Summary      Partially update a patient
+// @Description  Applies an RFC 7396 merge patch (application/merge-patch+json) or an RFC 6902 JSON Patch (application/json-patch+json) to a patient. Only the fields named by the patch change; removing phone_number or an optional detail clears it. Changing demographics needs patient:update and changing diagnosis or notes patient:medical:write. Honors If-Match like the update of a patient.
+// @Tags         Patients
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      int    true  "Patient ID"
+// @Param        If-Match header    string false "ETag of the patient as last read"
+// @Param        patch    body      object true  "Merge patch or JSON Patch"
+// @Success      200      {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400      {object}  ErrorResponse "Invalid patch, ID or resulting patient"
+// @Failure      403      {object}  ErrorResponse "Not allowed to change a patched field or not on the patient's care team"
+// @Failure      404      {object}  ErrorResponse "Patient not found"
+// @Failure      409      {object}  ErrorResponse "A test operation failed"
+// @Failure      412      {object}  RevisionResponse "Patient changed since it was read"
+// @Failure      415      {object}  ErrorResponse "Unsupported patch media type"
+// @Router       /patients/{id} [patch]
+func (h *Handler) PatchPatient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	req := PatchRequest{Type: c.ContentType()}
+	if req.Type != MergePatchType && req.Type != JSONPatchType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Patch must be " + MergePatchType + " or " + JSONPatchType})
+		return
+	}
+	if req.Body, err = c.GetRawData(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	var ok bool
+	if req.IfRevision, ok = ParseIfMatch(c.GetHeader("If-Match")); !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": ErrRevisionMismatch.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.PatchPatient(c.Request.Context(), caller, id, req)
+	if err != nil {
+		var rev *RevisionError
+		switch {
+		case errors.As(err, &rev):
+			c.Header("ETag", ETag(rev.Current.Revision))
+			c.JSON(http.StatusPreconditionFailed, RevisionResponse{Error: err.Error(), Current: Project(caller, rev.Current)})
+		case errors.Is(err, ErrInvalidPatch), errors.Is(err, ErrInvalidDateOfBirth):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrPatchTestFailed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrFieldForbidden), errors.Is(err, careteam.ErrAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrPatientNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch patient: " + err.Error()})
+		}
+		return
+	}
+
+	c.Header("ETag", ETag(patient.Revision))
+	c.JSON(http.StatusOK, Project(caller, patient))
 }
 
 // UpdatePatientMedical godoc
 // @Summary      Update patient's medical info (Doctor only)
-// @Description  Updates a patient's diagnosis and notes.
+// @Description  Updates a patient's diagnosis and notes. Honors If-Match like the update of a patient.
 // @Tags         Patients
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id      path      int                  true  "Patient ID"
+// @Param        If-Match header   string               false "ETag of the patient as last read"
 // @Param        patient body      UpdatePatientMedicalRequest true  "Patient medical data"
-// @Success      200     {object}  Patient
+// @Success      200     {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
 // @Failure      400     {object}  ErrorResponse "Invalid request body or ID"
-// @Failure      403     {object}  ErrorResponse "Forbidden"
+// @Failure      403     {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      404     {object}  ErrorResponse "Patient not found"
+// @Failure      412     {object}  RevisionResponse "Patient changed since it was read"
 // @Router       /patients/{id}/medical [patch]
 func (h *Handler) UpdatePatientMedical(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -168,29 +350,46 @@ func (h *Handler) UpdatePatientMedical(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
 		return
 	}
+	var ok bool
+	if req.IfRevision, ok = ParseIfMatch(c.GetHeader("If-Match")); !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": ErrRevisionMismatch.Error()})
+		return
+	}
 
-	patient, err := h.service.UpdatePatientMedical(c.Request.Context(), id, req)
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.UpdatePatientMedical(c.Request.Context(), caller, id, req)
 	if err != nil {
+		var rev *RevisionError
+		if errors.As(err, &rev) {
+			c.Header("ETag", ETag(rev.Current.Revision))
+			c.JSON(http.StatusPreconditionFailed, RevisionResponse{Error: err.Error(), Current: Project(caller, rev.Current)})
+			return
+		}
 		if errors.Is(err, ErrPatientNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update patient medical info: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, patient)
+	c.Header("ETag", ETag(patient.Revision))
+	c.JSON(http.StatusOK, Project(caller, patient))
 }
 
 // DeletePatient godoc
 // @Summary      Delete a patient
-// @Description  Deletes a patient from the system.
+// @Description  Moves a patient to the trash. The record, prescriptions and documents are hidden but kept, can be restored, and are permanently removed once the retention period has passed.
 // @Tags         Patients
 // @Security     ApiKeyAuth
 // @Param        id   path      int  true  "Patient ID"
 // @Success      204  {object}  nil
 // @Failure      400  {object}  ErrorResponse "Invalid patient ID"
-// @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      404  {object}  ErrorResponse "Patient not found"
 // @Router       /patients/{id} [delete]
 func (h *Handler) DeletePatient(c *gin.Context) {
@@ -200,12 +399,17 @@ func (h *Handler) DeletePatient(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeletePatient(c.Request.Context(), id)
+	caller, _ := middleware.GetIdentity(c)
+	err = h.service.DeletePatient(c.Request.Context(), caller, id)
 	if err != nil {
 		if errors.Is(err, ErrPatientNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete patient: " + err.Error()})
 		return
 	}
@@ -213,29 +417,112 @@ func (h *Handler) DeletePatient(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// RestorePatient godoc
+// @Summary      Restore a deleted patient
+// @Description  Takes a patient out of the trash, together with their prescriptions and documents.
+// @Tags         Patients
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Patient ID"
+// @Success      200  {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Patient not in the trash"
+// @Router       /patients/{id}/restore [post]
+func (h *Handler) RestorePatient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.RestorePatient(c.Request.Context(), caller, id)
+	if err != nil {
+		if errors.Is(err, ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore patient: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, Project(caller, patient))
+}
+
+// ListDeletedPatients godoc
+// @Summary      List deleted patients
+// @Description  Retrieves a page of the trash, most recently deleted first. Accepts the filters of GET /patients; deleted_at is an additional sort key.
+// @Tags         Patients
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        sort    query     string  false  "Sort key: deleted_at, name, date_of_birth, created_at or updated_at; prefix with - for descending" default(-deleted_at)
+// @Param        limit   query     int     false  "Page size (1-200)" default(50)
+// @Param        cursor  query     string  false  "next_cursor of the previous page"
+// @Success      200  {object}  pagination.Page[ClinicalRecord] "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400  {object}  ErrorResponse "Invalid filter, sort key or cursor"
+// @Failure      403  {object}  ErrorResponse "Forbidden or filtering by diagnosis without patient:clinical:read"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/trash [get]
+func (h *Handler) ListDeletedPatients(c *gin.Context) {
+	var opts ListOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	page, err := h.service.ListDeletedPatients(c.Request.Context(), caller, opts)
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, pagination.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrFilterForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ProjectPage(caller, page))
+}
+
 // SearchPatients godoc
 // @Summary      Search for patients
-// @Description  Searches for patients by name (case-insensitive).
+// @Description  Ranked fuzzy search over name, phone number, MRN and address. Names also match by trigram similarity and by sound, so "Jon Doe" finds "John Doe"; phone numbers match on their digits. Each result lists the fields that matched, how (exact, fuzzy or phonetic), and the field value with the matching parts wrapped in <mark> tags. Doctors only search their own panel.
 // @Tags         Patients
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        q   query      string  true  "Search query"
-// @Success      200  {array}   Patient
+// @Param        q      query     string  true   "Search query"
+// @Param        limit  query     int     false  "Maximum number of results (1-50)" default(20)
+// @Success      200  {array}   SearchHit "patient is the clinical or demographic view, as for GET /patients/{id}"
 // @Failure      400  {object}  ErrorResponse "Query parameter 'q' is required"
 // @Failure      403  {object}  ErrorResponse "Forbidden"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
 // @Router       /patients/search [get]
 func (h *Handler) SearchPatients(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
+	var opts SearchOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+	opts.Query = strings.TrimSpace(opts.Query)
+	if opts.Query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
 		return
 	}
 
-	patients, err := h.service.SearchPatients(c.Request.Context(), query)
+	caller, _ := middleware.GetIdentity(c)
+	results, err := h.service.SearchPatients(c.Request.Context(), caller, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search patients: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, patients)
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, ProjectSearch(caller, results))
+}
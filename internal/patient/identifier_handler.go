@@ -0,0 +1,163 @@
+package patient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// IdentifierHandler holds the dependencies for the external identifier handlers
+type IdentifierHandler struct {
+	service IdentifierService
+}
+
+// NewIdentifierHandler creates a new external identifier handler
+func NewIdentifierHandler(s IdentifierService) *IdentifierHandler {
+	return &IdentifierHandler{service: s}
+}
+
+// ListIdentifiers godoc
+// @Summary      List a patient's identifiers
+// @Description  Retrieves the patient's identifiers in other systems, such as national ID and insurance member ID.
+// @Tags         Patient Identifiers
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Patient ID"
+// @Success      200  {array}   Identifier
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Patient not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/identifiers [get]
+func (h *IdentifierHandler) ListIdentifiers(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	identifiers, err := h.service.ListIdentifiers(c.Request.Context(), caller, patientID)
+	if err != nil {
+		writeIdentifierError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, identifiers)
+}
+
+// AddIdentifier godoc
+// @Summary      Add an identifier
+// @Description  Adds an identifier in another system to the patient. A value identifies one patient per system, ignoring case.
+// @Tags         Patient Identifiers
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id          path      int                true  "Patient ID"
+// @Param        identifier  body      IdentifierRequest  true  "Identifier"
+// @Success      201         {object}  Identifier
+// @Failure      400         {object}  ErrorResponse "Invalid request body or identifier"
+// @Failure      403         {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404         {object}  ErrorResponse "Patient not found"
+// @Failure      409         {object}  ErrorResponse "Identifier already belongs to a patient"
+// @Failure      500         {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/identifiers [post]
+func (h *IdentifierHandler) AddIdentifier(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req IdentifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	identifier, err := h.service.AddIdentifier(c.Request.Context(), caller, patientID, req)
+	if err != nil {
+		writeIdentifierError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, identifier)
+}
+
+// DeleteIdentifier godoc
+// @Summary      Remove an identifier
+// @Description  Removes one of the patient's identifiers.
+// @Tags         Patient Identifiers
+// @Security     ApiKeyAuth
+// @Param        id             path  int  true  "Patient ID"
+// @Param        identifier_id  path  int  true  "Identifier ID"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse "Invalid ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Identifier not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/identifiers/{identifier_id} [delete]
+func (h *IdentifierHandler) DeleteIdentifier(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+	identifierID, err := strconv.Atoi(c.Param("identifier_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identifier ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	if err := h.service.DeleteIdentifier(c.Request.Context(), caller, patientID, identifierID); err != nil {
+		writeIdentifierError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// FindPatient godoc
+// @Summary      Get a patient by external identifier
+// @Description  Retrieves the patient a value identifies in another system, ignoring case, with the same views as GET /patients/{id}.
+// @Tags         Patient Identifiers
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        system  query     string  true  "Identifier system, e.g. national_id"
+// @Param        value   query     string  true  "Identifier value"
+// @Success      200     {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Header       200     {string}  ETag "Revision of the patient, for If-Match"
+// @Failure      400     {object}  ErrorResponse "Invalid identifier"
+// @Failure      403     {object}  ErrorResponse "Forbidden"
+// @Failure      404     {object}  ErrorResponse "Patient not found or not on the patient's care team"
+// @Failure      500     {object}  ErrorResponse "Internal server error"
+// @Router       /patients/by-identifier [get]
+func (h *IdentifierHandler) FindPatient(c *gin.Context) {
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.FindPatient(c.Request.Context(), caller, c.Query("system"), c.Query("value"))
+	if err != nil {
+		writeIdentifierError(c, err)
+		return
+	}
+
+	c.Header("ETag", ETag(patient.Revision))
+	c.JSON(http.StatusOK, Project(caller, patient))
+}
+
+func writeIdentifierError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidIdentifier):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, careteam.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound), errors.Is(err, ErrIdentifierNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrIdentifierTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,92 @@
+package patient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// IdentifierRepository defines the interface for external identifier storage
+type IdentifierRepository interface {
+	ListIdentifiers(ctx context.Context, patientID int) ([]Identifier, error)
+	CreateIdentifier(ctx context.Context, i *Identifier) error
+	DeleteIdentifier(ctx context.Context, patientID, id int) error
+	FindPatientID(ctx context.Context, system, value string) (int, error)
+}
+
+type postgresIdentifierRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresIdentifierRepository creates a new repository for external identifiers
+func NewPostgresIdentifierRepository(db *sqlx.DB) IdentifierRepository {
+	return &postgresIdentifierRepository{db: db}
+}
+
+const identifierColumns = `id, patient_id, system, value, created_by, created_at`
+
+// ListIdentifiers retrieves a patient's identifiers by system. It returns
+// ErrPatientNotFound for patients that do not exist or are deleted.
+func (r *postgresIdentifierRepository) ListIdentifiers(ctx context.Context, patientID int) ([]Identifier, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM patients WHERE id = $1 AND deleted_at IS NULL)`, patientID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPatientNotFound
+	}
+
+	identifiers := []Identifier{}
+	query := `SELECT ` + identifierColumns + ` FROM patient_identifiers WHERE patient_id = $1 ORDER BY system, id`
+	err := r.db.SelectContext(ctx, &identifiers, query, patientID)
+	return identifiers, err
+}
+
+// CreateIdentifier adds an identifier to an active patient. It returns
+// ErrIdentifierTaken if the value already identifies a patient in the system.
+func (r *postgresIdentifierRepository) CreateIdentifier(ctx context.Context, i *Identifier) error {
+	query := `INSERT INTO patient_identifiers (patient_id, system, value, created_by, created_at)
+		SELECT id, $2, $3, $4, NOW() FROM patients WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + identifierColumns
+	err := r.db.GetContext(ctx, i, query, i.PatientID, i.System, i.Value, i.CreatedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPatientNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrIdentifierTaken
+	}
+	return err
+}
+
+// DeleteIdentifier removes an identifier of a patient
+func (r *postgresIdentifierRepository) DeleteIdentifier(ctx context.Context, patientID, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM patient_identifiers WHERE id = $1 AND patient_id = $2`, id, patientID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrIdentifierNotFound
+	}
+	return nil
+}
+
+// FindPatientID returns the active patient a value identifies in a system,
+// ignoring case
+func (r *postgresIdentifierRepository) FindPatientID(ctx context.Context, system, value string) (int, error) {
+	var id int
+	query := `SELECT i.patient_id FROM patient_identifiers i JOIN patients p ON p.id = i.patient_id
+		WHERE i.system = $1 AND UPPER(i.value) = UPPER($2) AND p.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &id, query, system, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrPatientNotFound
+	}
+	return id, err
+}
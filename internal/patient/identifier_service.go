@@ -0,0 +1,104 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// Well-known identifier systems
+const (
+	IdentifierNationalID      = "national_id"
+	IdentifierInsuranceMember = "insurance_member_id"
+)
+
+var (
+	ErrIdentifierNotFound = errors.New("identifier not found")
+	ErrIdentifierTaken    = errors.New("identifier already belongs to a patient in this system")
+	ErrInvalidIdentifier  = errors.New("identifier system must be a lowercase name like national_id and the value must not be blank")
+)
+
+var identifierSystem = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// IdentifierService manages the external identifiers of patients the caller
+// may access and finds patients by them
+type IdentifierService interface {
+	ListIdentifiers(ctx context.Context, caller *middleware.Identity, patientID int) ([]Identifier, error)
+	AddIdentifier(ctx context.Context, caller *middleware.Identity, patientID int, req IdentifierRequest) (*Identifier, error)
+	DeleteIdentifier(ctx context.Context, caller *middleware.Identity, patientID, id int) error
+	FindPatient(ctx context.Context, caller *middleware.Identity, system, value string) (*Patient, error)
+}
+
+type identifierService struct {
+	repo     IdentifierRepository
+	patients Repository
+	access   careteam.AccessChecker
+}
+
+// NewIdentifierService creates a new external identifier service
+func NewIdentifierService(r IdentifierRepository, patients Repository, access careteam.AccessChecker) IdentifierService {
+	return &identifierService{repo: r, patients: patients, access: access}
+}
+
+func (s *identifierService) ListIdentifiers(ctx context.Context, caller *middleware.Identity, patientID int) ([]Identifier, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListIdentifiers(ctx, patientID)
+}
+
+func (s *identifierService) AddIdentifier(ctx context.Context, caller *middleware.Identity, patientID int, req IdentifierRequest) (*Identifier, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	system, value, err := normalizeIdentifier(req.System, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	i := &Identifier{PatientID: patientID, System: system, Value: value, CreatedBy: actorID(caller)}
+	if err := s.repo.CreateIdentifier(ctx, i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (s *identifierService) DeleteIdentifier(ctx context.Context, caller *middleware.Identity, patientID, id int) error {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return err
+	}
+	return s.repo.DeleteIdentifier(ctx, patientID, id)
+}
+
+// FindPatient returns the patient a value identifies in a system, if the
+// caller may access them
+func (s *identifierService) FindPatient(ctx context.Context, caller *middleware.Identity, system, value string) (*Patient, error) {
+	system, value, err := normalizeIdentifier(system, value)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.repo.FindPatientID(ctx, system, value)
+	if err != nil {
+		return nil, err
+	}
+	// Callers who may not see the patient cannot tell that the value is taken
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			return nil, ErrPatientNotFound
+		}
+		return nil, err
+	}
+	return s.patients.GetByID(ctx, id)
+}
+
+// normalizeIdentifier checks an identifier and trims its value
+func normalizeIdentifier(system, value string) (string, string, error) {
+	system, value = strings.TrimSpace(system), strings.TrimSpace(value)
+	if !identifierSystem.MatchString(system) || value == "" {
+		return "", "", ErrInvalidIdentifier
+	}
+	return system, value, nil
+}
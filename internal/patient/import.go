@@ -0,0 +1,332 @@
+package patient
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+)
+
+// Import modes: one transaction for every row, or resumable batches that are
+// committed one by one
+const (
+	ImportTransaction = "transaction"
+	ImportBatches     = "batches"
+)
+
+// Import statuses
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// What to do with rows that probably are patients who already exist
+const (
+	DuplicatesReject = "reject"
+	DuplicatesSkip   = "skip"
+	DuplicatesCreate = "create"
+)
+
+const (
+	DefaultImportBatchSize = 500
+	MaxImportRows          = 10000
+	MaxImportFileSize      = 20 << 20
+)
+
+// ImportOptions are the form fields sent with an import file. Mapping is a
+// JSON object from patient fields to column headers, for example
+// {"name": "Full name", "date_of_birth": "DOB"}; without it columns are
+// matched to fields by header. DateFormat is the Go layout of dates that are
+// not written as YYYY-MM-DD, such as "02/01/2006".
+type ImportOptions struct {
+	Mapping    string `form:"mapping"`
+	DryRun     bool   `form:"dry_run"`
+	Mode       string `form:"mode" binding:"omitempty,oneof=transaction batches"`
+	BatchSize  int    `form:"batch_size" binding:"omitempty,min=1,max=5000"`
+	Duplicates string `form:"duplicates" binding:"omitempty,oneof=reject skip create"`
+	DateFormat string `form:"date_format"`
+}
+
+// RowIssue is a problem with one row of an import file. Row is the line of
+// the file, counting the header as line 1. DuplicateOf lists the existing
+// patients a row probably duplicates.
+type RowIssue struct {
+	Row         int    `json:"row"`
+	Field       string `json:"field,omitempty"`
+	Error       string `json:"error"`
+	DuplicateOf []int  `json:"duplicate_of,omitempty"`
+}
+
+// ImportReport is the result of checking an import file. Import is the
+// started import, unless it was a dry run.
+type ImportReport struct {
+	DryRun     bool       `json:"dry_run"`
+	TotalRows  int        `json:"total_rows"`
+	ValidRows  int        `json:"valid_rows"`
+	Duplicates int        `json:"duplicates"`
+	Issues     []RowIssue `json:"issues"`
+	Import     *Import    `json:"import,omitempty"`
+}
+
+// Import is a bulk import of patients. NextRow counts the rows committed so
+// far; an import that is not completed can be resumed from there.
+type Import struct {
+	ID        int        `json:"id" db:"id"`
+	Filename  string     `json:"filename" db:"filename"`
+	Mode      string     `json:"mode" db:"mode"`
+	Status    string     `json:"status" db:"status"`
+	BatchSize int        `json:"batch_size" db:"batch_size"`
+	TotalRows int        `json:"total_rows" db:"total_rows"`
+	NextRow   int        `json:"imported_rows" db:"next_row"`
+	Rows      ImportRows `json:"-" db:"rows"`
+	Error     *string    `json:"error,omitempty" db:"error"`
+	CreatedBy *int       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ImportRow is a validated row of an import file, waiting to be committed
+type ImportRow struct {
+	Row     int                  `json:"row"`
+	Patient CreatePatientRequest `json:"patient"`
+}
+
+// ImportRows are stored as a JSON array
+type ImportRows []ImportRow
+
+func (r ImportRows) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *ImportRows) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported type %T for import rows", src)
+	}
+	return json.Unmarshal(b, r)
+}
+
+var (
+	ErrInvalidImportFile = errors.New("invalid import file")
+	ErrInvalidMapping    = errors.New("invalid column mapping")
+)
+
+// importFields sets the field of a patient that a column maps to
+var importFields = map[string]func(req *CreatePatientRequest, value string) error{
+	"name":               func(req *CreatePatientRequest, v string) error { req.Name = v; return nil },
+	"phone_number":       func(req *CreatePatientRequest, v string) error { req.PhoneNumber = optional(v); return nil },
+	"date_of_birth":      func(req *CreatePatientRequest, v string) error { req.DateOfBirth = v; return nil },
+	"dob_estimated":      setDOBEstimated,
+	"address":            func(req *CreatePatientRequest, v string) error { req.Address = v; return nil },
+	"sex":                func(req *CreatePatientRequest, v string) error { req.Sex = optional(strings.ToLower(v)); return nil },
+	"gender":             func(req *CreatePatientRequest, v string) error { req.Gender = optional(v); return nil },
+	"email":              func(req *CreatePatientRequest, v string) error { req.Email = optional(v); return nil },
+	"preferred_language": func(req *CreatePatientRequest, v string) error { req.PreferredLanguage = optional(v); return nil },
+	"city":               func(req *CreatePatientRequest, v string) error { req.City = optional(v); return nil },
+	"region":             func(req *CreatePatientRequest, v string) error { req.Region = optional(v); return nil },
+	"postcode":           func(req *CreatePatientRequest, v string) error { req.Postcode = optional(v); return nil },
+	"country":            func(req *CreatePatientRequest, v string) error { req.Country = optional(strings.ToUpper(v)); return nil },
+	"marital_status":     func(req *CreatePatientRequest, v string) error { req.MaritalStatus = optional(strings.ToLower(v)); return nil },
+	"occupation":         func(req *CreatePatientRequest, v string) error { req.Occupation = optional(v); return nil },
+}
+
+// requiredImportFields must be mapped to a column
+var requiredImportFields = []string{"name", "date_of_birth", "address"}
+
+func optional(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func setDOBEstimated(req *CreatePatientRequest, v string) error {
+	switch strings.ToLower(v) {
+	case "", "0", "n", "no", "false":
+		req.DOBEstimated = false
+	case "1", "y", "yes", "true":
+		req.DOBEstimated = true
+	default:
+		return fmt.Errorf("expected yes or no, got %q", v)
+	}
+	return nil
+}
+
+// readTable reads the rows of a CSV file or of the first sheet of an XLSX
+// file, header first. Dates in XLSX files are returned as serial numbers.
+func readTable(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("%w: no sheets", ErrInvalidImportFile)
+		}
+		rows, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("%w: expected a .csv or .xlsx file", ErrInvalidImportFile)
+}
+
+// columnMapping returns the column of every mapped field. Without a mapping
+// spec a column maps to the field its header names, ignoring case, spaces
+// and dashes.
+func columnMapping(header []string, spec string) (map[string]int, error) {
+	columns := make(map[string]int)
+	if spec == "" {
+		for i, h := range header {
+			field := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(h)))
+			if _, ok := importFields[field]; ok {
+				columns[field] = i
+			}
+		}
+	} else {
+		var mapping map[string]string
+		if err := json.Unmarshal([]byte(spec), &mapping); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+		}
+		for field, column := range mapping {
+			if _, ok := importFields[field]; !ok {
+				return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidMapping, field)
+			}
+			i := indexOf(header, column)
+			if i < 0 {
+				return nil, fmt.Errorf("%w: no column %q for %s", ErrInvalidMapping, column, field)
+			}
+			columns[field] = i
+		}
+	}
+	for _, field := range requiredImportFields {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("%w: no column for %s", ErrInvalidMapping, field)
+		}
+	}
+	return columns, nil
+}
+
+func indexOf(header []string, column string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(column)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseRow builds the request to create the patient of one row and checks it
+// with the rules of CreatePatientRequest. serialDates reads numeric dates as
+// spreadsheet serial numbers.
+func parseRow(line int, cells []string, columns map[string]int, dateFormat string, serialDates bool, now time.Time) (CreatePatientRequest, []RowIssue) {
+	var req CreatePatientRequest
+	var issues []RowIssue
+	for field, i := range columns {
+		value := ""
+		if i < len(cells) {
+			value = strings.TrimSpace(cells[i])
+		}
+		if field == "date_of_birth" && value != "" {
+			var err error
+			if value, err = importDate(value, dateFormat, serialDates); err != nil {
+				issues = append(issues, RowIssue{Row: line, Field: field, Error: err.Error()})
+				continue
+			}
+		}
+		if err := importFields[field](&req, value); err != nil {
+			issues = append(issues, RowIssue{Row: line, Field: field, Error: err.Error()})
+		}
+	}
+	if len(issues) > 0 {
+		return req, issues
+	}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return req, []RowIssue{{Row: line, Error: err.Error()}}
+		}
+		for _, fe := range fieldErrs {
+			rule := fe.Tag()
+			if fe.Param() != "" {
+				rule += "=" + fe.Param()
+			}
+			issues = append(issues, RowIssue{Row: line, Field: requestFieldNames[fe.StructField()], Error: fmt.Sprintf("value %q breaks the %s rule", fmt.Sprint(fe.Value()), rule)})
+		}
+		return req, issues
+	}
+	if _, err := ParseDateOfBirth(req.DateOfBirth, now); err != nil {
+		return req, []RowIssue{{Row: line, Field: "date_of_birth", Error: err.Error()}}
+	}
+	req.Details = normalizeDetails(req.Details)
+	return req, nil
+}
+
+// importDate rewrites a date of birth as YYYY-MM-DD
+func importDate(value, layout string, serial bool) (string, error) {
+	if serial {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			t, err := excelize.ExcelDateToTime(n, false)
+			if err != nil {
+				return "", err
+			}
+			return t.Format(DateLayout), nil
+		}
+	}
+	if layout == "" {
+		return value, nil
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return "", fmt.Errorf("expected a date like %s, got %q", layout, value)
+	}
+	return t.Format(DateLayout), nil
+}
+
+// requestFieldNames maps the fields of CreatePatientRequest to their JSON names
+var requestFieldNames = func() map[string]string {
+	names := make(map[string]string)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Anonymous {
+				walk(f.Type)
+				continue
+			}
+			names[f.Name] = strings.Split(f.Tag.Get("json"), ",")[0]
+		}
+	}
+	walk(reflect.TypeOf(CreatePatientRequest{}))
+	return names
+}()
@@ -0,0 +1,169 @@
+package patient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// ImportHandler holds the dependencies for the patient import handlers
+type ImportHandler struct {
+	service ImportService
+}
+
+// NewImportHandler creates a new patient import handler
+func NewImportHandler(s ImportService) *ImportHandler {
+	return &ImportHandler{service: s}
+}
+
+// ImportRejectedResponse is returned when an import file has rows that stop
+// the import
+type ImportRejectedResponse struct {
+	Error  string        `json:"error"`
+	Report *ImportReport `json:"report"`
+}
+
+// ImportPatients godoc
+// @Summary      Import patients from a CSV or XLSX file
+// @Description  Checks every row of the file like a new patient and against existing patients. With dry_run only the report is returned. Otherwise the rows are committed in one transaction, or in batches that can be resumed if the import fails, as long as no row is invalid and, with duplicates=reject, none probably duplicates a patient.
+// @Tags         Patient Imports
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        file         formData  file    true   "CSV or XLSX file, header first"
+// @Param        mapping      formData  string  false  "JSON object from patient fields to column headers"
+// @Param        dry_run      formData  bool    false  "Only check the file"
+// @Param        mode         formData  string  false  "transaction (default) or batches"
+// @Param        batch_size   formData  int     false  "Rows per batch, 500 by default"
+// @Param        duplicates   formData  string  false  "reject (default), skip or create probable duplicates"
+// @Param        date_format  formData  string  false  "Go layout of dates not written as YYYY-MM-DD"
+// @Success      200          {object}  ImportReport "Dry run"
+// @Success      201          {object}  ImportReport "Import started; see import.status"
+// @Failure      400          {object}  ErrorResponse "Invalid file, options or column mapping"
+// @Failure      422          {object}  ImportRejectedResponse "Invalid or duplicate rows"
+// @Failure      500          {object}  ErrorResponse "Internal server error"
+// @Router       /patients/import [post]
+func (h *ImportHandler) ImportPatients(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportFileSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File 'file' is required in form-data"})
+		return
+	}
+	var opts ImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import options: " + err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	caller, _ := middleware.GetIdentity(c)
+	report, err := h.service.ImportPatients(c.Request.Context(), caller, fileHeader.Filename, file, opts)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	if report.DryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListImports godoc
+// @Summary      List patient imports
+// @Description  Retrieves every patient import, newest first.
+// @Tags         Patient Imports
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   Import
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patient-imports [get]
+func (h *ImportHandler) ListImports(c *gin.Context) {
+	caller, _ := middleware.GetIdentity(c)
+	imports, err := h.service.ListImports(c.Request.Context(), caller)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, imports)
+}
+
+// GetImport godoc
+// @Summary      Get a patient import
+// @Description  Retrieves the progress of a patient import.
+// @Tags         Patient Imports
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Import ID"
+// @Success      200  {object}  Import
+// @Failure      400  {object}  ErrorResponse "Invalid import ID"
+// @Failure      404  {object}  ErrorResponse "Import not found"
+// @Router       /patient-imports/{id} [get]
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	imp, err := h.service.GetImport(c.Request.Context(), caller, id)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, imp)
+}
+
+// ResumeImport godoc
+// @Summary      Resume a patient import
+// @Description  Commits the rows of a failed or interrupted import that were not committed yet.
+// @Tags         Patient Imports
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Import ID"
+// @Success      200  {object}  Import
+// @Failure      400  {object}  ErrorResponse "Invalid import ID"
+// @Failure      404  {object}  ErrorResponse "Import not found"
+// @Failure      409  {object}  ErrorResponse "Import already completed"
+// @Router       /patient-imports/{id}/resume [post]
+func (h *ImportHandler) ResumeImport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	imp, err := h.service.ResumeImport(c.Request.Context(), caller, id)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, imp)
+}
+
+func writeImportError(c *gin.Context, err error) {
+	var rejected *ImportError
+	switch {
+	case errors.As(err, &rejected):
+		c.JSON(http.StatusUnprocessableEntity, ImportRejectedResponse{Error: err.Error(), Report: rejected.Report})
+	case errors.Is(err, ErrInvalidImportFile), errors.Is(err, ErrInvalidMapping):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrImportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrImportCompleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,110 @@
+package patient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportRepository defines the interface for bulk imports of patients
+type ImportRepository interface {
+	CreateImport(ctx context.Context, imp *Import) error
+	GetImport(ctx context.Context, id int) (*Import, error)
+	ListImports(ctx context.Context) ([]Import, error)
+	CommitBatch(ctx context.Context, id, from int, patients []*Patient) (*Import, error)
+	FailImport(ctx context.Context, id int, reason string) error
+}
+
+type postgresImportRepository struct {
+	db  *sqlx.DB
+	mrn *MRNFormat
+}
+
+// NewPostgresImportRepository creates a new repository for patient imports.
+// Imported patients are given MRNs in the mrn format, like new patients.
+func NewPostgresImportRepository(db *sqlx.DB, mrn *MRNFormat) ImportRepository {
+	return &postgresImportRepository{db: db, mrn: mrn}
+}
+
+const importColumns = `id, filename, mode, status, batch_size, total_rows, next_row, rows, error, created_by, created_at, updated_at`
+
+// importSummaryColumns leave out the rows waiting to be committed
+const importSummaryColumns = `id, filename, mode, status, batch_size, total_rows, next_row, '[]'::jsonb AS rows, error, created_by, created_at, updated_at`
+
+func (r *postgresImportRepository) CreateImport(ctx context.Context, imp *Import) error {
+	query := `INSERT INTO patient_imports (filename, mode, status, batch_size, total_rows, rows, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`
+	return r.db.QueryRowxContext(ctx, query, imp.Filename, imp.Mode, imp.Status, imp.BatchSize, imp.TotalRows, imp.Rows, imp.CreatedBy).
+		Scan(&imp.ID, &imp.CreatedAt, &imp.UpdatedAt)
+}
+
+func (r *postgresImportRepository) GetImport(ctx context.Context, id int) (*Import, error) {
+	var imp Import
+	err := r.db.GetContext(ctx, &imp, `SELECT `+importColumns+` FROM patient_imports WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// ListImports returns the imports newest first, without their rows
+func (r *postgresImportRepository) ListImports(ctx context.Context) ([]Import, error) {
+	imports := []Import{}
+	if err := r.db.SelectContext(ctx, &imports, `SELECT `+importSummaryColumns+` FROM patient_imports ORDER BY id DESC`); err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// CommitBatch inserts the patients of the rows starting at from and advances
+// the import past them in one transaction. A batch another run has already
+// committed is not inserted again. The import is returned without its rows.
+func (r *postgresImportRepository) CommitBatch(ctx context.Context, id, from int, patients []*Patient) (*Import, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var next int
+	err = tx.GetContext(ctx, &next, `SELECT next_row FROM patient_imports WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if next == from {
+		for _, p := range patients {
+			if err := insertPatient(ctx, tx, p, r.mrn); err != nil {
+				return nil, err
+			}
+		}
+		query := `UPDATE patient_imports SET next_row = next_row + $2,
+				status = CASE WHEN next_row + $2 >= total_rows THEN 'completed' ELSE 'running' END,
+				rows = CASE WHEN next_row + $2 >= total_rows THEN '[]'::jsonb ELSE rows END,
+				error = NULL, updated_at = NOW()
+			WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, query, id, len(patients)); err != nil {
+			return nil, err
+		}
+	}
+
+	var imp Import
+	if err := tx.GetContext(ctx, &imp, `SELECT `+importSummaryColumns+` FROM patient_imports WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &imp, tx.Commit()
+}
+
+// FailImport records why an import stopped. It can be resumed from the first
+// row that was not committed.
+func (r *postgresImportRepository) FailImport(ctx context.Context, id int, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE patient_imports SET status = 'failed', error = $2, updated_at = NOW() WHERE id = $1`, id, reason)
+	return err
+}
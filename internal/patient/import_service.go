@@ -0,0 +1,248 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+var (
+	ErrImportNotFound  = errors.New("import not found")
+	ErrImportRejected  = errors.New("import file has invalid or duplicate rows")
+	ErrImportCompleted = errors.New("import is already completed")
+)
+
+// ImportError is returned instead of ErrImportRejected with the report of
+// the rows that stopped the import
+type ImportError struct {
+	Report *ImportReport
+}
+
+func (e *ImportError) Error() string { return ErrImportRejected.Error() }
+
+func (e *ImportError) Is(target error) bool { return target == ErrImportRejected }
+
+// ImportService imports patients in bulk from CSV and XLSX files
+type ImportService interface {
+	ImportPatients(ctx context.Context, caller *middleware.Identity, filename string, file io.Reader, opts ImportOptions) (*ImportReport, error)
+	ListImports(ctx context.Context, caller *middleware.Identity) ([]Import, error)
+	GetImport(ctx context.Context, caller *middleware.Identity, id int) (*Import, error)
+	ResumeImport(ctx context.Context, caller *middleware.Identity, id int) (*Import, error)
+}
+
+type importService struct {
+	repo     ImportRepository
+	patients Repository
+}
+
+// NewImportService creates a new patient import service
+func NewImportService(r ImportRepository, patients Repository) ImportService {
+	return &importService{repo: r, patients: patients}
+}
+
+// ImportPatients checks every row of an import file like a new patient and
+// against existing patients and the rows before it. A dry run only reports
+// the problems. Otherwise nothing is imported while any row is invalid or,
+// unless duplicates are skipped or created, probably duplicates a patient;
+// then the rows are committed in one transaction or in batches.
+func (s *importService) ImportPatients(ctx context.Context, caller *middleware.Identity, filename string, file io.Reader, opts ImportOptions) (*ImportReport, error) {
+	table, err := readTable(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(table) < 2 {
+		return nil, fmt.Errorf("%w: no rows below the header", ErrInvalidImportFile)
+	}
+	if len(table)-1 > MaxImportRows {
+		return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImportFile, MaxImportRows)
+	}
+	columns, err := columnMapping(table[0], opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Duplicates == "" {
+		opts.Duplicates = DuplicatesReject
+	}
+
+	now := time.Now()
+	serialDates := strings.EqualFold(filepath.Ext(filename), ".xlsx")
+	report := &ImportReport{DryRun: opts.DryRun, Issues: []RowIssue{}}
+	rows := ImportRows{}
+	seen := make(map[string]int)
+	for i, cells := range table[1:] {
+		line := i + 2
+		if isBlankRow(cells) {
+			continue
+		}
+		report.TotalRows++
+		req, issues := parseRow(line, cells, columns, opts.DateFormat, serialDates, now)
+		if len(issues) > 0 {
+			report.Issues = append(report.Issues, issues...)
+			continue
+		}
+		report.ValidRows++
+
+		issue, err := s.duplicateIssue(ctx, caller, line, req, seen, now)
+		if err != nil {
+			return nil, err
+		}
+		if issue != nil {
+			report.Duplicates++
+			report.Issues = append(report.Issues, *issue)
+			if opts.Duplicates == DuplicatesSkip {
+				continue
+			}
+		}
+		rows = append(rows, ImportRow{Row: line, Patient: req})
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if report.ValidRows < report.TotalRows || (report.Duplicates > 0 && opts.Duplicates == DuplicatesReject) {
+		return nil, &ImportError{Report: report}
+	}
+
+	imp := &Import{
+		Filename:  truncate(filepath.Base(filename), 255),
+		Mode:      opts.Mode,
+		Status:    ImportPending,
+		BatchSize: opts.BatchSize,
+		TotalRows: len(rows),
+		Rows:      rows,
+		CreatedBy: actorID(caller),
+	}
+	if imp.Mode == "" {
+		imp.Mode = ImportTransaction
+	}
+	switch {
+	case imp.Mode == ImportTransaction:
+		imp.BatchSize = max(len(rows), 1)
+	case imp.BatchSize == 0:
+		imp.BatchSize = DefaultImportBatchSize
+	}
+	if err := s.repo.CreateImport(ctx, imp); err != nil {
+		return nil, err
+	}
+	if report.Import, err = s.run(ctx, imp, actorOf(caller)); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// duplicateIssue reports a row that probably is an existing patient or the
+// same patient as an earlier row
+func (s *importService) duplicateIssue(ctx context.Context, caller *middleware.Identity, line int, req CreatePatientRequest, seen map[string]int, now time.Time) (*RowIssue, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(req.Name), " ")) + "|" + req.DateOfBirth
+	if first, ok := seen[key]; ok {
+		return &RowIssue{Row: line, Error: fmt.Sprintf("same patient as row %d", first)}, nil
+	}
+	seen[key] = line
+
+	p, err := importPatient(req, Actor{}, now)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := findDuplicates(ctx, s.patients, caller, p)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+	issue := &RowIssue{Row: line, Error: ErrProbableDuplicate.Error()}
+	for _, c := range candidates {
+		issue.DuplicateOf = append(issue.DuplicateOf, c.ID)
+	}
+	return issue, nil
+}
+
+func (s *importService) ListImports(ctx context.Context, caller *middleware.Identity) ([]Import, error) {
+	return s.repo.ListImports(ctx)
+}
+
+func (s *importService) GetImport(ctx context.Context, caller *middleware.Identity, id int) (*Import, error) {
+	return s.repo.GetImport(ctx, id)
+}
+
+// ResumeImport commits the rows of a failed or interrupted import that were
+// not committed yet
+func (s *importService) ResumeImport(ctx context.Context, caller *middleware.Identity, id int) (*Import, error) {
+	imp, err := s.repo.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status == ImportCompleted {
+		return nil, ErrImportCompleted
+	}
+	return s.run(ctx, imp, actorOf(caller))
+}
+
+// run commits the remaining rows of an import batch by batch. A failed batch
+// is rolled back and recorded on the import, which is returned as failed.
+// The import carries on if the client goes away, so that it stops at a batch
+// boundary.
+func (s *importService) run(ctx context.Context, imp *Import, by Actor) (*Import, error) {
+	ctx = context.WithoutCancel(ctx)
+	rows := imp.Rows
+	now := time.Now()
+	for imp.Status != ImportCompleted {
+		from := imp.NextRow
+		end := min(from+imp.BatchSize, len(rows))
+		patients := make([]*Patient, 0, end-from)
+		for _, row := range rows[from:end] {
+			p, err := importPatient(row.Patient, by, now)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			patients = append(patients, p)
+		}
+
+		next, err := s.repo.CommitBatch(ctx, imp.ID, from, patients)
+		if err != nil {
+			if failErr := s.repo.FailImport(ctx, imp.ID, err.Error()); failErr != nil {
+				return nil, failErr
+			}
+			return s.repo.GetImport(ctx, imp.ID)
+		}
+		imp = next
+	}
+	return imp, nil
+}
+
+// importPatient returns the patient to create for a checked row
+func importPatient(req CreatePatientRequest, createdBy Actor, now time.Time) (*Patient, error) {
+	dob, err := ParseDateOfBirth(req.DateOfBirth, now)
+	if err != nil {
+		return nil, err
+	}
+	return &Patient{
+		Name:            req.Name,
+		DateOfBirth:     dob,
+		DOBEstimated:    req.DOBEstimated,
+		Address:         req.Address,
+		PhoneNumber:     req.PhoneNumber,
+		Details:         req.Details,
+		UpdatedBy:       createdBy.UserID,
+		UpdatedByAPIKey: createdBy.APIKeyID,
+	}, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+package patient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// MergeHandler holds the dependencies for the patient merge handlers
+type MergeHandler struct {
+	service MergeService
+}
+
+// NewMergeHandler creates a new patient merge handler
+func NewMergeHandler(s MergeService) *MergeHandler {
+	return &MergeHandler{service: s}
+}
+
+// MergePatients godoc
+// @Summary      Merge a duplicate patient
+// @Description  Moves the prescriptions, documents, care team, identifiers, emergency contacts and portal account of the duplicate to this patient and moves the duplicate to the trash. The merge is recorded and can be undone.
+// @Tags         Patient Merges
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      int           true  "Surviving patient ID"
+// @Param        body  body      MergeRequest  true  "Duplicate to merge"
+// @Success      201   {object}  Merge
+// @Failure      400   {object}  ErrorResponse "Invalid request body or merge with itself"
+// @Failure      403   {object}  ErrorResponse "Forbidden or not on both patients' care teams"
+// @Failure      404   {object}  ErrorResponse "Patient not found"
+// @Failure      409   {object}  ErrorResponse "Both patients have a portal account"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/merge [post]
+func (h *MergeHandler) MergePatients(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	merge, err := h.service.MergePatients(c.Request.Context(), caller, id, req)
+	if err != nil {
+		writeMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, merge)
+}
+
+// ListMerges godoc
+// @Summary      List a patient's merges
+// @Description  Retrieves the merges the patient took part in as survivor or as duplicate, newest first.
+// @Tags         Patient Merges
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        patient_id  query     int  true  "Patient ID"
+// @Success      200         {array}   Merge
+// @Failure      400         {object}  ErrorResponse "Invalid patient ID"
+// @Failure      403         {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      500         {object}  ErrorResponse "Internal server error"
+// @Router       /patient-merges [get]
+func (h *MergeHandler) ListMerges(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Query("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	merges, err := h.service.ListMerges(c.Request.Context(), caller, patientID)
+	if err != nil {
+		writeMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, merges)
+}
+
+// UndoMerge godoc
+// @Summary      Undo a patient merge
+// @Description  Moves the prescriptions, documents, care team members, identifiers, emergency contacts and portal account the merge moved back to the duplicate and restores it from the trash.
+// @Tags         Patient Merges
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Merge ID"
+// @Success      200  {object}  Merge
+// @Failure      400  {object}  ErrorResponse "Invalid merge ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Merge not found"
+// @Failure      409  {object}  ErrorResponse "Merge already undone or duplicate no longer in the trash"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patient-merges/{id}/undo [post]
+func (h *MergeHandler) UndoMerge(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merge ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	merge, err := h.service.UndoMerge(c.Request.Context(), caller, id)
+	if err != nil {
+		writeMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, merge)
+}
+
+func writeMergeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrMergeSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, careteam.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound), errors.Is(err, ErrMergeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrMergeConflict), errors.Is(err, ErrMergeAlreadyUndone), errors.Is(err, ErrMergeNotUndoable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
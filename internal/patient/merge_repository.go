@@ -0,0 +1,197 @@
+package patient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// MergeRepository defines the interface for merging duplicate patients
+type MergeRepository interface {
+	Merge(ctx context.Context, survivorID, duplicateID int, mergedBy Actor) (*Merge, error)
+	GetMerge(ctx context.Context, id int) (*Merge, error)
+	ListMerges(ctx context.Context, patientID int) ([]Merge, error)
+	Undo(ctx context.Context, id int, undoneBy Actor) (*Merge, error)
+}
+
+type postgresMergeRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresMergeRepository creates a new repository for patient merges
+func NewPostgresMergeRepository(db *sqlx.DB) MergeRepository {
+	return &postgresMergeRepository{db: db}
+}
+
+const mergeColumns = `id, survivor_id, duplicate_id, merged_by, merged_at, prescription_ids, document_ids,
+	care_team_doctor_ids, identifier_ids, contact_ids, portal_user_id, undone_at, undone_by`
+
+// Merge moves the prescriptions, documents, care team, identifiers, emergency
+// contacts and portal account of the duplicate to the survivor, moves the
+// duplicate to the trash and records what was moved, all in one transaction
+func (r *postgresMergeRepository) Merge(ctx context.Context, survivorID, duplicateID int, mergedBy Actor) (*Merge, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked []int
+	err = tx.SelectContext(ctx, &locked, `SELECT id FROM patients WHERE id IN ($1, $2) AND deleted_at IS NULL ORDER BY id FOR UPDATE`, survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) != 2 {
+		return nil, ErrPatientNotFound
+	}
+
+	m := Merge{
+		PrescriptionIDs:   pq.Int64Array{},
+		DocumentIDs:       pq.Int64Array{},
+		CareTeamDoctorIDs: pq.Int64Array{},
+		IdentifierIDs:     pq.Int64Array{},
+		ContactIDs:        pq.Int64Array{},
+	}
+	if err := tx.SelectContext(ctx, &m.PrescriptionIDs, `UPDATE prescriptions SET patient_id = $1 WHERE patient_id = $2 RETURNING id`, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	if err := tx.SelectContext(ctx, &m.DocumentIDs, `UPDATE patient_documents SET patient_id = $1 WHERE patient_id = $2 RETURNING id`, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	if err := tx.SelectContext(ctx, &m.IdentifierIDs, `UPDATE patient_identifiers SET patient_id = $1 WHERE patient_id = $2 RETURNING id`, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	// The survivor's primary contact stays the primary one
+	query := `UPDATE patient_emergency_contacts SET patient_id = $1,
+			is_primary = is_primary AND NOT EXISTS (SELECT 1 FROM patient_emergency_contacts WHERE patient_id = $1 AND is_primary)
+		WHERE patient_id = $2 RETURNING id`
+	if err := tx.SelectContext(ctx, &m.ContactIDs, query, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	// Doctors already on the survivor's care team keep their assignment
+	query = `INSERT INTO care_team_members (patient_id, doctor_id, is_primary, assigned_by, assigned_at)
+		SELECT $1, doctor_id, FALSE, $3, NOW() FROM care_team_members WHERE patient_id = $2
+		ON CONFLICT (patient_id, doctor_id) DO NOTHING RETURNING doctor_id`
+	if err := tx.SelectContext(ctx, &m.CareTeamDoctorIDs, query, survivorID, duplicateID, mergedBy.UserID); err != nil {
+		return nil, err
+	}
+
+	// A portal account follows its patient. Two accounts cannot be merged.
+	var accounts []struct {
+		ID        int `db:"id"`
+		PatientID int `db:"patient_id"`
+	}
+	if err := tx.SelectContext(ctx, &accounts, `SELECT id, patient_id FROM users WHERE patient_id IN ($1, $2)`, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	if len(accounts) == 2 {
+		return nil, ErrMergeConflict
+	}
+	if len(accounts) == 1 && accounts[0].PatientID == duplicateID {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET patient_id = $1 WHERE id = $2`, survivorID, accounts[0].ID); err != nil {
+			return nil, err
+		}
+		m.PortalUserID = &accounts[0].ID
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE patients SET deleted_at = NOW(), deleted_by = $2, updated_by_api_key = $3 WHERE id = $1`, duplicateID, mergedBy.UserID, mergedBy.APIKeyID); err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO patient_merges (survivor_id, duplicate_id, merged_by, merged_at, prescription_ids, document_ids, care_team_doctor_ids, identifier_ids, contact_ids, portal_user_id)
+		VALUES ($1, $2, $3, NOW(), $4, $5, $6, $7, $8, $9) RETURNING ` + mergeColumns
+	err = tx.GetContext(ctx, &m, query, survivorID, duplicateID, mergedBy.UserID, m.PrescriptionIDs, m.DocumentIDs, m.CareTeamDoctorIDs, m.IdentifierIDs, m.ContactIDs, m.PortalUserID)
+	if err != nil {
+		return nil, err
+	}
+	return &m, tx.Commit()
+}
+
+// GetMerge retrieves a merge record by its ID
+func (r *postgresMergeRepository) GetMerge(ctx context.Context, id int) (*Merge, error) {
+	var m Merge
+	err := r.db.GetContext(ctx, &m, `SELECT `+mergeColumns+` FROM patient_merges WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMergeNotFound
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListMerges retrieves the merges a patient took part in, newest first
+func (r *postgresMergeRepository) ListMerges(ctx context.Context, patientID int) ([]Merge, error) {
+	merges := []Merge{}
+	query := `SELECT ` + mergeColumns + ` FROM patient_merges WHERE survivor_id = $1 OR duplicate_id = $1 ORDER BY merged_at DESC, id DESC`
+	err := r.db.SelectContext(ctx, &merges, query, patientID)
+	return merges, err
+}
+
+// Undo moves the recorded rows back to the duplicate and restores it. Rows
+// that were moved on again since the merge are left where they are.
+func (r *postgresMergeRepository) Undo(ctx context.Context, id int, undoneBy Actor) (*Merge, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var m Merge
+	if err := tx.GetContext(ctx, &m, `SELECT `+mergeColumns+` FROM patient_merges WHERE id = $1 FOR UPDATE`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMergeNotFound
+		}
+		return nil, err
+	}
+	if m.UndoneAt != nil {
+		return nil, ErrMergeAlreadyUndone
+	}
+	// A purged patient cannot be brought back
+	if m.SurvivorID == nil || m.DuplicateID == nil {
+		return nil, ErrMergeNotUndoable
+	}
+	survivorID, duplicateID := *m.SurvivorID, *m.DuplicateID
+
+	res, err := tx.ExecContext(ctx, `UPDATE patients SET deleted_at = NULL, deleted_by = NULL, updated_by = $2, updated_by_api_key = $3 WHERE id = $1 AND deleted_at IS NOT NULL`, duplicateID, undoneBy.UserID, undoneBy.APIKeyID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrMergeNotUndoable
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE prescriptions SET patient_id = $1 WHERE patient_id = $2 AND id = ANY($3)`, duplicateID, survivorID, m.PrescriptionIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE patient_documents SET patient_id = $1 WHERE patient_id = $2 AND id = ANY($3)`, duplicateID, survivorID, m.DocumentIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE patient_identifiers SET patient_id = $1 WHERE patient_id = $2 AND id = ANY($3)`, duplicateID, survivorID, m.IdentifierIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE patient_emergency_contacts SET patient_id = $1 WHERE patient_id = $2 AND id = ANY($3)`, duplicateID, survivorID, m.ContactIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM care_team_members WHERE patient_id = $1 AND doctor_id = ANY($2) AND NOT is_primary`, survivorID, m.CareTeamDoctorIDs); err != nil {
+		return nil, err
+	}
+	if m.PortalUserID != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET patient_id = $1 WHERE id = $2 AND patient_id = $3`, duplicateID, *m.PortalUserID, survivorID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.GetContext(ctx, &m, `UPDATE patient_merges SET undone_at = NOW(), undone_by = $2 WHERE id = $1 RETURNING `+mergeColumns, id, undoneBy.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &m, tx.Commit()
+}
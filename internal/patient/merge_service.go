@@ -0,0 +1,73 @@
+package patient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+var (
+	ErrMergeNotFound      = errors.New("patient merge not found")
+	ErrMergeSelf          = errors.New("a patient cannot be merged into itself")
+	ErrMergeConflict      = errors.New("both patients have a portal account")
+	ErrMergeAlreadyUndone = errors.New("patient merge has already been undone")
+	ErrMergeNotUndoable   = errors.New("patient merge can no longer be undone")
+)
+
+// MergeService merges duplicate patients into a surviving record and undoes
+// merges
+type MergeService interface {
+	MergePatients(ctx context.Context, caller *middleware.Identity, survivorID int, req MergeRequest) (*Merge, error)
+	ListMerges(ctx context.Context, caller *middleware.Identity, patientID int) ([]Merge, error)
+	UndoMerge(ctx context.Context, caller *middleware.Identity, id int) (*Merge, error)
+}
+
+type mergeService struct {
+	repo   MergeRepository
+	access careteam.AccessChecker
+}
+
+// NewMergeService creates a new patient merge service
+func NewMergeService(r MergeRepository, access careteam.AccessChecker) MergeService {
+	return &mergeService{repo: r, access: access}
+}
+
+// MergePatients moves the duplicate's prescriptions, documents, care team and
+// portal account to the survivor and moves the duplicate to the trash. The
+// caller must have access to both patients.
+func (s *mergeService) MergePatients(ctx context.Context, caller *middleware.Identity, survivorID int, req MergeRequest) (*Merge, error) {
+	if survivorID == req.DuplicateID {
+		return nil, ErrMergeSelf
+	}
+	for _, id := range []int{survivorID, req.DuplicateID} {
+		if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Merge(ctx, survivorID, req.DuplicateID, actorOf(caller))
+}
+
+// ListMerges returns the merges a patient took part in, as survivor or as
+// duplicate
+func (s *mergeService) ListMerges(ctx context.Context, caller *middleware.Identity, patientID int) ([]Merge, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMerges(ctx, patientID)
+}
+
+// UndoMerge moves what a merge moved back to the duplicate and restores it
+func (s *mergeService) UndoMerge(ctx context.Context, caller *middleware.Identity, id int) (*Merge, error) {
+	m, err := s.repo.GetMerge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.SurvivorID != nil {
+		if err := s.access.CheckPatientAccess(ctx, caller, *m.SurvivorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Undo(ctx, id, actorOf(caller))
+}
@@ -1,37 +1,216 @@
 package patient
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
 
 type Patient struct {
-	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	PhoneNumber *string   `json:"phone_number,omitempty" db:"phone_number"`
-	Age         int       `json:"age" db:"age"`
-	Address     string    `json:"address" db:"address"`
-	Diagnosis   *string   `json:"diagnosis" db:"diagnosis"`
-	Notes       *string   `json:"notes" db:"notes"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-}
-
-// CreatePatientRequest is used for creating a new patient
+	ID           int       `json:"id" db:"id"`
+	MRN          *string   `json:"mrn,omitempty" db:"mrn"`
+	Name         string    `json:"name" db:"name"`
+	PhoneNumber  *string   `json:"phone_number,omitempty" db:"phone_number"`
+	DateOfBirth  time.Time `json:"date_of_birth" db:"date_of_birth"`
+	DOBEstimated bool      `json:"dob_estimated" db:"dob_estimated"`
+	Address      string    `json:"address" db:"address"`
+	Details
+	Diagnosis       *string    `json:"diagnosis" db:"diagnosis"`
+	Notes           *string    `json:"notes" db:"notes"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	UpdatedBy       *int       `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedByAPIKey *int       `json:"updated_by_api_key,omitempty" db:"updated_by_api_key"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy       *int       `json:"deleted_by,omitempty" db:"deleted_by"`
+	Revision        int        `json:"revision" db:"revision"`
+}
+
+// Details are the optional demographics of a patient. Address is the street
+// line of the structured address; City to Country complete it. Country is an
+// ISO 3166-1 alpha-2 code and PreferredLanguage a BCP 47 tag such as "en" or
+// "pt-BR".
+type Details struct {
+	Sex               *string `json:"sex,omitempty" db:"sex" binding:"omitempty,oneof=female male intersex unknown"`
+	Gender            *string `json:"gender,omitempty" db:"gender" binding:"omitempty,max=64"`
+	Email             *string `json:"email,omitempty" db:"email" binding:"omitempty,email,max=255"`
+	PreferredLanguage *string `json:"preferred_language,omitempty" db:"preferred_language" binding:"omitempty,max=35,bcp47_language_tag"`
+	City              *string `json:"city,omitempty" db:"city" binding:"omitempty,max=100"`
+	Region            *string `json:"region,omitempty" db:"region" binding:"omitempty,max=100"`
+	Postcode          *string `json:"postcode,omitempty" db:"postcode" binding:"omitempty,max=20"`
+	Country           *string `json:"country,omitempty" db:"country" binding:"omitempty,iso3166_1_alpha2"`
+	MaritalStatus     *string `json:"marital_status,omitempty" db:"marital_status" binding:"omitempty,oneof=single married partnered separated divorced widowed unknown"`
+	Occupation        *string `json:"occupation,omitempty" db:"occupation" binding:"omitempty,max=100"`
+}
+
+// CreatePatientRequest is used for creating a new patient. A patient that
+// resembles existing patients is only created with ConfirmDuplicate set.
+// DOBEstimated marks a date of birth worked out from a stated age.
 type CreatePatientRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	PhoneNumber *string `json:"phone_number"`
-	Age         int     `json:"age" binding:"required,gt=0"`
-	Address     string  `json:"address" binding:"required"`
+	Name             string  `json:"name" binding:"required"`
+	PhoneNumber      *string `json:"phone_number"`
+	DateOfBirth      string  `json:"date_of_birth" binding:"required,datetime=2006-01-02"`
+	DOBEstimated     bool    `json:"dob_estimated"`
+	Address          string  `json:"address" binding:"required"`
+	ConfirmDuplicate bool    `json:"confirm_duplicate"`
+	Details
+}
+
+// DuplicateCandidate is an existing patient that probably is the same person
+// as a new one. Score is between 0 and 1; Reasons lists the fields that matched.
+type DuplicateCandidate struct {
+	Patient
+	Score   float64
+	Reasons []string
+}
+
+// MergeRequest names the duplicate to merge into the patient in the path
+type MergeRequest struct {
+	DuplicateID int `json:"duplicate_id" binding:"required,gt=0"`
+}
+
+// Merge records a duplicate patient merged into a surviving patient and the
+// rows that were moved, so the merge can be undone. SurvivorID and
+// DuplicateID are cleared when either patient is purged.
+type Merge struct {
+	ID                int           `json:"id" db:"id"`
+	SurvivorID        *int          `json:"survivor_id" db:"survivor_id"`
+	DuplicateID       *int          `json:"duplicate_id" db:"duplicate_id"`
+	MergedBy          *int          `json:"merged_by,omitempty" db:"merged_by"`
+	MergedAt          time.Time     `json:"merged_at" db:"merged_at"`
+	PrescriptionIDs   pq.Int64Array `json:"prescription_ids" db:"prescription_ids" swaggertype:"array,integer"`
+	DocumentIDs       pq.Int64Array `json:"document_ids" db:"document_ids" swaggertype:"array,integer"`
+	CareTeamDoctorIDs pq.Int64Array `json:"care_team_doctor_ids" db:"care_team_doctor_ids" swaggertype:"array,integer"`
+	IdentifierIDs     pq.Int64Array `json:"identifier_ids" db:"identifier_ids" swaggertype:"array,integer"`
+	ContactIDs        pq.Int64Array `json:"contact_ids" db:"contact_ids" swaggertype:"array,integer"`
+	PortalUserID      *int          `json:"portal_user_id,omitempty" db:"portal_user_id"`
+	UndoneAt          *time.Time    `json:"undone_at,omitempty" db:"undone_at"`
+	UndoneBy          *int          `json:"undone_by,omitempty" db:"undone_by"`
 }
 
 // UpdatePatientRequest is used for updating a patient's full record
 type UpdatePatientRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	PhoneNumber *string `json:"phone_number"`
-	Age         int     `json:"age" binding:"required,gt=0"`
-	Address     string  `json:"address" binding:"required"`
+	Name         string  `json:"name" binding:"required"`
+	PhoneNumber  *string `json:"phone_number"`
+	DateOfBirth  string  `json:"date_of_birth" binding:"required,datetime=2006-01-02"`
+	DOBEstimated bool    `json:"dob_estimated"`
+	Address      string  `json:"address" binding:"required"`
+	Details
+
+	// IfRevision is the revision the caller last read, taken from the
+	// If-Match header. Zero updates whatever the current revision is.
+	IfRevision int `json:"-"`
+}
+
+// EmergencyContact is a person to call about a patient in an emergency. At
+// most one of a patient's contacts is the primary contact.
+type EmergencyContact struct {
+	ID           int       `json:"id" db:"id"`
+	PatientID    int       `json:"patient_id" db:"patient_id"`
+	Name         string    `json:"name" db:"name"`
+	Relationship string    `json:"relationship" db:"relationship"`
+	PhoneNumber  string    `json:"phone_number" db:"phone_number"`
+	Email        *string   `json:"email,omitempty" db:"email"`
+	IsPrimary    bool      `json:"is_primary" db:"is_primary"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EmergencyContactRequest is used for adding and replacing an emergency contact
+type EmergencyContactRequest struct {
+	Name         string  `json:"name" binding:"required,max=100"`
+	Relationship string  `json:"relationship" binding:"required,max=50"`
+	PhoneNumber  string  `json:"phone_number" binding:"required,max=30"`
+	Email        *string `json:"email" binding:"omitempty,email,max=255"`
+	IsPrimary    bool    `json:"is_primary"`
+}
+
+// Identifier is a patient's identifier in another system, such as a national
+// ID or an insurance member ID. A value identifies one patient per system.
+type Identifier struct {
+	ID        int       `json:"id" db:"id"`
+	PatientID int       `json:"patient_id" db:"patient_id"`
+	System    string    `json:"system" db:"system"`
+	Value     string    `json:"value" db:"value"`
+	CreatedBy *int      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// IdentifierRequest is used for adding an identifier. System is a lowercase
+// name such as national_id or insurance_member_id.
+type IdentifierRequest struct {
+	System string `json:"system" binding:"required,max=32"`
+	Value  string `json:"value" binding:"required,max=64"`
+}
+
+// Version is one immutable version of a patient record: who changed it,
+// when, and the old and new value of every changed field. Operation is
+// create, update, delete or restore. Changes made with an API key have
+// ChangedByAPIKey set instead of ChangedBy.
+type Version struct {
+	PatientID       int             `json:"patient_id" db:"patient_id"`
+	Version         int             `json:"version" db:"version"`
+	Operation       string          `json:"operation" db:"operation"`
+	ChangedBy       *int            `json:"changed_by,omitempty" db:"changed_by"`
+	ChangedByAPIKey *int            `json:"changed_by_api_key,omitempty" db:"changed_by_api_key"`
+	ChangedAt       time.Time       `json:"changed_at" db:"changed_at"`
+	Changes         Changes         `json:"changes" db:"changes"`
+	Snapshot        json.RawMessage `json:"-" db:"snapshot"`
+}
+
+// FieldChange is the old and new JSON value of a field
+type FieldChange struct {
+	Old json.RawMessage `json:"old" swaggertype:"object"`
+	New json.RawMessage `json:"new" swaggertype:"object"`
+}
+
+// Changes maps the changed fields of a patient to their old and new values
+type Changes map[string]FieldChange
+
+// Scan implements sql.Scanner for the JSONB changes column
+func (c *Changes) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Changes", src)
+	}
+	return json.Unmarshal(b, c)
+}
+
+// Diff lists the fields that differ between two versions of a patient
+type Diff struct {
+	PatientID int     `json:"patient_id"`
+	From      int     `json:"from"`
+	To        int     `json:"to"`
+	Changes   Changes `json:"changes"`
 }
 
 // UpdatePatientMedicalRequest is used by doctors to update medical fields
 type UpdatePatientMedicalRequest struct {
-	Diagnosis string `json:"diagnosis" binding:"required"`
-	Notes     string `json:"notes"`
+	Diagnosis  string `json:"diagnosis" binding:"required"`
+	Notes      string `json:"notes"`
+	IfRevision int    `json:"-"`
+}
+
+// ListOptions filters, sorts and pages a patient listing. Date ranges are
+// inclusive calendar days; ages are completed years as of today.
+type ListOptions struct {
+	AgeMin       *int       `form:"age_min" binding:"omitempty,min=0"`
+	AgeMax       *int       `form:"age_max" binding:"omitempty,min=0"`
+	CreatedFrom  *time.Time `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo    *time.Time `form:"created_to" time_format:"2006-01-02"`
+	UpdatedFrom  *time.Time `form:"updated_from" time_format:"2006-01-02"`
+	UpdatedTo    *time.Time `form:"updated_to" time_format:"2006-01-02"`
+	HasDiagnosis *bool      `form:"has_diagnosis"`
+	DoctorID     *int       `form:"doctor_id" binding:"omitempty,min=1"`
+	pagination.Request
+
+	// PanelOf restricts the listing to a doctor's care teams and Deleted lists
+	// the trash instead of active patients. Both are set by the service, never
+	// from the query string.
+	PanelOf int  `form:"-"`
+	Deleted bool `form:"-"`
 }
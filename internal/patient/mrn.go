@@ -0,0 +1,150 @@
+package patient
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMRNPattern yields MRNs like MRN-2026-0004213
+const DefaultMRNPattern = "MRN-{YYYY}-{SEQ:6}{CHECK}"
+
+// MaxMRNLength is the length of the mrn column
+const MaxMRNLength = 32
+
+var ErrInvalidMRNPattern = errors.New("invalid MRN pattern")
+
+// MRNFormat generates medical record numbers from a pattern of literal text
+// and the placeholders {YYYY} and {YY} for the year of registration, {SEQ} or
+// {SEQ:n} for a sequence number zero-padded to n digits, and {CHECK} for a
+// Luhn check digit over the other digits. The sequence counts per scope: the
+// pattern with the year filled in, so a pattern with a year restarts at 1
+// every year.
+type MRNFormat struct {
+	parts []mrnPart
+}
+
+type mrnPart struct {
+	kind  string // "", "YYYY", "YY", "SEQ" or "CHECK"
+	text  string
+	width int
+}
+
+var mrnPlaceholder = regexp.MustCompile(`\{(YYYY|YY|SEQ(?::(\d+))?|CHECK)\}`)
+
+// ParseMRNFormat parses an MRN pattern. It must have exactly one sequence,
+// at most one check digit and yield MRNs of at most MaxMRNLength characters
+// up to a sequence of a million.
+func ParseMRNFormat(pattern string) (*MRNFormat, error) {
+	f := &MRNFormat{}
+	seqs, checks, last := 0, 0, 0
+	for _, m := range mrnPlaceholder.FindAllStringSubmatchIndex(pattern, -1) {
+		if m[0] > last {
+			f.parts = append(f.parts, mrnPart{text: pattern[last:m[0]]})
+		}
+		last = m[1]
+		token := pattern[m[2]:m[3]]
+		part := mrnPart{kind: token}
+		if strings.HasPrefix(token, "SEQ") {
+			part.kind = "SEQ"
+			seqs++
+			if m[4] >= 0 {
+				part.width, _ = strconv.Atoi(pattern[m[4]:m[5]])
+			}
+		}
+		if token == "CHECK" {
+			checks++
+		}
+		f.parts = append(f.parts, part)
+	}
+	if last < len(pattern) {
+		f.parts = append(f.parts, mrnPart{text: pattern[last:]})
+	}
+
+	for _, p := range f.parts {
+		if p.kind == "" && strings.ContainsAny(p.text, "{}") {
+			return nil, fmt.Errorf("%w: unknown placeholder in %q", ErrInvalidMRNPattern, p.text)
+		}
+	}
+	if seqs != 1 {
+		return nil, fmt.Errorf("%w: %q must have exactly one {SEQ}", ErrInvalidMRNPattern, pattern)
+	}
+	if checks > 1 {
+		return nil, fmt.Errorf("%w: %q has more than one {CHECK}", ErrInvalidMRNPattern, pattern)
+	}
+	if n := len(f.Format(time.Now(), 1_000_000)); n > MaxMRNLength {
+		return nil, fmt.Errorf("%w: %q yields MRNs of %d characters, more than %d", ErrInvalidMRNPattern, pattern, n, MaxMRNLength)
+	}
+	return f, nil
+}
+
+// Scope returns the scope that counts the sequence of MRNs registered at now
+func (f *MRNFormat) Scope(now time.Time) string {
+	var b strings.Builder
+	for _, p := range f.parts {
+		switch p.kind {
+		case "SEQ", "CHECK":
+		default:
+			b.WriteString(p.render(now, 0))
+		}
+	}
+	return b.String()
+}
+
+// Format returns the MRN with sequence number seq of a patient registered at now
+func (f *MRNFormat) Format(now time.Time, seq int64) string {
+	var b strings.Builder
+	check := -1
+	for _, p := range f.parts {
+		if p.kind == "CHECK" {
+			check = b.Len()
+			continue
+		}
+		b.WriteString(p.render(now, seq))
+	}
+	mrn := b.String()
+	if check < 0 {
+		return mrn
+	}
+	return mrn[:check] + string(luhnDigit(mrn)) + mrn[check:]
+}
+
+func (p mrnPart) render(now time.Time, seq int64) string {
+	switch p.kind {
+	case "YYYY":
+		return now.Format("2006")
+	case "YY":
+		return now.Format("06")
+	case "SEQ":
+		return fmt.Sprintf("%0*d", p.width, seq)
+	}
+	return p.text
+}
+
+// luhnDigit returns the Luhn check digit of the digits in s
+func luhnDigit(s string) byte {
+	sum, double := 0, true
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// NormalizeMRN returns an MRN as stored, for lookups
+func NormalizeMRN(mrn string) string {
+	return strings.ToUpper(strings.TrimSpace(mrn))
+}
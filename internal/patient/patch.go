@@ -0,0 +1,466 @@
+package patient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// Media types of the patches accepted by PATCH /patients/:id
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrInvalidPatch    = errors.New("invalid patch")
+	ErrPatchTestFailed = errors.New("patch test operation failed")
+	ErrFieldForbidden  = errors.New("not allowed to change field")
+)
+
+// PatchRequest is a partial update of a patient: an RFC 7396 merge patch or
+// an RFC 6902 JSON Patch, as named by Type, applied to the PatchDocument of
+// the patient
+type PatchRequest struct {
+	Type string
+	Body []byte
+
+	// IfRevision is the revision from the If-Match header, as in
+	// UpdatePatientRequest
+	IfRevision int
+}
+
+// PatchDocument is the editable part of a patient that patches apply to.
+// Diagnosis and notes are only part of it for callers with clinical access.
+// Removing phone_number or a detail clears it.
+type PatchDocument struct {
+	Name         string  `json:"name" binding:"required"`
+	PhoneNumber  *string `json:"phone_number"`
+	DateOfBirth  string  `json:"date_of_birth" binding:"required,datetime=2006-01-02"`
+	DOBEstimated bool    `json:"dob_estimated"`
+	Address      string  `json:"address" binding:"required"`
+	Details
+	Diagnosis *string `json:"diagnosis,omitempty"`
+	Notes     *string `json:"notes,omitempty"`
+}
+
+// clinicalPatchFields are the members of a PatchDocument that need clinical access
+var clinicalPatchFields = []string{"diagnosis", "notes"}
+
+// patchDocument returns the document a caller's patch applies to
+func patchDocument(p *Patient, clinical bool) (map[string]interface{}, error) {
+	raw, err := json.Marshal(PatchDocument{
+		Name:         p.Name,
+		PhoneNumber:  p.PhoneNumber,
+		DateOfBirth:  p.DateOfBirth.Format(DateLayout),
+		DOBEstimated: p.DOBEstimated,
+		Address:      p.Address,
+		Details:      p.Details,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if clinical {
+		doc["diagnosis"] = stringOrNil(p.Diagnosis)
+		doc["notes"] = stringOrNil(p.Notes)
+	}
+	return doc, nil
+}
+
+func stringOrNil(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// patchPatient returns the patient with the patch applied, to be saved at the
+// current revision
+func patchPatient(caller *middleware.Identity, current *Patient, req PatchRequest) (*Patient, error) {
+	clinical := HasClinicalAccess(caller)
+	before, err := patchDocument(current, clinical)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := applyPatch(deepCopy(before).(map[string]interface{}), req.Type, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	after, ok := patched.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: patched patient must be an object", ErrInvalidPatch)
+	}
+
+	for _, field := range clinicalPatchFields {
+		if _, ok := after[field]; ok && !clinical {
+			return nil, fmt.Errorf("%w: %s", ErrFieldForbidden, field)
+		}
+	}
+	for _, field := range changedFields(before, after) {
+		permission := authz.PatientUpdate
+		if slices.Contains(clinicalPatchFields, field) {
+			permission = authz.PatientMedicalWrite
+		}
+		if caller == nil || !caller.HasPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", ErrFieldForbidden, field)
+		}
+	}
+
+	raw, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	var patch PatchDocument
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if err := binding.Validator.ValidateStruct(&patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	dob, err := ParseDateOfBirth(patch.DateOfBirth, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	by := actorOf(caller)
+	p := &Patient{
+		ID:              current.ID,
+		Name:            patch.Name,
+		DateOfBirth:     dob,
+		DOBEstimated:    patch.DOBEstimated,
+		Address:         patch.Address,
+		PhoneNumber:     patch.PhoneNumber,
+		Details:         normalizeDetails(patch.Details),
+		Diagnosis:       current.Diagnosis,
+		Notes:           current.Notes,
+		UpdatedBy:       by.UserID,
+		UpdatedByAPIKey: by.APIKeyID,
+		Revision:        current.Revision,
+	}
+	if clinical {
+		p.Diagnosis, p.Notes = patch.Diagnosis, patch.Notes
+	}
+	return p, nil
+}
+
+// changedFields returns the members that differ between two documents
+func changedFields(before, after map[string]interface{}) []string {
+	var fields []string
+	for field, v := range after {
+		if !reflect.DeepEqual(before[field], v) {
+			fields = append(fields, field)
+		}
+	}
+	for field, v := range before {
+		if _, ok := after[field]; !ok && v != nil {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// applyPatch applies a patch of the given media type to doc
+func applyPatch(doc map[string]interface{}, patchType string, body []byte) (interface{}, error) {
+	switch patchType {
+	case MergePatchType:
+		var patch interface{}
+		if err := json.Unmarshal(body, &patch); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		if _, ok := patch.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: merge patch must be an object", ErrInvalidPatch)
+		}
+		return MergePatch(doc, patch), nil
+	case JSONPatchType:
+		var ops []PatchOperation
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return ApplyJSONPatch(doc, ops)
+	}
+	return nil, fmt.Errorf("%w: unsupported media type %q", ErrInvalidPatch, patchType)
+}
+
+// MergePatch applies an RFC 7396 merge patch to target: members of an object
+// patch are merged recursively, null members are removed and any other value
+// replaces the target
+func MergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = MergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// PatchOperation is one operation of an RFC 6902 JSON Patch
+type PatchOperation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from,omitempty"`
+	Value *json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// ApplyJSONPatch applies the operations of an RFC 6902 JSON Patch to doc in
+// order. Either every operation applies or an error is returned; doc itself
+// may have been modified in either case.
+func ApplyJSONPatch(doc interface{}, ops []PatchOperation) (interface{}, error) {
+	for i, op := range ops {
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (interface{}, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		var v interface{}
+		if err := json.Unmarshal(*op.Value, &v); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return v, nil
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if doc, _, err = removeValue(doc, path); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+			}
+			doc, v, err = removeValue(doc, from)
+		} else {
+			v, err = getValue(doc, from)
+			v = deepCopy(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(got, v) {
+			return nil, ErrPatchTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			v, ok := node[token]
+			if !ok {
+				return nil, pathNotFound(path)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, pathNotFound(path)
+		}
+	}
+	return doc, nil
+}
+
+// addValue sets the value at path, inserting it into arrays, and returns the
+// new document
+func addValue(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = v
+		return doc, nil
+	case []interface{}:
+		i := len(node)
+		if last != "-" {
+			if i, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node[:i], append([]interface{}{v}, node[i:]...)...)
+		return setValue(doc, path[:len(path)-1], node)
+	}
+	return nil, pathNotFound(path)
+}
+
+// removeValue removes the value at path and returns the new document and the
+// removed value
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		v, ok := node[last]
+		if !ok {
+			return nil, nil, pathNotFound(path)
+		}
+		delete(node, last)
+		return doc, v, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := node[i]
+		node = append(node[:i:i], node[i+1:]...)
+		doc, err = setValue(doc, path[:len(path)-1], node)
+		return doc, v, err
+	}
+	return nil, nil, pathNotFound(path)
+}
+
+// setValue replaces the value at an existing path, for arrays that changed length
+func setValue(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = v
+	case []interface{}:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = v
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index token no greater than max
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return i, nil
+}
+
+func pathNotFound(path []string) error {
+	return fmt.Errorf("%w: path /%s does not exist", ErrInvalidPatch, strings.Join(path, "/"))
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for k, e := range node {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, e := range node {
+			out[i] = deepCopy(e)
+		}
+		return out
+	}
+	return v
+}
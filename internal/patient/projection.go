@@ -0,0 +1,110 @@
+package patient
+
+import (
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+// Demographics is the view of a patient returned to callers without clinical
+// access, such as receptionists. Age is computed from the date of birth on
+// every read.
+type Demographics struct {
+	ID           int        `json:"id"`
+	MRN          *string    `json:"mrn,omitempty"`
+	Name         string     `json:"name"`
+	PhoneNumber  *string    `json:"phone_number,omitempty"`
+	DateOfBirth  string     `json:"date_of_birth"`
+	DOBEstimated bool       `json:"dob_estimated"`
+	Age          Age        `json:"age"`
+	Address      string     `json:"address"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy    *int       `json:"deleted_by,omitempty"`
+	Revision     int        `json:"revision"`
+	Details
+}
+
+// ClinicalRecord is the view of a patient returned to callers with the
+// patient:clinical:read permission, such as doctors. Everyone else gets the
+// Demographics view, which has no diagnosis or notes.
+type ClinicalRecord struct {
+	Demographics
+	// Only returned to callers with patient:clinical:read
+	Diagnosis *string `json:"diagnosis"`
+	// Only returned to callers with patient:clinical:read
+	Notes *string `json:"notes"`
+}
+
+// HasClinicalAccess reports whether the caller may see diagnosis and notes
+func HasClinicalAccess(caller *middleware.Identity) bool {
+	return caller != nil && caller.HasPermission(authz.PatientClinicalRead)
+}
+
+// Project returns the view of a patient the caller is allowed to see. Every
+// patient response goes through Project or ProjectPage.
+func Project(caller *middleware.Identity, p *Patient) interface{} {
+	if HasClinicalAccess(caller) {
+		return clinicalRecord(p)
+	}
+	return demographics(p)
+}
+
+// ProjectPage returns the view of a page of patients the caller is allowed to see
+func ProjectPage(caller *middleware.Identity, page *pagination.Page[Patient]) interface{} {
+	if HasClinicalAccess(caller) {
+		return pagination.Map(page, clinicalRecord)
+	}
+	return pagination.Map(page, demographics)
+}
+
+// DuplicateHit is the response for one probable duplicate. Patient is the
+// view of the patient the caller is allowed to see.
+type DuplicateHit struct {
+	Patient interface{} `json:"patient"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons"`
+}
+
+// ProjectDuplicates returns the probable duplicates as the caller is allowed to see them
+func ProjectDuplicates(caller *middleware.Identity, candidates []DuplicateCandidate) []DuplicateHit {
+	hits := make([]DuplicateHit, 0, len(candidates))
+	for i := range candidates {
+		hits = append(hits, DuplicateHit{
+			Patient: Project(caller, &candidates[i].Patient),
+			Score:   candidates[i].Score,
+			Reasons: candidates[i].Reasons,
+		})
+	}
+	return hits
+}
+
+func demographics(p *Patient) Demographics {
+	return Demographics{
+		ID:           p.ID,
+		MRN:          p.MRN,
+		Name:         p.Name,
+		PhoneNumber:  p.PhoneNumber,
+		DateOfBirth:  p.DateOfBirth.Format(DateLayout),
+		DOBEstimated: p.DOBEstimated,
+		Age:          AgeAt(p.DateOfBirth, time.Now()),
+		Address:      p.Address,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+		DeletedAt:    p.DeletedAt,
+		DeletedBy:    p.DeletedBy,
+		Revision:     p.Revision,
+		Details:      p.Details,
+	}
+}
+
+func clinicalRecord(p *Patient) ClinicalRecord {
+	return ClinicalRecord{
+		Demographics: demographics(p),
+		Diagnosis:    p.Diagnosis,
+		Notes:        p.Notes,
+	}
+}
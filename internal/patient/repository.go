@@ -4,40 +4,121 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
 var ErrPatientNotFound = errors.New("patient not found")
 
+// ErrRevisionMismatch is returned by conditional updates of a patient that
+// has been changed since the caller read it
+var ErrRevisionMismatch = errors.New("patient has been changed since it was read")
+
 // Repository defines the interface for patient data operations
 type Repository interface {
 	Create(ctx context.Context, patient *Patient) error
 	GetByID(ctx context.Context, id int) (*Patient, error)
-	GetAll(ctx context.Context) ([]Patient, error)
+	GetByMRN(ctx context.Context, mrn string) (*Patient, error)
+	List(ctx context.Context, opts ListOptions) (*pagination.Page[Patient], error)
 	Update(ctx context.Context, patient *Patient) error
-	UpdateMedical(ctx context.Context, id int, diagnosis, notes string) error
-	Delete(ctx context.Context, id int) error
-	SearchByName(ctx context.Context, name string) ([]Patient, error)
+	UpdateMedical(ctx context.Context, id int, diagnosis, notes string, updatedBy Actor, ifRevision int) error
+	Patch(ctx context.Context, patient *Patient) error
+	Delete(ctx context.Context, id int, deletedBy Actor) error
+	Restore(ctx context.Context, id int, restoredBy Actor) error
+	Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error)
+	FindDuplicates(ctx context.Context, p *Patient, panelOf int) ([]DuplicateCandidate, error)
+
+	// Retention of deleted patients
+	ListExpired(ctx context.Context, retention time.Duration, limit int) ([]int, error)
+	DocumentPublicIDs(ctx context.Context, patientID int) ([]string, error)
+	Purge(ctx context.Context, id int) error
 }
 
 type postgresRepository struct {
-	db *sqlx.DB
+	db  *sqlx.DB
+	mrn *MRNFormat
 }
 
-// NewPostgresRepository creates a new patient repository
-func NewPostgresRepository(db *sqlx.DB) Repository {
-	return &postgresRepository{db: db}
+// NewPostgresRepository creates a new patient repository. New patients are
+// given MRNs in the mrn format.
+func NewPostgresRepository(db *sqlx.DB, mrn *MRNFormat) Repository {
+	return &postgresRepository{db: db, mrn: mrn}
 }
 
+const patientColumns = `p.id, p.mrn, p.name, p.date_of_birth, p.dob_estimated, p.address, p.phone_number,
+	p.sex, p.gender, p.email, p.preferred_language, p.city, p.region, p.postcode, p.country, p.marital_status, p.occupation,
+	p.diagnosis, p.notes, p.created_at, p.updated_at, p.updated_by, p.updated_by_api_key, p.deleted_at, p.deleted_by, p.revision`
+
+// Create inserts a new patient with the next MRN
 func (r *postgresRepository) Create(ctx context.Context, p *Patient) error {
-	query := `INSERT INTO patients (name, age, address, phone_number, created_at, updated_at) VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`
-	return r.db.QueryRowContext(ctx, query, p.Name, p.Age, p.Address, p.PhoneNumber).Scan(&p.ID)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertPatient(ctx, tx, p, r.mrn); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertPatient inserts a new patient within a transaction. Unless the
+// patient has one, it is given the next MRN of the format; the sequence row
+// stays locked until the transaction ends, so numbers are not skipped.
+func insertPatient(ctx context.Context, tx *sqlx.Tx, p *Patient, mrn *MRNFormat) error {
+	if p.MRN == nil && mrn != nil {
+		value, err := nextMRN(ctx, tx, mrn, time.Now())
+		if err != nil {
+			return err
+		}
+		p.MRN = &value
+	}
+
+	query := `INSERT INTO patients (mrn, name, date_of_birth, dob_estimated, address, phone_number,
+			sex, gender, email, preferred_language, city, region, postcode, country, marital_status, occupation, updated_by, updated_by_api_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NOW(), NOW()) RETURNING id`
+	args := append([]interface{}{p.MRN, p.Name, p.DateOfBirth, p.DOBEstimated, p.Address, p.PhoneNumber}, detailArgs(&p.Details)...)
+	return tx.QueryRowxContext(ctx, query, append(args, p.UpdatedBy, p.UpdatedByAPIKey)...).Scan(&p.ID)
+}
+
+// nextMRN takes the next MRN of the format for a patient registered at
+// registered. Numbers already taken, such as by an MRN entered by hand before
+// they were generated, are skipped.
+func nextMRN(ctx context.Context, tx *sqlx.Tx, mrn *MRNFormat, registered time.Time) (string, error) {
+	for {
+		var seq int64
+		query := `INSERT INTO patient_mrn_sequences (scope, last_value) VALUES ($1, 1)
+			ON CONFLICT (scope) DO UPDATE SET last_value = patient_mrn_sequences.last_value + 1
+			RETURNING last_value`
+		if err := tx.GetContext(ctx, &seq, query, mrn.Scope(registered)); err != nil {
+			return "", err
+		}
+		value := mrn.Format(registered, seq)
+		var taken bool
+		if err := tx.GetContext(ctx, &taken, `SELECT EXISTS(SELECT 1 FROM patients WHERE UPPER(mrn) = $1)`, NormalizeMRN(value)); err != nil {
+			return "", err
+		}
+		if !taken {
+			return value, nil
+		}
+	}
+}
+
+// detailArgs returns the optional demographics in column order
+func detailArgs(d *Details) []interface{} {
+	return []interface{}{d.Sex, d.Gender, d.Email, d.PreferredLanguage, d.City, d.Region, d.Postcode, d.Country, d.MaritalStatus, d.Occupation}
 }
 
 func (r *postgresRepository) GetByID(ctx context.Context, id int) (*Patient, error) {
 	var p Patient
-	query := `SELECT id, name, age, address, phone_number, diagnosis, notes, created_at, updated_at FROM patients WHERE id = $1`
+	query := `SELECT ` + patientColumns + ` FROM patients p WHERE p.id = $1 AND p.deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &p, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -48,16 +129,198 @@ func (r *postgresRepository) GetByID(ctx context.Context, id int) (*Patient, err
 	return &p, nil
 }
 
-func (r *postgresRepository) GetAll(ctx context.Context) ([]Patient, error) {
+// GetByMRN retrieves an active patient by MRN, ignoring case
+func (r *postgresRepository) GetByMRN(ctx context.Context, mrn string) (*Patient, error) {
+	var p Patient
+	query := `SELECT ` + patientColumns + ` FROM patients p WHERE UPPER(p.mrn) = UPPER($1) AND p.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &p, query, mrn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPatientNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// patientSorter lists the sort keys of patient listings, newest first by default
+var patientSorter = pagination.Sorter[Patient]{
+	Keys: map[string]pagination.Column[Patient]{
+		"name":          {Expr: "p.name", Cast: "text", Value: func(p *Patient) string { return p.Name }},
+		"date_of_birth": {Expr: "p.date_of_birth", Cast: "date", Value: func(p *Patient) string { return p.DateOfBirth.Format(DateLayout) }},
+		"created_at":    {Expr: "p.created_at", Cast: "timestamp", Value: func(p *Patient) string { return pagination.FormatTime(p.CreatedAt) }},
+		"updated_at":    {Expr: "p.updated_at", Cast: "timestamp", Value: func(p *Patient) string { return pagination.FormatTime(p.UpdatedAt) }},
+	},
+	Default:  "-created_at",
+	IDColumn: "p.id",
+	ID:       func(p *Patient) int { return p.ID },
+}
+
+// trashSorter lists the sort keys of the trash, most recently deleted first by default
+var trashSorter = func() pagination.Sorter[Patient] {
+	s := patientSorter
+	s.Keys = map[string]pagination.Column[Patient]{
+		"deleted_at": {Expr: "p.deleted_at", Cast: "timestamp", Value: func(p *Patient) string { return pagination.FormatTime(*p.DeletedAt) }},
+	}
+	for k, c := range patientSorter.Keys {
+		s.Keys[k] = c
+	}
+	s.Default = "-deleted_at"
+	return s
+}()
+
+// List retrieves one page of the patients matching the filters, together with
+// the number of matching patients across all pages. Deleted patients are only
+// listed, and only listed, when opts.Deleted is set.
+func (r *postgresRepository) List(ctx context.Context, opts ListOptions) (*pagination.Page[Patient], error) {
+	conds := []string{"p.deleted_at IS NULL"}
+	sorter := patientSorter
+	if opts.Deleted {
+		conds = []string{"p.deleted_at IS NOT NULL"}
+		sorter = trashSorter
+	}
+	var args []interface{}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if opts.AgeMin != nil {
+		where("p.date_of_birth <= CURRENT_DATE - make_interval(years => $%d)", *opts.AgeMin)
+	}
+	if opts.AgeMax != nil {
+		where("p.date_of_birth > CURRENT_DATE - make_interval(years => $%d + 1)", *opts.AgeMax)
+	}
+	if opts.CreatedFrom != nil {
+		where("p.created_at >= $%d", *opts.CreatedFrom)
+	}
+	if opts.CreatedTo != nil {
+		where("p.created_at < $%d::timestamp + INTERVAL '1 day'", *opts.CreatedTo)
+	}
+	if opts.UpdatedFrom != nil {
+		where("p.updated_at >= $%d", *opts.UpdatedFrom)
+	}
+	if opts.UpdatedTo != nil {
+		where("p.updated_at < $%d::timestamp + INTERVAL '1 day'", *opts.UpdatedTo)
+	}
+	if opts.HasDiagnosis != nil {
+		if *opts.HasDiagnosis {
+			conds = append(conds, "COALESCE(p.diagnosis, '') <> ''")
+		} else {
+			conds = append(conds, "COALESCE(p.diagnosis, '') = ''")
+		}
+	}
+	if opts.DoctorID != nil {
+		where("EXISTS (SELECT 1 FROM care_team_members m WHERE m.patient_id = p.id AND m.doctor_id = $%d)", *opts.DoctorID)
+	}
+	if opts.PanelOf != 0 {
+		where("EXISTS (SELECT 1 FROM care_team_members m WHERE m.patient_id = p.id AND m.doctor_id = $%d)", opts.PanelOf)
+	}
+
+	q, err := sorter.Prepare(opts.Request, len(args)+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM patients p` + whereClause(conds)
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, err
+	}
+
+	if q.Where != "" {
+		conds = append(conds, q.Where)
+		args = append(args, q.Args...)
+	}
 	var patients []Patient
-	query := `SELECT id, name, age, address, phone_number, diagnosis, notes, created_at, updated_at FROM patients ORDER BY created_at DESC`
-	err := r.db.SelectContext(ctx, &patients, query)
-	return patients, err
+	query := `SELECT ` + patientColumns + ` FROM patients p` + whereClause(conds) + ` ORDER BY ` + q.OrderBy + fmt.Sprintf(` LIMIT %d`, q.Limit)
+	if err := r.db.SelectContext(ctx, &patients, query, args...); err != nil {
+		return nil, err
+	}
+	return sorter.Page(q, patients, total), nil
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
 }
 
+// Update saves the demographics of a patient. When p.Revision is set the
+// update only applies to that revision of the patient, and ErrRevisionMismatch
+// is returned if it has been changed since.
 func (r *postgresRepository) Update(ctx context.Context, p *Patient) error {
-	query := `UPDATE patients SET name = $1, age = $2, address = $3, phone_number = $4, updated_at = NOW() WHERE id = $5`
-	res, err := r.db.ExecContext(ctx, query, p.Name, p.Age, p.Address, p.PhoneNumber, p.ID)
+	query := `UPDATE patients SET name = $1, date_of_birth = $2, dob_estimated = $3, address = $4, phone_number = $5,
+			sex = $6, gender = $7, email = $8, preferred_language = $9, city = $10, region = $11, postcode = $12, country = $13,
+			marital_status = $14, occupation = $15, updated_by = $16, updated_by_api_key = $17, updated_at = NOW()
+		WHERE id = $18 AND deleted_at IS NULL AND ($19 = 0 OR revision = $19)`
+	args := append([]interface{}{p.Name, p.DateOfBirth, p.DOBEstimated, p.Address, p.PhoneNumber}, detailArgs(&p.Details)...)
+	res, err := r.db.ExecContext(ctx, query, append(args, p.UpdatedBy, p.UpdatedByAPIKey, p.ID, p.Revision)...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err == nil && rowsAffected == 0 {
+		return r.missingOrChanged(ctx, p.ID)
+	}
+	return err
+}
+
+// UpdateMedical saves the diagnosis and notes of a patient, checking
+// ifRevision like Update
+func (r *postgresRepository) UpdateMedical(ctx context.Context, id int, diagnosis, notes string, updatedBy Actor, ifRevision int) error {
+	query := `UPDATE patients SET diagnosis = $1, notes = $2, updated_by = $3, updated_by_api_key = $4, updated_at = NOW()
+		WHERE id = $5 AND deleted_at IS NULL AND ($6 = 0 OR revision = $6)`
+	res, err := r.db.ExecContext(ctx, query, diagnosis, notes, updatedBy.UserID, updatedBy.APIKeyID, id, ifRevision)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err == nil && rowsAffected == 0 {
+		return r.missingOrChanged(ctx, id)
+	}
+	return err
+}
+
+// Patch saves the demographics, diagnosis and notes of a patient at
+// p.Revision, which is required
+func (r *postgresRepository) Patch(ctx context.Context, p *Patient) error {
+	query := `UPDATE patients SET name = $1, date_of_birth = $2, dob_estimated = $3, address = $4, phone_number = $5,
+			sex = $6, gender = $7, email = $8, preferred_language = $9, city = $10, region = $11, postcode = $12, country = $13,
+			marital_status = $14, occupation = $15, diagnosis = $16, notes = $17, updated_by = $18, updated_by_api_key = $19, updated_at = NOW()
+		WHERE id = $20 AND deleted_at IS NULL AND revision = $21`
+	args := append([]interface{}{p.Name, p.DateOfBirth, p.DOBEstimated, p.Address, p.PhoneNumber}, detailArgs(&p.Details)...)
+	res, err := r.db.ExecContext(ctx, query, append(args, p.Diagnosis, p.Notes, p.UpdatedBy, p.UpdatedByAPIKey, p.ID, p.Revision)...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err == nil && rowsAffected == 0 {
+		return r.missingOrChanged(ctx, p.ID)
+	}
+	return err
+}
+
+// missingOrChanged explains why a conditional update of a patient matched no
+// row
+func (r *postgresRepository) missingOrChanged(ctx context.Context, id int) error {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM patients WHERE id = $1 AND deleted_at IS NULL)`, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrRevisionMismatch
+	}
+	return ErrPatientNotFound
+}
+
+// Delete moves a patient to the trash. The record, its prescriptions and its
+// documents are kept until Purge removes them.
+func (r *postgresRepository) Delete(ctx context.Context, id int, deletedBy Actor) error {
+	query := `UPDATE patients SET deleted_at = NOW(), deleted_by = $2, updated_by_api_key = $3 WHERE id = $1 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, id, deletedBy.UserID, deletedBy.APIKeyID)
 	if err != nil {
 		return err
 	}
@@ -68,9 +331,10 @@ func (r *postgresRepository) Update(ctx context.Context, p *Patient) error {
 	return err
 }
 
-func (r *postgresRepository) UpdateMedical(ctx context.Context, id int, diagnosis, notes string) error {
-	query := `UPDATE patients SET diagnosis = $1, notes = $2, updated_at = NOW() WHERE id = $3`
-	res, err := r.db.ExecContext(ctx, query, diagnosis, notes, id)
+// Restore takes a patient out of the trash
+func (r *postgresRepository) Restore(ctx context.Context, id int, restoredBy Actor) error {
+	query := `UPDATE patients SET deleted_at = NULL, deleted_by = NULL, updated_by = $2, updated_by_api_key = $3 WHERE id = $1 AND deleted_at IS NOT NULL`
+	res, err := r.db.ExecContext(ctx, query, id, restoredBy.UserID, restoredBy.APIKeyID)
 	if err != nil {
 		return err
 	}
@@ -81,9 +345,25 @@ func (r *postgresRepository) UpdateMedical(ctx context.Context, id int, diagnosi
 	return err
 }
 
-func (r *postgresRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM patients WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, query, id)
+// ListExpired returns up to limit patients that have been in the trash for longer than the retention period
+func (r *postgresRepository) ListExpired(ctx context.Context, retention time.Duration, limit int) ([]int, error) {
+	ids := []int{}
+	query := `SELECT id FROM patients WHERE deleted_at < NOW() - $1 * INTERVAL '1 second' ORDER BY deleted_at LIMIT $2`
+	err := r.db.SelectContext(ctx, &ids, query, int64(retention.Seconds()), limit)
+	return ids, err
+}
+
+// DocumentPublicIDs returns the stored file IDs of a patient's documents
+func (r *postgresRepository) DocumentPublicIDs(ctx context.Context, patientID int) ([]string, error) {
+	ids := []string{}
+	err := r.db.SelectContext(ctx, &ids, `SELECT public_id FROM patient_documents WHERE patient_id = $1`, patientID)
+	return ids, err
+}
+
+// Purge permanently removes a deleted patient. Prescriptions, documents and
+// the other rows that reference the patient are removed with it.
+func (r *postgresRepository) Purge(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM patients WHERE id = $1 AND deleted_at IS NOT NULL`, id)
 	if err != nil {
 		return err
 	}
@@ -94,9 +374,190 @@ func (r *postgresRepository) Delete(ctx context.Context, id int) error {
 	return err
 }
 
-func (r *postgresRepository) SearchByName(ctx context.Context, name string) ([]Patient, error) {
-	var patients []Patient
-	query := `SELECT id, name, age, address, phone_number, diagnosis, notes, created_at, updated_at FROM patients WHERE name ILIKE $1 ORDER BY name ASC`
-	err := r.db.SelectContext(ctx, &patients, query, "%"+name+"%")
-	return patients, err
+// searchRow is a patient with the per-field match flags computed by Search
+type searchRow struct {
+	Patient
+	MRNMatch          bool    `db:"mrn_match"`
+	PhoneMatch        bool    `db:"phone_match"`
+	NameExact         bool    `db:"name_exact"`
+	NameSimilarity    float64 `db:"name_similarity"`
+	NamePhonetic      bool    `db:"name_phonetic"`
+	AddressExact      bool    `db:"address_exact"`
+	AddressSimilarity float64 `db:"address_similarity"`
+	Score             float64 `db:"score"`
+}
+
+// Similarity thresholds above which a trigram match counts
+const (
+	nameSimilarityThreshold    = 0.3
+	addressSimilarityThreshold = 0.6
+)
+
+// Search finds patients by MRN, phone number digits, name and address, ranked
+// by relevance. Names match as a substring, by trigram similarity or when
+// every query word sounds like a word of the name (Double Metaphone).
+func (r *postgresRepository) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	digits := phoneDigits(opts.Query)
+	args := []interface{}{opts.Query, escapeLike(opts.Query), digits}
+
+	conds := []string{"p.deleted_at IS NULL"}
+	if opts.PanelOf != 0 {
+		args = append(args, opts.PanelOf)
+		conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM care_team_members m WHERE m.patient_id = p.id AND m.doctor_id = $%d)", len(args)))
+	}
+	limit := opts.Limit
+	if limit <= 0 || limit > MaxSearchLimit {
+		limit = DefaultSearchLimit
+	}
+
+	query := `SELECT s.*, GREATEST(
+			CASE WHEN s.mrn_match THEN 1.0 ELSE 0 END,
+			CASE WHEN s.phone_match THEN 0.9 ELSE 0 END,
+			CASE WHEN s.name_exact THEN 0.8 + 0.2 * s.name_similarity ELSE s.name_similarity END,
+			CASE WHEN s.name_phonetic THEN 0.6 ELSE 0 END,
+			CASE WHEN s.address_exact THEN 0.5 + 0.2 * s.address_similarity ELSE 0.7 * s.address_similarity END
+		)::float8 AS score
+		FROM (
+			SELECT ` + patientColumns + `,
+				COALESCE(UPPER(p.mrn) = UPPER($1), FALSE) AS mrn_match,
+				COALESCE(LENGTH($3) >= 3 AND regexp_replace(p.phone_number, '\D', '', 'g') LIKE '%' || $3 || '%', FALSE) AS phone_match,
+				p.name ILIKE '%' || $2 || '%' AS name_exact,
+				similarity(p.name, $1)::float8 AS name_similarity,
+				` + soundsLike("$1") + ` AS name_phonetic,
+				p.address ILIKE '%' || $2 || '%' AS address_exact,
+				word_similarity($1, p.address)::float8 AS address_similarity
+			FROM patients p` + whereClause(conds) + `
+		) s
+		WHERE s.mrn_match OR s.phone_match OR s.name_exact OR s.name_phonetic OR s.address_exact
+			OR s.name_similarity >= ` + fmt.Sprint(nameSimilarityThreshold) + `
+			OR s.address_similarity >= ` + fmt.Sprint(addressSimilarityThreshold) + `
+		ORDER BY score DESC, s.name, s.id
+		LIMIT ` + strconv.Itoa(limit)
+
+	var rows []searchRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, SearchResult{Patient: row.Patient, Score: row.Score, Matches: row.matches()})
+	}
+	return results, nil
+}
+
+// matches lists the fields of the row that matched, strongest match per field.
+// Highlights are added by the service.
+func (row *searchRow) matches() []SearchMatch {
+	var m []SearchMatch
+	if row.MRNMatch {
+		m = append(m, SearchMatch{Field: "mrn", Type: MatchExact})
+	}
+	if row.PhoneMatch {
+		m = append(m, SearchMatch{Field: "phone_number", Type: MatchExact})
+	}
+	switch {
+	case row.NameExact:
+		m = append(m, SearchMatch{Field: "name", Type: MatchExact})
+	case row.NameSimilarity >= nameSimilarityThreshold:
+		m = append(m, SearchMatch{Field: "name", Type: MatchFuzzy})
+	case row.NamePhonetic:
+		m = append(m, SearchMatch{Field: "name", Type: MatchPhonetic})
+	}
+	switch {
+	case row.AddressExact:
+		m = append(m, SearchMatch{Field: "address", Type: MatchExact})
+	case row.AddressSimilarity >= addressSimilarityThreshold:
+		m = append(m, SearchMatch{Field: "address", Type: MatchFuzzy})
+	}
+	return m
+}
+
+// soundsLike returns a condition that holds when every word of the name in
+// the param sounds like a word of the patient's name (Double Metaphone)
+func soundsLike(param string) string {
+	return param + ` ~ '[[:alpha:]]' AND NOT EXISTS (
+		SELECT 1 FROM regexp_split_to_table(LOWER(TRIM(` + param + `)), '\s+') AS q(word)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM regexp_split_to_table(LOWER(p.name), '\s+') AS n(word)
+			WHERE dmetaphone(n.word) = dmetaphone(q.word)
+		)
+	)`
+}
+
+// DuplicateThreshold is the score from which a patient is a probable duplicate
+const DuplicateThreshold = 0.5
+
+// duplicateRow is a patient with the match flags computed by FindDuplicates
+type duplicateRow struct {
+	Patient
+	NameSimilarity float64 `db:"name_similarity"`
+	NamePhonetic   bool    `db:"name_phonetic"`
+	PhoneMatch     bool    `db:"phone_match"`
+	DOBMatch       bool    `db:"dob_match"`
+	Score          float64 `db:"score"`
+}
+
+// FindDuplicates returns the active patients that probably are the same person
+// as p, best match first. Names count for half of the score, a matching phone
+// number for 0.3 and the same date of birth for 0.2. Estimated dates of birth
+// match within a year either way. A non-zero panelOf
+// restricts the candidates to a doctor's care teams.
+func (r *postgresRepository) FindDuplicates(ctx context.Context, p *Patient, panelOf int) ([]DuplicateCandidate, error) {
+	phone := ""
+	if p.PhoneNumber != nil {
+		phone = phoneDigits(*p.PhoneNumber)
+	}
+	args := []interface{}{p.Name, phone, p.DateOfBirth, p.ID, p.DOBEstimated}
+
+	conds := []string{"p.deleted_at IS NULL", "p.id <> $4"}
+	if panelOf != 0 {
+		args = append(args, panelOf)
+		conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM care_team_members m WHERE m.patient_id = p.id AND m.doctor_id = $%d)", len(args)))
+	}
+
+	query := `SELECT * FROM (
+			SELECT d.*, (
+				0.5 * GREATEST(d.name_similarity, CASE WHEN d.name_phonetic THEN 0.8 ELSE 0 END)
+				+ CASE WHEN d.phone_match THEN 0.3 ELSE 0 END
+				+ CASE WHEN d.dob_match THEN 0.2 ELSE 0 END
+			)::float8 AS score
+			FROM (
+				SELECT ` + patientColumns + `,
+					similarity(LOWER(p.name), LOWER($1))::float8 AS name_similarity,
+					` + soundsLike("$1") + ` AS name_phonetic,
+					COALESCE(LENGTH($2) >= 6 AND regexp_replace(p.phone_number, '\D', '', 'g') = $2, FALSE) AS phone_match,
+					(p.date_of_birth = $3::date OR ((p.dob_estimated OR $5) AND ABS(p.date_of_birth - $3::date) <= 366)) AS dob_match
+				FROM patients p` + whereClause(conds) + `
+			) d
+		) s
+		WHERE s.score >= ` + fmt.Sprint(DuplicateThreshold) + `
+		ORDER BY s.score DESC, s.id
+		LIMIT 10`
+
+	var rows []duplicateRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	candidates := make([]DuplicateCandidate, 0, len(rows))
+	for _, row := range rows {
+		var reasons []string
+		if row.NameSimilarity >= nameSimilarityThreshold || row.NamePhonetic {
+			reasons = append(reasons, "name")
+		}
+		if row.PhoneMatch {
+			reasons = append(reasons, "phone_number")
+		}
+		if row.DOBMatch {
+			reasons = append(reasons, "date_of_birth")
+		}
+		candidates = append(candidates, DuplicateCandidate{Patient: row.Patient, Score: row.Score, Reasons: reasons})
+	}
+	return candidates, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
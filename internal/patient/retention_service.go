@@ -0,0 +1,102 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// purgeBatchSize bounds the number of patients purged per run
+const purgeBatchSize = 100
+
+// FileStore deletes stored document files
+type FileStore interface {
+	DeleteFile(ctx context.Context, publicID string) error
+}
+
+// RetentionService permanently removes patients that have been in the trash
+// for longer than the retention period, together with their stored files
+type RetentionService interface {
+	PurgeExpired(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type retentionService struct {
+	repo      Repository
+	files     FileStore
+	retention time.Duration
+}
+
+// NewRetentionService creates a new retention service. A retention period of
+// zero or less disables purging.
+func NewRetentionService(r Repository, files FileStore, retention time.Duration) RetentionService {
+	return &retentionService{repo: r, files: files, retention: retention}
+}
+
+// PurgeExpired purges one batch of expired patients and returns how many were
+// purged. A patient's files are deleted before the record, so a failure
+// leaves the patient in the trash to be retried on the next run rather than
+// orphaning files. A failing patient does not hold up the rest of the batch;
+// the failures are returned joined.
+func (s *retentionService) PurgeExpired(ctx context.Context) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	ids, err := s.repo.ListExpired(ctx, s.retention, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for _, id := range ids {
+		if err := s.purge(ctx, id); err != nil {
+			log.Printf("WARN: Could not purge patient %d: %v", id, err)
+			errs = append(errs, fmt.Errorf("purge patient %d: %w", id, err))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+func (s *retentionService) purge(ctx context.Context, id int) error {
+	publicIDs, err := s.repo.DocumentPublicIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, publicID := range publicIDs {
+		if err := s.files.DeleteFile(ctx, publicID); err != nil {
+			return err
+		}
+	}
+	return s.repo.Purge(ctx, id)
+}
+
+// Run purges expired patients every interval until ctx is cancelled
+func (s *retentionService) Run(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 || interval <= 0 {
+		log.Println("Patient retention purge is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := s.PurgeExpired(ctx)
+		if err != nil {
+			log.Printf("WARN: Patient retention purge failed: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Purged %d patients past the retention period", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
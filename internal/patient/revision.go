@@ -0,0 +1,32 @@
+package patient
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag returns the entity tag of a patient revision, for example "7"
+func ETag(revision int) string {
+	return strconv.Quote(strconv.Itoa(revision))
+}
+
+// ParseIfMatch returns the revision named by an If-Match header. A missing
+// header or "*" matches any revision and yields zero. ok is false when the
+// header names no revision of a patient, such as a weak or malformed tag,
+// which never matches. Of several tags the first revision is used.
+func ParseIfMatch(header string) (revision int, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if n, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil && n > 0 {
+			return n, true
+		}
+	}
+	return 0, false
+}
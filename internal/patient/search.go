@@ -0,0 +1,181 @@
+package patient
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// Search match types, from strongest to weakest
+const (
+	MatchExact    = "exact"
+	MatchFuzzy    = "fuzzy"
+	MatchPhonetic = "phonetic"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+)
+
+// SearchOptions is a patient search. The query is matched against name,
+// phone number, MRN and address.
+type SearchOptions struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"`
+
+	// PanelOf restricts the search to a doctor's care teams. It is set by the
+	// service from the caller, never from the query string.
+	PanelOf int `form:"-"`
+}
+
+// SearchMatch names a field that matched a search and how. Highlight is the
+// HTML-escaped field value with the matching parts wrapped in <mark> tags.
+type SearchMatch struct {
+	Field     string `json:"field"`
+	Type      string `json:"type"`
+	Highlight string `json:"highlight"`
+}
+
+// SearchResult is a patient found by a search, with its relevance score
+// between 0 and 1 and the fields that matched
+type SearchResult struct {
+	Patient
+	Score   float64
+	Matches []SearchMatch
+}
+
+// SearchHit is the response for one search result. Patient is the view of
+// the patient the caller is allowed to see.
+type SearchHit struct {
+	Patient interface{}   `json:"patient"`
+	Score   float64       `json:"score"`
+	Matches []SearchMatch `json:"matches"`
+}
+
+// ProjectSearch returns the search results as the caller is allowed to see them
+func ProjectSearch(caller *middleware.Identity, results []SearchResult) []SearchHit {
+	hits := make([]SearchHit, 0, len(results))
+	for i := range results {
+		hits = append(hits, SearchHit{
+			Patient: Project(caller, &results[i].Patient),
+			Score:   results[i].Score,
+			Matches: results[i].Matches,
+		})
+	}
+	return hits
+}
+
+// Highlight marks the parts of value that match the search query. The whole
+// query is marked where it occurs verbatim; otherwise each word of value that
+// contains or closely resembles a query word is marked. Phone numbers are
+// compared digit by digit, ignoring formatting.
+func Highlight(field, value, query string) string {
+	if field == "phone_number" {
+		return highlightDigits(value, query)
+	}
+
+	lower, q := strings.ToLower(value), strings.ToLower(strings.TrimSpace(query))
+	if i := strings.Index(lower, q); q != "" && i >= 0 && len(lower) == len(value) {
+		return mark(value, [][2]int{{i, i + len(q)}})
+	}
+
+	terms := strings.Fields(q)
+	var spans [][2]int
+	for _, w := range words(value) {
+		word := strings.ToLower(value[w[0]:w[1]])
+		for _, t := range terms {
+			if strings.Contains(word, t) || levenshtein(word, t) <= 1+len(t)/5 {
+				spans = append(spans, w)
+				break
+			}
+		}
+	}
+	return mark(value, spans)
+}
+
+func highlightDigits(value, query string) string {
+	var digits []byte
+	var pos []int
+	for i := 0; i < len(value); i++ {
+		if value[i] >= '0' && value[i] <= '9' {
+			digits = append(digits, value[i])
+			pos = append(pos, i)
+		}
+	}
+	want := phoneDigits(query)
+	i := strings.Index(string(digits), want)
+	if want == "" || i < 0 {
+		return html.EscapeString(value)
+	}
+	return mark(value, [][2]int{{pos[i], pos[i+len(want)-1] + 1}})
+}
+
+// phoneDigits returns the digits of a phone number, dropping spaces,
+// punctuation and other formatting
+func phoneDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// words returns the byte ranges of the words in s
+func words(s string) [][2]int {
+	var out [][2]int
+	start := -1
+	for i, r := range s {
+		letter := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case letter && start < 0:
+			start = i
+		case !letter && start >= 0:
+			out = append(out, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, [2]int{start, len(s)})
+	}
+	return out
+}
+
+// mark escapes value and wraps the given ascending, non-overlapping byte ranges in <mark> tags
+func mark(value string, spans [][2]int) string {
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(html.EscapeString(value[last:s[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(value[s[0]:s[1]]))
+		b.WriteString("</mark>")
+		last = s[1]
+	}
+	b.WriteString(html.EscapeString(value[last:]))
+	return b.String()
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -1,76 +1,367 @@
 package patient
 
-import "context"
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
 
-// Service provides patient-related business logic
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+// ErrFilterForbidden is returned when a caller without clinical access
+// filters patients by diagnosis
+var ErrFilterForbidden = errors.New("not allowed to filter by diagnosis")
+
+// ErrProbableDuplicate is matched by a *DuplicateError
+var ErrProbableDuplicate = errors.New("patient probably already exists; confirm to create anyway")
+
+// DuplicateError lists the existing patients a new patient resembles
+type DuplicateError struct {
+	Candidates []DuplicateCandidate
+}
+
+func (e *DuplicateError) Error() string { return ErrProbableDuplicate.Error() }
+
+func (e *DuplicateError) Is(target error) bool { return target == ErrProbableDuplicate }
+
+// RevisionError is returned instead of ErrRevisionMismatch with the current
+// state of the patient, so the caller can reapply its change
+type RevisionError struct {
+	Current *Patient
+}
+
+func (e *RevisionError) Error() string { return ErrRevisionMismatch.Error() }
+
+func (e *RevisionError) Is(target error) bool { return target == ErrRevisionMismatch }
+
+// Service provides patient-related business logic. Doctors may only access
+// patients on their care teams; callers with the patient:access:all
+// permission may access every patient.
 type Service interface {
-	CreatePatient(ctx context.Context, req CreatePatientRequest) (*Patient, error)
-	GetPatient(ctx context.Context, id int) (*Patient, error)
-	ListAllPatients(ctx context.Context) ([]Patient, error)
-	UpdatePatient(ctx context.Context, id int, req UpdatePatientRequest) (*Patient, error)
-	UpdatePatientMedical(ctx context.Context, id int, req UpdatePatientMedicalRequest) (*Patient, error)
-	DeletePatient(ctx context.Context, id int) error
-	SearchPatients(ctx context.Context, name string) ([]Patient, error)
+	CreatePatient(ctx context.Context, caller *middleware.Identity, req CreatePatientRequest) (*Patient, error)
+	GetPatient(ctx context.Context, caller *middleware.Identity, id int) (*Patient, error)
+	GetPatientByMRN(ctx context.Context, caller *middleware.Identity, mrn string) (*Patient, error)
+	ListPatients(ctx context.Context, caller *middleware.Identity, opts ListOptions) (*pagination.Page[Patient], error)
+	UpdatePatient(ctx context.Context, caller *middleware.Identity, id int, req UpdatePatientRequest) (*Patient, error)
+	UpdatePatientMedical(ctx context.Context, caller *middleware.Identity, id int, req UpdatePatientMedicalRequest) (*Patient, error)
+	PatchPatient(ctx context.Context, caller *middleware.Identity, id int, req PatchRequest) (*Patient, error)
+	DeletePatient(ctx context.Context, caller *middleware.Identity, id int) error
+	RestorePatient(ctx context.Context, caller *middleware.Identity, id int) (*Patient, error)
+	ListDeletedPatients(ctx context.Context, caller *middleware.Identity, opts ListOptions) (*pagination.Page[Patient], error)
+	SearchPatients(ctx context.Context, caller *middleware.Identity, opts SearchOptions) ([]SearchResult, error)
 }
 
 type service struct {
-	repo Repository
+	repo   Repository
+	access careteam.AccessChecker
 }
 
 // NewService creates a new patient service
-func NewService(r Repository) Service {
-	return &service{repo: r}
+func NewService(r Repository, access careteam.AccessChecker) Service {
+	return &service{repo: r, access: access}
 }
 
-func (s *service) CreatePatient(ctx context.Context, req CreatePatientRequest) (*Patient, error) {
+// CreatePatient registers a new patient. Unless the request confirms it, a
+// patient resembling existing patients is not created and a *DuplicateError
+// listing them is returned instead.
+func (s *service) CreatePatient(ctx context.Context, caller *middleware.Identity, req CreatePatientRequest) (*Patient, error) {
+	dob, err := ParseDateOfBirth(req.DateOfBirth, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	by := actorOf(caller)
 	p := &Patient{
-		Name:        req.Name,
-		Age:         req.Age,
-		Address:     req.Address,
-		PhoneNumber: req.PhoneNumber,
+		Name:            req.Name,
+		DateOfBirth:     dob,
+		DOBEstimated:    req.DOBEstimated,
+		Address:         req.Address,
+		PhoneNumber:     req.PhoneNumber,
+		Details:         normalizeDetails(req.Details),
+		UpdatedBy:       by.UserID,
+		UpdatedByAPIKey: by.APIKeyID,
 	}
-	err := s.repo.Create(ctx, p)
-	if err != nil {
+
+	if !req.ConfirmDuplicate {
+		candidates, err := findDuplicates(ctx, s.repo, caller, p)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) > 0 {
+			return nil, &DuplicateError{Candidates: candidates}
+		}
+	}
+
+	if err := s.repo.Create(ctx, p); err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
-func (s *service) GetPatient(ctx context.Context, id int) (*Patient, error) {
+// findDuplicates compares a new patient with every patient for callers with
+// broad access and with the caller's own panel for everyone else
+func findDuplicates(ctx context.Context, repo Repository, caller *middleware.Identity, p *Patient) ([]DuplicateCandidate, error) {
+	if careteam.CanAccessAll(caller) {
+		return repo.FindDuplicates(ctx, p, 0)
+	}
+	if caller == nil || caller.UserID == 0 {
+		return nil, nil
+	}
+	return repo.FindDuplicates(ctx, p, caller.UserID)
+}
+
+func (s *service) GetPatient(ctx context.Context, caller *middleware.Identity, id int) (*Patient, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		return nil, err
+	}
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *service) ListAllPatients(ctx context.Context) ([]Patient, error) {
-	return s.repo.GetAll(ctx)
+// GetPatientByMRN looks a patient up by medical record number, ignoring case
+func (s *service) GetPatientByMRN(ctx context.Context, caller *middleware.Identity, mrn string) (*Patient, error) {
+	p, err := s.repo.GetByMRN(ctx, NormalizeMRN(mrn))
+	if err != nil {
+		return nil, err
+	}
+	// Callers who may not see the patient cannot tell that the MRN exists
+	if err := s.access.CheckPatientAccess(ctx, caller, p.ID); err != nil {
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			return nil, ErrPatientNotFound
+		}
+		return nil, err
+	}
+	return p, nil
 }
 
-func (s *service) UpdatePatient(ctx context.Context, id int, req UpdatePatientRequest) (*Patient, error) {
-	p := &Patient{
-		ID:          id,
-		Name:        req.Name,
-		Age:         req.Age,
-		Address:     req.Address,
-		PhoneNumber: req.PhoneNumber,
+// ListPatients returns a page of every patient to callers with broad access
+// and a page of the caller's own panel to everyone else
+func (s *service) ListPatients(ctx context.Context, caller *middleware.Identity, opts ListOptions) (*pagination.Page[Patient], error) {
+	opts.Deleted = false
+	return s.list(ctx, caller, opts)
+}
+
+// ListDeletedPatients returns a page of the trash, restricted like ListPatients
+func (s *service) ListDeletedPatients(ctx context.Context, caller *middleware.Identity, opts ListOptions) (*pagination.Page[Patient], error) {
+	opts.Deleted = true
+	return s.list(ctx, caller, opts)
+}
+
+func (s *service) list(ctx context.Context, caller *middleware.Identity, opts ListOptions) (*pagination.Page[Patient], error) {
+	if opts.HasDiagnosis != nil && !HasClinicalAccess(caller) {
+		return nil, ErrFilterForbidden
+	}
+	opts.PanelOf = 0
+	if !careteam.CanAccessAll(caller) {
+		if caller == nil || caller.UserID == 0 {
+			return &pagination.Page[Patient]{Items: []Patient{}}, nil
+		}
+		opts.PanelOf = caller.UserID
 	}
-	err := s.repo.Update(ctx, p)
+	return s.repo.List(ctx, opts)
+}
+
+func (s *service) UpdatePatient(ctx context.Context, caller *middleware.Identity, id int, req UpdatePatientRequest) (*Patient, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		return nil, err
+	}
+	dob, err := ParseDateOfBirth(req.DateOfBirth, time.Now())
 	if err != nil {
 		return nil, err
 	}
+	by := actorOf(caller)
+	p := &Patient{
+		ID:              id,
+		Name:            req.Name,
+		DateOfBirth:     dob,
+		DOBEstimated:    req.DOBEstimated,
+		Address:         req.Address,
+		PhoneNumber:     req.PhoneNumber,
+		Details:         normalizeDetails(req.Details),
+		UpdatedBy:       by.UserID,
+		UpdatedByAPIKey: by.APIKeyID,
+		Revision:        req.IfRevision,
+	}
+	err = s.repo.Update(ctx, p)
+	if err != nil {
+		return nil, s.revisionError(ctx, id, err)
+	}
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *service) UpdatePatientMedical(ctx context.Context, id int, req UpdatePatientMedicalRequest) (*Patient, error) {
-	err := s.repo.UpdateMedical(ctx, id, req.Diagnosis, req.Notes)
+func (s *service) UpdatePatientMedical(ctx context.Context, caller *middleware.Identity, id int, req UpdatePatientMedicalRequest) (*Patient, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		return nil, err
+	}
+	err := s.repo.UpdateMedical(ctx, id, req.Diagnosis, req.Notes, actorOf(caller), req.IfRevision)
 	if err != nil {
+		return nil, s.revisionError(ctx, id, err)
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// patchAttempts bounds how often a patch without If-Match is reapplied when
+// the patient changes between reading and writing it
+const patchAttempts = 3
+
+// PatchPatient applies a merge patch or JSON Patch to a patient. Changing
+// demographics needs patient:update and changing diagnosis or notes needs
+// patient:medical:write; callers without clinical access cannot see or patch
+// those fields at all. The patched patient is validated like a full update
+// and saved in one conditional write, so concurrent changes are never lost.
+func (s *service) PatchPatient(ctx context.Context, caller *middleware.Identity, id int, req PatchRequest) (*Patient, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if req.IfRevision != 0 && req.IfRevision != current.Revision {
+			return nil, &RevisionError{Current: current}
+		}
+		p, err := patchPatient(caller, current, req)
+		if err != nil {
+			return nil, err
+		}
+		err = s.repo.Patch(ctx, p)
+		if errors.Is(err, ErrRevisionMismatch) && req.IfRevision == 0 && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, s.revisionError(ctx, id, err)
+		}
+		return s.repo.GetByID(ctx, id)
+	}
+}
+
+// revisionError attaches the current state of the patient to an
+// ErrRevisionMismatch from a conditional update
+func (s *service) revisionError(ctx context.Context, id int, err error) error {
+	if !errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
+	current, getErr := s.repo.GetByID(ctx, id)
+	if getErr != nil {
+		return getErr
+	}
+	return &RevisionError{Current: current}
+}
+
+// DeletePatient moves a patient to the trash, recording who deleted them
+func (s *service) DeletePatient(ctx context.Context, caller *middleware.Identity, id int) error {
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id, actorOf(caller))
+}
+
+// RestorePatient takes a patient out of the trash
+func (s *service) RestorePatient(ctx context.Context, caller *middleware.Identity, id int) (*Patient, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Restore(ctx, id, actorOf(caller)); err != nil {
 		return nil, err
 	}
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *service) DeletePatient(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, id)
+// SearchPatients runs a ranked search over every patient for callers with
+// broad access and over the caller's own panel for everyone else, and
+// highlights the matching part of each matched field
+func (s *service) SearchPatients(ctx context.Context, caller *middleware.Identity, opts SearchOptions) ([]SearchResult, error) {
+	opts.PanelOf = 0
+	if !careteam.CanAccessAll(caller) {
+		if caller == nil || caller.UserID == 0 {
+			return []SearchResult{}, nil
+		}
+		opts.PanelOf = caller.UserID
+	}
+
+	results, err := s.repo.Search(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		p := &results[i].Patient
+		for j := range results[i].Matches {
+			m := &results[i].Matches[j]
+			m.Highlight = Highlight(m.Field, fieldValue(p, m.Field), opts.Query)
+		}
+	}
+	return results, nil
+}
+
+// actorID returns the user to record as the author of a change, or nil for
+// API keys
+func actorID(caller *middleware.Identity) *int {
+	if caller == nil || caller.UserID == 0 {
+		return nil
+	}
+	id := caller.UserID
+	return &id
+}
+
+// Actor is the author of a change to a patient: a user, or the API key of a
+// caller without one
+type Actor struct {
+	UserID   *int
+	APIKeyID *int
+}
+
+// actorOf returns the author to record for a change made by caller
+func actorOf(caller *middleware.Identity) Actor {
+	a := Actor{UserID: actorID(caller)}
+	if caller != nil && caller.APIKeyID != 0 {
+		id := caller.APIKeyID
+		a.APIKeyID = &id
+	}
+	return a
+}
+
+// normalizeDetails trims the optional demographics, clears blank values and
+// lowercases the email address
+func normalizeDetails(d Details) Details {
+	for _, f := range []**string{&d.Sex, &d.Gender, &d.Email, &d.PreferredLanguage, &d.City, &d.Region, &d.Postcode, &d.Country, &d.MaritalStatus, &d.Occupation} {
+		*f = trimmed(*f)
+	}
+	if d.Email != nil {
+		email := strings.ToLower(*d.Email)
+		d.Email = &email
+	}
+	return d
 }
 
-func (s *service) SearchPatients(ctx context.Context, name string) ([]Patient, error) {
-	return s.repo.SearchByName(ctx, name)
+// trimmed returns s without surrounding whitespace, or nil if nothing is left
+func trimmed(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	t := strings.TrimSpace(*s)
+	if t == "" {
+		return nil
+	}
+	return &t
+}
+
+// fieldValue returns the value of a searchable patient field
+func fieldValue(p *Patient, field string) string {
+	switch field {
+	case "mrn":
+		if p.MRN != nil {
+			return *p.MRN
+		}
+	case "phone_number":
+		if p.PhoneNumber != nil {
+			return *p.PhoneNumber
+		}
+	case "name":
+		return p.Name
+	case "address":
+		return p.Address
+	}
+	return ""
 }
@@ -0,0 +1,134 @@
+package patient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+// VersionHandler holds the dependencies for the patient history handlers
+type VersionHandler struct {
+	service VersionService
+}
+
+// NewVersionHandler creates a new patient history handler
+func NewVersionHandler(s VersionService) *VersionHandler {
+	return &VersionHandler{service: s}
+}
+
+// ListVersions godoc
+// @Summary      List a patient's versions
+// @Description  Retrieves every version of the patient record, newest first: who changed it, when, and the old and new value of each changed field. Diagnosis and notes changes are only included for callers with patient:clinical:read; versions that changed nothing else are left out for everyone else.
+// @Tags         Patient History
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Patient ID"
+// @Success      200  {array}   Version
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Patient not found"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/versions [get]
+func (h *VersionHandler) ListVersions(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	versions, err := h.service.ListVersions(c.Request.Context(), caller, patientID)
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// DiffVersions godoc
+// @Summary      Compare two versions of a patient
+// @Description  Lists the fields that differ between two versions of the patient record with their values in each.
+// @Tags         Patient History
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      int  true  "Patient ID"
+// @Param        from  query     int  true  "Older version"
+// @Param        to    query     int  true  "Newer version"
+// @Success      200   {object}  Diff
+// @Failure      400   {object}  ErrorResponse "Invalid patient ID or version"
+// @Failure      403   {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404   {object}  ErrorResponse "Version not found"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/versions/diff [get]
+func (h *VersionHandler) DiffVersions(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'from' and 'to' must be version numbers"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	diff, err := h.service.DiffVersions(c.Request.Context(), caller, patientID, from, to)
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetPatientAsOf godoc
+// @Summary      View a patient as of a point in time
+// @Description  Retrieves the patient record as it was at the given time, projected for the caller like GET /patients/{id}.
+// @Tags         Patient History
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      int     true  "Patient ID"
+// @Param        at   query     string  true  "RFC 3339 timestamp, e.g. 2026-01-31T17:00:00Z"
+// @Success      200  {object}  ClinicalRecord "Diagnosis and notes are only included for callers with patient:clinical:read; everyone else gets the Demographics view"
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID or timestamp"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
+// @Failure      404  {object}  ErrorResponse "Patient did not exist at that time"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/as-of [get]
+func (h *VersionHandler) GetPatientAsOf(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'at' must be an RFC 3339 timestamp"})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	patient, err := h.service.GetPatientAsOf(c.Request.Context(), caller, patientID, at)
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, Project(caller, patient))
+}
+
+func writeVersionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, careteam.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound), errors.Is(err, ErrVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,77 @@
+package patient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// VersionRepository reads the version history of patients. Versions are
+// written by a database trigger on every insert and update of a patient.
+type VersionRepository interface {
+	ListVersions(ctx context.Context, patientID int) ([]Version, error)
+	GetVersion(ctx context.Context, patientID, version int) (*Version, error)
+	GetAsOf(ctx context.Context, patientID int, at time.Time) (*Patient, error)
+}
+
+type postgresVersionRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresVersionRepository creates a new repository for patient versions
+func NewPostgresVersionRepository(db *sqlx.DB) VersionRepository {
+	return &postgresVersionRepository{db: db}
+}
+
+const versionColumns = `patient_id, version, operation, changed_by, changed_by_api_key, changed_at, changes, snapshot`
+
+// ListVersions retrieves a patient's versions, newest first. It returns
+// ErrPatientNotFound for patients without history, i.e. that do not exist.
+func (r *postgresVersionRepository) ListVersions(ctx context.Context, patientID int) ([]Version, error) {
+	versions := []Version{}
+	query := `SELECT ` + versionColumns + ` FROM patient_versions WHERE patient_id = $1 ORDER BY version DESC`
+	if err := r.db.SelectContext(ctx, &versions, query, patientID); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrPatientNotFound
+	}
+	return versions, nil
+}
+
+// GetVersion retrieves one version of a patient
+func (r *postgresVersionRepository) GetVersion(ctx context.Context, patientID, version int) (*Version, error) {
+	var v Version
+	query := `SELECT ` + versionColumns + ` FROM patient_versions WHERE patient_id = $1 AND version = $2`
+	if err := r.db.GetContext(ctx, &v, query, patientID, version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetAsOf rebuilds a patient from the last version written at or before at.
+// Columns added after that version was written are empty, except the
+// revision: snapshots taken before patients had one get the version number,
+// which is what their revision was set to.
+func (r *postgresVersionRepository) GetAsOf(ctx context.Context, patientID int, at time.Time) (*Patient, error) {
+	var p Patient
+	query := `SELECT ` + patientColumns + `
+		FROM (
+			SELECT version, snapshot FROM patient_versions
+			WHERE patient_id = $1 AND changed_at <= $2
+			ORDER BY version DESC LIMIT 1
+		) v, jsonb_populate_record(NULL::patients, jsonb_build_object('revision', v.version) || v.snapshot) p`
+	if err := r.db.GetContext(ctx, &p, query, patientID, at); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,140 @@
+package patient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+var ErrVersionNotFound = errors.New("patient version not found")
+
+// clinicalFields are left out of the history shown to callers without
+// clinical access
+var clinicalFields = []string{"diagnosis", "notes"}
+
+// untrackedFields change with every write and are left out of diffs
+var untrackedFields = map[string]bool{"updated_at": true, "updated_by": true, "updated_by_api_key": true, "revision": true}
+
+// VersionService gives access to the version history of patients
+type VersionService interface {
+	ListVersions(ctx context.Context, caller *middleware.Identity, patientID int) ([]Version, error)
+	DiffVersions(ctx context.Context, caller *middleware.Identity, patientID, from, to int) (*Diff, error)
+	GetPatientAsOf(ctx context.Context, caller *middleware.Identity, patientID int, at time.Time) (*Patient, error)
+}
+
+type versionService struct {
+	repo   VersionRepository
+	access careteam.AccessChecker
+}
+
+// NewVersionService creates a new patient version service
+func NewVersionService(r VersionRepository, access careteam.AccessChecker) VersionService {
+	return &versionService{repo: r, access: access}
+}
+
+// ListVersions returns a patient's versions, newest first. Diagnosis and notes
+// changes are only included for callers with clinical access; versions that
+// changed nothing else are left out for everyone else, so their existence does
+// not give the change away.
+func (s *versionService) ListVersions(ctx context.Context, caller *middleware.Identity, patientID int) ([]Version, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.ListVersions(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if HasClinicalAccess(caller) {
+		return versions, nil
+	}
+	visible := versions[:0]
+	for _, v := range versions {
+		changes := v.Changes.without(clinicalFields...)
+		if len(changes) == 0 && len(v.Changes) > 0 {
+			continue
+		}
+		v.Changes = changes
+		visible = append(visible, v)
+	}
+	return visible, nil
+}
+
+// DiffVersions compares two versions of a patient field by field
+func (s *versionService) DiffVersions(ctx context.Context, caller *middleware.Identity, patientID, from, to int) (*Diff, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	a, err := s.repo.GetVersion(ctx, patientID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.repo.GetVersion(ctx, patientID, to)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffSnapshots(a.Snapshot, b.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if !HasClinicalAccess(caller) {
+		changes = changes.without(clinicalFields...)
+	}
+	return &Diff{PatientID: patientID, From: from, To: to, Changes: changes}, nil
+}
+
+// GetPatientAsOf returns the patient as it was at the given time
+func (s *versionService) GetPatientAsOf(ctx context.Context, caller *middleware.Identity, patientID int, at time.Time) (*Patient, error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAsOf(ctx, patientID, at)
+}
+
+// diffSnapshots returns the fields whose values differ between two snapshots
+func diffSnapshots(from, to json.RawMessage) (Changes, error) {
+	var a, b map[string]json.RawMessage
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, err
+	}
+	changes := Changes{}
+	for _, fields := range []map[string]json.RawMessage{a, b} {
+		for field := range fields {
+			if untrackedFields[field] {
+				continue
+			}
+			old, new := valueOrNull(a[field]), valueOrNull(b[field])
+			if !bytes.Equal(old, new) {
+				changes[field] = FieldChange{Old: old, New: new}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// valueOrNull returns v, or JSON null for a field missing from a snapshot
+func valueOrNull(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+// without returns the changes without the given fields
+func (c Changes) without(fields ...string) Changes {
+	out := make(Changes, len(c))
+	for field, change := range c {
+		out[field] = change
+	}
+	for _, field := range fields {
+		delete(out, field)
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package portal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+// Handler holds the dependencies for the patient portal handlers
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new patient portal handler
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Invite godoc
+// @Summary      Invite a patient to the portal
+// @Description  Issues a single-use activation link and sends it to the patient's email address. Earlier unused invitations for the patient stop working. The token is never returned in the response.
+// @Tags         Patient Portal
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      int            true  "Patient ID"
+// @Param        body  body      InviteRequest  true  "Where to send the invitation"
+// @Success      201   {object}  InvitationResponse
+// @Failure      400   {object}  ErrorResponse "Invalid request body or ID"
+// @Failure      403   {object}  ErrorResponse "Forbidden"
+// @Failure      404   {object}  ErrorResponse "Patient not found"
+// @Failure      409   {object}  ErrorResponse "Patient already has a portal account"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /patients/{id}/portal-invitation [post]
+func (h *Handler) Invite(c *gin.Context) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	var req InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	resp, err := h.service.Invite(c.Request.Context(), c.GetInt(middleware.ContextKeyUserID), patientID, req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Activate godoc
+// @Summary      Activate a patient portal account
+// @Description  Redeems an invitation and creates the patient's portal account with the chosen username and password. The patient then signs in through /login.
+// @Tags         Patient Portal
+// @Accept       json
+// @Produce      json
+// @Param        body  body      ActivateRequest  true  "Invitation token and credentials"
+// @Success      201   {object}  auth.User
+// @Failure      400   {object}  ErrorResponse "Invalid request body or invitation"
+// @Failure      409   {object}  ErrorResponse "Username taken or account already exists"
+// @Failure      500   {object}  ErrorResponse "Internal server error"
+// @Router       /portal/activate [post]
+func (h *Handler) Activate(c *gin.Context) {
+	var req ActivateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.Activate(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetProfile godoc
+// @Summary      Get my patient record
+// @Description  Retrieves the demographics of the patient linked to the signed-in portal account. Clinical notes are never included.
+// @Tags         Patient Portal
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  Profile
+// @Failure      403  {object}  ErrorResponse "Not a patient portal account"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /portal/me [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	caller, _ := middleware.GetIdentity(c)
+	profile, err := h.service.GetProfile(c.Request.Context(), caller)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// ListPrescriptions godoc
+// @Summary      List my active prescriptions
+// @Description  Retrieves the signed-in patient's prescriptions that have not ended.
+// @Tags         Patient Portal
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   prescription.Prescription
+// @Failure      403  {object}  ErrorResponse "Not a patient portal account"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /portal/me/prescriptions [get]
+func (h *Handler) ListPrescriptions(c *gin.Context) {
+	caller, _ := middleware.GetIdentity(c)
+	prescriptions, err := h.service.ListPrescriptions(c.Request.Context(), caller)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prescriptions)
+}
+
+// ListDocuments godoc
+// @Summary      List my documents
+// @Description  Retrieves a page of the documents on file for the signed-in patient.
+// @Tags         Patient Portal
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        sort    query     string  false  "Sort key: file_name or uploaded_at; prefix with - for descending" default(-uploaded_at)
+// @Param        limit   query     int     false  "Page size (1-200)" default(50)
+// @Param        cursor  query     string  false  "next_cursor of the previous page"
+// @Success      200  {object}  pagination.Page[document.Document]
+// @Failure      400  {object}  ErrorResponse "Invalid sort key or cursor"
+// @Failure      403  {object}  ErrorResponse "Not a patient portal account"
+// @Failure      500  {object}  ErrorResponse "Internal server error"
+// @Router       /portal/me/documents [get]
+func (h *Handler) ListDocuments(c *gin.Context) {
+	var req pagination.Request
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	page, err := h.service.ListDocuments(c.Request.Context(), caller, req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotAPatient):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPatientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidInvitation), errors.Is(err, pagination.ErrInvalidCursor), errors.Is(err, pagination.ErrInvalidSort):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAccountExists), errors.Is(err, auth.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
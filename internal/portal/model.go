@@ -0,0 +1,37 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+// Profile is the part of a patient record shown to the patient themselves.
+// Clinical fields such as the diagnosis and doctors' notes are left out.
+type Profile struct {
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	DateOfBirth string      `json:"date_of_birth"`
+	Age         patient.Age `json:"age"`
+	Address     string      `json:"address"`
+	PhoneNumber *string     `json:"phone_number,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// InviteRequest invites a patient to the portal at the given email address
+type InviteRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+}
+
+// InvitationResponse describes an invitation that was issued and delivered
+type InvitationResponse struct {
+	PatientID int       `json:"patient_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ActivateRequest redeems an invitation and chooses the account's credentials
+type ActivateRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Password string `json:"password" binding:"required,min=8"`
+}
@@ -0,0 +1,102 @@
+package portal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/lib/pq"
+)
+
+// Repository defines the interface for patient portal account storage
+type Repository interface {
+	HasAccount(ctx context.Context, patientID int) (bool, error)
+	CreateInvitation(ctx context.Context, patientID, createdBy int, email, tokenHash string, ttl time.Duration) (time.Time, error)
+	ActivateAccount(ctx context.Context, tokenHash string, u *auth.User) error
+}
+
+type postgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository creates a new repository for patient portal accounts
+func NewPostgresRepository(db *sqlx.DB) Repository {
+	return &postgresRepository{db: db}
+}
+
+// HasAccount reports whether a portal account exists for the patient
+func (r *postgresRepository) HasAccount(ctx context.Context, patientID int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM users WHERE patient_id = $1)`, patientID)
+	return exists, err
+}
+
+// CreateInvitation stores an invitation hash, replacing any unused invitations
+// previously issued for the patient, and returns its expiry
+func (r *postgresRepository) CreateInvitation(ctx context.Context, patientID, createdBy int, email, tokenHash string, ttl time.Duration) (time.Time, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM patient_invitations WHERE patient_id = $1 AND used_at IS NULL`, patientID); err != nil {
+		return time.Time{}, err
+	}
+
+	var expiresAt time.Time
+	query := `INSERT INTO patient_invitations (patient_id, email, token_hash, created_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW() + $5 * INTERVAL '1 second', NOW()) RETURNING expires_at`
+	if err := tx.QueryRowContext(ctx, query, patientID, email, tokenHash, createdBy, int64(ttl.Seconds())).Scan(&expiresAt); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "fk_patient" {
+			return time.Time{}, ErrPatientNotFound
+		}
+		return time.Time{}, err
+	}
+	return expiresAt, tx.Commit()
+}
+
+// ActivateAccount redeems an invitation and creates the patient's portal
+// account in one transaction, so an invitation yields at most one account.
+// The account's patient and email come from the invitation.
+func (r *postgresRepository) ActivateAccount(ctx context.Context, tokenHash string, u *auth.User) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var patientID int
+	var email string
+	query := `UPDATE patient_invitations SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING patient_id, email`
+	if err := tx.QueryRowContext(ctx, query, tokenHash).Scan(&patientID, &email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidInvitation
+		}
+		return err
+	}
+
+	u.PatientID = &patientID
+	u.Email = &email
+	query = `INSERT INTO users (username, email, password_hash, role, is_active, must_change_password, patient_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, u.Username, u.Email, u.PasswordHash, u.Role, u.IsActive, u.MustChangePassword, u.PatientID).
+		Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			if pqErr.Constraint == "users_patient_id_key" {
+				return ErrAccountExists
+			}
+			return auth.ErrUsernameTaken
+		}
+		return err
+	}
+	return tx.Commit()
+}
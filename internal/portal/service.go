@@ -0,0 +1,192 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/document"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/notify"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+	"github.com/kyash99252/Medical-Portal/internal/prescription"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+var (
+	ErrNotAPatient       = errors.New("only patient portal accounts can use this endpoint")
+	ErrPatientNotFound   = errors.New("patient not found")
+	ErrAccountExists     = errors.New("patient already has a portal account")
+	ErrInvalidInvitation = errors.New("invitation is invalid, expired or already used")
+)
+
+// Options configures invitation delivery
+type Options struct {
+	// InviteURL is the frontend page that accepts an invitation as its "token" query parameter
+	InviteURL string
+	InviteTTL time.Duration
+}
+
+// PasswordHasher hashes the password chosen when an account is activated
+type PasswordHasher interface {
+	HashPassword(password string) (string, error)
+}
+
+// Service provides the patient self-service portal. Every read is scoped to
+// the patient record linked to the caller's account; there is no way to name
+// another patient.
+type Service interface {
+	Invite(ctx context.Context, actorID, patientID int, req InviteRequest) (*InvitationResponse, error)
+	Activate(ctx context.Context, req ActivateRequest) (*auth.User, error)
+	GetProfile(ctx context.Context, caller *middleware.Identity) (*Profile, error)
+	ListPrescriptions(ctx context.Context, caller *middleware.Identity) ([]prescription.Prescription, error)
+	ListDocuments(ctx context.Context, caller *middleware.Identity, req pagination.Request) (*pagination.Page[document.Document], error)
+}
+
+type service struct {
+	repo          Repository
+	patients      patient.Repository
+	prescriptions prescription.Repository
+	documents     document.Repository
+	hasher        PasswordHasher
+	sender        notify.Sender
+	opts          Options
+}
+
+// NewService creates a new patient portal service
+func NewService(r Repository, patients patient.Repository, prescriptions prescription.Repository, documents document.Repository, hasher PasswordHasher, sender notify.Sender, opts Options) Service {
+	return &service{
+		repo:          r,
+		patients:      patients,
+		prescriptions: prescriptions,
+		documents:     documents,
+		hasher:        hasher,
+		sender:        sender,
+		opts:          opts,
+	}
+}
+
+// Invite issues a single-use invitation for a patient without a portal
+// account and delivers the activation link to the given email address. The
+// token itself is never returned to the staff member.
+func (s *service) Invite(ctx context.Context, actorID, patientID int, req InviteRequest) (*InvitationResponse, error) {
+	p, err := s.patients.GetByID(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, patient.ErrPatientNotFound) {
+			return nil, ErrPatientNotFound
+		}
+		return nil, err
+	}
+	exists, err := s.repo.HasAccount(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAccountExists
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := s.repo.CreateInvitation(ctx, patientID, actorID, req.Email, auth.HashOpaqueToken(token), s.opts.InviteTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sender.Send(ctx, s.inviteMessage(p, req.Email, token)); err != nil {
+		return nil, fmt.Errorf("failed to deliver invitation: %w", err)
+	}
+
+	return &InvitationResponse{PatientID: patientID, ExpiresAt: expiresAt}, nil
+}
+
+// Activate redeems an invitation and creates the patient's portal account
+func (s *service) Activate(ctx context.Context, req ActivateRequest) (*auth.User, error) {
+	hash, err := s.hasher.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &auth.User{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         auth.RolePatient,
+		IsActive:     true,
+	}
+	if err := s.repo.ActivateAccount(ctx, auth.HashOpaqueToken(req.Token), u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *service) GetProfile(ctx context.Context, caller *middleware.Identity) (*Profile, error) {
+	patientID, err := ownPatientID(caller)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.patients.GetByID(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, patient.ErrPatientNotFound) {
+			return nil, ErrPatientNotFound
+		}
+		return nil, err
+	}
+	return &Profile{
+		ID:          p.ID,
+		Name:        p.Name,
+		DateOfBirth: p.DateOfBirth.Format(patient.DateLayout),
+		Age:         patient.AgeAt(p.DateOfBirth, time.Now()),
+		Address:     p.Address,
+		PhoneNumber: p.PhoneNumber,
+		CreatedAt:   p.CreatedAt,
+	}, nil
+}
+
+// ListPrescriptions returns the caller's prescriptions that have not ended
+func (s *service) ListPrescriptions(ctx context.Context, caller *middleware.Identity) ([]prescription.Prescription, error) {
+	patientID, err := ownPatientID(caller)
+	if err != nil {
+		return nil, err
+	}
+	return s.prescriptions.GetActiveByPatientID(ctx, patientID)
+}
+
+// ListDocuments returns a page of the documents on file for the caller
+func (s *service) ListDocuments(ctx context.Context, caller *middleware.Identity, req pagination.Request) (*pagination.Page[document.Document], error) {
+	patientID, err := ownPatientID(caller)
+	if err != nil {
+		return nil, err
+	}
+	return s.documents.GetByPatientID(ctx, patientID, req)
+}
+
+// ownPatientID returns the patient record linked to the caller's account
+func ownPatientID(caller *middleware.Identity) (int, error) {
+	if caller == nil || caller.PatientID == 0 {
+		return 0, ErrNotAPatient
+	}
+	return caller.PatientID, nil
+}
+
+func (s *service) inviteMessage(p *patient.Patient, email, token string) notify.Message {
+	link := s.opts.InviteURL + "?token=" + token
+	if u, err := url.Parse(s.opts.InviteURL); err == nil {
+		q := u.Query()
+		q.Set("token", token)
+		u.RawQuery = q.Encode()
+		link = u.String()
+	}
+
+	return notify.Message{
+		To:      email,
+		Subject: "Your Medical Portal patient account",
+		Body: fmt.Sprintf("Hello %s,\n\nYour clinic has invited you to the patient portal, where you can see your details, "+
+			"current prescriptions and documents. Use the link below to choose a username and password. "+
+			"It can be used once and expires in %s.\n\n%s\n",
+			p.Name, s.opts.InviteTTL, link),
+	}
+}
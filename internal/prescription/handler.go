@@ -1,11 +1,14 @@
 package prescription
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
 	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
 // Handler holds dependencies for the prescription handlers
@@ -33,7 +36,8 @@ type ErrorResponse struct {
 // @Param        prescription body CreateRequest true "Prescription details"
 // @Success      201 {object} Prescription
 // @Failure      400 {object} ErrorResponse "Bad request due to invalid patient ID or request body"
-// @Failure      403 {object} ErrorResponse "Forbidden if user is not a doctor or token is invalid"
+// @Failure      403 {object} ErrorResponse "Forbidden if user is not a doctor on the patient's care team, the caller is an API key or token is invalid"
+// @Failure      404 {object} ErrorResponse "Patient not found"
 // @Failure      500 {object} ErrorResponse "Internal server error"
 // @Router       /patients/{id}/prescriptions [post]
 func (h *Handler) CreatePrescription(c *gin.Context) {
@@ -43,26 +47,28 @@ func (h *Handler) CreatePrescription(c *gin.Context) {
 		return
 	}
 
-	doctorIDVal, exists := c.Get(middleware.ContextKeyUserID)
+	caller, exists := middleware.GetIdentity(c)
 	if !exists {
 		c.JSON(http.StatusForbidden, gin.H{"error": "User ID not found in token"})
 		return
 	}
 
-	doctorID, ok := doctorIDVal.(int)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID in context is not of expected type"})
-		return
-	}
-
 	var req CreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
 		return
 	}
 
-	prescription, err := h.service.CreatePrescription(c.Request.Context(), patientID, doctorID, req)
+	prescription, err := h.service.CreatePrescription(c.Request.Context(), caller, patientID, req)
 	if err != nil {
+		if errors.Is(err, careteam.ErrAccessDenied) || errors.Is(err, ErrNoPrescriber) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prescription: " + err.Error()})
 		return
 	}
@@ -72,13 +78,17 @@ func (h *Handler) CreatePrescription(c *gin.Context) {
 
 // GetPatientPrescriptions godoc
 // @Summary      List prescriptions for a patient
-// @Description  Retrieves a list of all prescriptions for a specific patient.
+// @Description  Retrieves a page of the prescriptions for a specific patient. Pass the returned next_cursor as cursor to fetch the following page.
 // @Tags         Prescriptions
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        id   path      int  true  "Patient ID"
-// @Success      200  {array}   Prescription
-// @Failure      400  {object}  ErrorResponse "Invalid patient ID"
+// @Param        id      path      int     true   "Patient ID"
+// @Param        sort    query     string  false  "Sort key: medication or created_at; prefix with - for descending" default(-created_at)
+// @Param        limit   query     int     false  "Page size (1-200)" default(50)
+// @Param        cursor  query     string  false  "next_cursor of the previous page"
+// @Success      200  {object}  pagination.Page[Prescription]
+// @Failure      400  {object}  ErrorResponse "Invalid patient ID, sort key or cursor"
+// @Failure      403  {object}  ErrorResponse "Forbidden or not on the patient's care team"
 // @Failure      500  {object}  ErrorResponse "Internal server error"
 // @Router       /patients/{id}/prescriptions [get]
 func (h *Handler) GetPatientPrescriptions(c *gin.Context) {
@@ -88,11 +98,26 @@ func (h *Handler) GetPatientPrescriptions(c *gin.Context) {
 		return
 	}
 
-	prescriptions, err := h.service.GetPrescriptionsForPatient(c.Request.Context(), patientID)
+	var req pagination.Request
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		return
+	}
+
+	caller, _ := middleware.GetIdentity(c)
+	page, err := h.service.GetPrescriptionsForPatient(c.Request.Context(), caller, patientID, req)
 	if err != nil {
+		if errors.Is(err, careteam.ErrAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, pagination.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve prescriptions: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, prescriptions)
+	c.JSON(http.StatusOK, page)
 }
\ No newline at end of file
@@ -3,14 +3,15 @@ package prescription
 import "time"
 
 type Prescription struct {
-	ID         int       `json:"id" db:"id"`
-	PatientID  int       `json:"patient_id" db:"patient_id"`
-	DoctorID   int       `json:"doctor_id" db:"doctor_id"`
-	Medication string    `json:"medication" db:"medication"`
-	Dosage     string    `json:"dosage" db:"dosage"`
-	Frequency  string    `json:"frequency" db:"frequency"`
-	Notes      *string   `json:"notes,omitempty" db:"notes"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID         int        `json:"id" db:"id"`
+	PatientID  int        `json:"patient_id" db:"patient_id"`
+	DoctorID   int        `json:"doctor_id" db:"doctor_id"`
+	Medication string     `json:"medication" db:"medication"`
+	Dosage     string     `json:"dosage" db:"dosage"`
+	Frequency  string     `json:"frequency" db:"frequency"`
+	Notes      *string    `json:"notes,omitempty" db:"notes"`
+	EndsAt     *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 }
 
 // CreateRequest defines the payload for creating a prescription
@@ -19,4 +20,7 @@ type CreateRequest struct {
 	Dosage     string  `json:"dosage" binding:"required"`
 	Frequency  string  `json:"frequency" binding:"required"`
 	Notes      *string `json:"notes"`
+	// DurationDays ends the prescription after the given number of days.
+	// Without it the prescription stays active indefinitely.
+	DurationDays *int `json:"duration_days" binding:"omitempty,min=1,max=3650"`
 }
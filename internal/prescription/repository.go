@@ -2,14 +2,25 @@ package prescription
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
+var ErrPatientNotFound = errors.New("patient not found")
+
+// activePatient excludes the prescriptions of deleted patients
+const activePatient = `patient_id IN (SELECT id FROM patients WHERE deleted_at IS NULL)`
+
 // Repository defines the interface for prescription data storage operations
 type Repository interface {
-	Create(ctx context.Context, p *Prescription) error
-	GetByPatientID(ctx context.Context, patientID int) ([]Prescription, error)
+	Create(ctx context.Context, p *Prescription, durationDays *int) error
+	GetByPatientID(ctx context.Context, patientID int, req pagination.Request) (*pagination.Page[Prescription], error)
+	GetActiveByPatientID(ctx context.Context, patientID int) ([]Prescription, error)
 }
 
 type postgresRepository struct {
@@ -21,20 +32,61 @@ func NewPostgresRepository(db *sqlx.DB) Repository {
 	return &postgresRepository{db: db}
 }
 
-// Create inserts a new prescription record into the database
-func (r *postgresRepository) Create(ctx context.Context, p *Prescription) error {
-	query := `INSERT INTO prescriptions (patient_id, doctor_id, medication, dosage, frequency, notes, created_at) VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`
-	return r.db.QueryRowContext(ctx, query, p.PatientID, p.DoctorID, p.Medication, p.Dosage, p.Frequency, p.Notes).Scan(&p.ID, &p.CreatedAt)
+// Create inserts a new prescription record into the database. A prescription
+// with a duration ends that many days after it is written. It returns
+// ErrPatientNotFound for patients that do not exist or are deleted.
+func (r *postgresRepository) Create(ctx context.Context, p *Prescription, durationDays *int) error {
+	query := `INSERT INTO prescriptions (patient_id, doctor_id, medication, dosage, frequency, notes, ends_at, created_at)
+		SELECT id, $2, $3, $4, $5, $6, NOW() + $7 * INTERVAL '1 day', NOW() FROM patients WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, ends_at, created_at`
+	err := r.db.QueryRowContext(ctx, query, p.PatientID, p.DoctorID, p.Medication, p.Dosage, p.Frequency, p.Notes, durationDays).Scan(&p.ID, &p.EndsAt, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPatientNotFound
+	}
+	return err
 }
 
-// GetByPatientID retrieves all prescriptions for a given patient from the database
-func (r *postgresRepository) GetByPatientID(ctx context.Context, patientID int) ([]Prescription, error) {
-	var prescriptions []Prescription
-	query := `SELECT id, patient_id, doctor_id, medication, dosage, frequency, notes, created_at FROM prescriptions WHERE patient_id = $1 ORDER BY created_at DESC`
+// prescriptionSorter lists the sort keys of a patient's prescriptions, newest first by default
+var prescriptionSorter = pagination.Sorter[Prescription]{
+	Keys: map[string]pagination.Column[Prescription]{
+		"medication": {Expr: "medication", Cast: "text", Value: func(p *Prescription) string { return p.Medication }},
+		"created_at": {Expr: "created_at", Cast: "timestamp", Value: func(p *Prescription) string { return pagination.FormatTime(p.CreatedAt) }},
+	},
+	Default:  "-created_at",
+	IDColumn: "id",
+	ID:       func(p *Prescription) int { return p.ID },
+}
 
-	err := r.db.SelectContext(ctx, &prescriptions, query, patientID)
+// GetByPatientID retrieves a page of the prescriptions for a given patient from the database
+func (r *postgresRepository) GetByPatientID(ctx context.Context, patientID int, req pagination.Request) (*pagination.Page[Prescription], error) {
+	q, err := prescriptionSorter.Prepare(req, 2)
 	if err != nil {
 		return nil, err
 	}
-	return prescriptions, nil
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM prescriptions WHERE patient_id = $1 AND `+activePatient, patientID); err != nil {
+		return nil, err
+	}
+
+	var prescriptions []Prescription
+	query := `SELECT id, patient_id, doctor_id, medication, dosage, frequency, notes, ends_at, created_at FROM prescriptions WHERE patient_id = $1 AND ` + activePatient
+	if q.Where != "" {
+		query += ` AND ` + q.Where
+	}
+	query += fmt.Sprintf(` ORDER BY %s LIMIT %d`, q.OrderBy, q.Limit)
+	args := append([]interface{}{patientID}, q.Args...)
+	if err := r.db.SelectContext(ctx, &prescriptions, query, args...); err != nil {
+		return nil, err
+	}
+	return prescriptionSorter.Page(q, prescriptions, total), nil
+}
+
+// GetActiveByPatientID retrieves the prescriptions of a patient that have not ended
+func (r *postgresRepository) GetActiveByPatientID(ctx context.Context, patientID int) ([]Prescription, error) {
+	prescriptions := []Prescription{}
+	query := `SELECT id, patient_id, doctor_id, medication, dosage, frequency, notes, ends_at, created_at FROM prescriptions
+		WHERE patient_id = $1 AND ` + activePatient + ` AND (ends_at IS NULL OR ends_at > NOW()) ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &prescriptions, query, patientID)
+	return prescriptions, err
 }
\ No newline at end of file
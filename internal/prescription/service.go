@@ -1,41 +1,63 @@
 package prescription
 
-import "context"
+import (
+	"context"
+	"errors"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+// ErrNoPrescriber is returned when a caller without a user account, such as an
+// API key, creates a prescription; every prescription has a prescribing doctor
+var ErrNoPrescriber = errors.New("prescriptions can only be created by a doctor's account")
 
 // Service provides prescription-related business logic
 type Service interface {
-	CreatePrescription(ctx context.Context, patientID int, doctorID int, req CreateRequest) (*Prescription, error)
-	GetPrescriptionsForPatient(ctx context.Context, patientID int) ([]Prescription, error)
+	CreatePrescription(ctx context.Context, caller *middleware.Identity, patientID int, req CreateRequest) (*Prescription, error)
+	GetPrescriptionsForPatient(ctx context.Context, caller *middleware.Identity, patientID int, req pagination.Request) (*pagination.Page[Prescription], error)
 }
 
 type service struct {
-	repo Repository
+	repo   Repository
+	access careteam.AccessChecker
 }
 
 // NewService creates a new prescription service with the given repository
-func NewService(r Repository) Service {
-	return &service{repo: r}
+func NewService(r Repository, access careteam.AccessChecker) Service {
+	return &service{repo: r, access: access}
 }
 
-// CreatePrescription validates the input, constructs a Prescription model, and instructs the repository to save it.
-func (s *service) CreatePrescription(ctx context.Context, patientID int, doctorID int, req CreateRequest) (*Prescription, error) {
+// CreatePrescription checks that the prescribing doctor may access the patient, constructs a Prescription model, and instructs the repository to save it.
+func (s *service) CreatePrescription(ctx context.Context, caller *middleware.Identity, patientID int, req CreateRequest) (*Prescription, error) {
+	if caller == nil || caller.UserID == 0 {
+		return nil, ErrNoPrescriber
+	}
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+
 	p := &Prescription{
 		PatientID: patientID,
-		DoctorID: doctorID,
+		DoctorID: caller.UserID,
 		Medication: req.Medication,
 		Dosage: req.Dosage,
 		Frequency: req.Frequency,
 		Notes: req.Notes,
 	}
 
-	if err := s.repo.Create(ctx, p); err != nil {
+	if err := s.repo.Create(ctx, p, req.DurationDays); err != nil {
 		return nil, err
 	}
 
 	return p, nil
 }
 
-// GetPrescriptionsForPatient is a straightforward pass-through to the repository to fetch all prescriptions for a specific patient
-func (s *service) GetPrescriptionsForPatient(ctx context.Context, patientID int) ([]Prescription, error) {
-	return s.repo.GetByPatientID(ctx, patientID)
+// GetPrescriptionsForPatient fetches a page of the prescriptions for a specific patient the caller may access
+func (s *service) GetPrescriptionsForPatient(ctx context.Context, caller *middleware.Identity, patientID int, req pagination.Request) (*pagination.Page[Prescription], error) {
+	if err := s.access.CheckPatientAccess(ctx, caller, patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByPatientID(ctx, patientID, req)
 }
\ No newline at end of file
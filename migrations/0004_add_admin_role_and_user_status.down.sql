@@ -0,0 +1,11 @@
+DELETE FROM users WHERE role = 'admin';
+
+DROP TRIGGER IF EXISTS set_timestamp ON users;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS is_active;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor'));
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor', 'admin'));
+
+ALTER TABLE users
+    ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON users
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- Seed the initial administrator so staff accounts can be managed through the API
+-- Password is 'password123'
+INSERT INTO users (username, password_hash, role) VALUES
+('admin', '$2y$10$T1idEec.BiY/4j2fvrXUZ.sOFufq9HF2TfJuAvk84o3X6MZ8PGoA6', 'admin');
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS password_reset_tokens;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_changed_at,
+    DROP COLUMN IF EXISTS must_change_password,
+    DROP COLUMN IF EXISTS email;
\ No newline at end of file
@@ -0,0 +1,25 @@
+ALTER TABLE users
+    ADD COLUMN email VARCHAR(255) UNIQUE,
+    ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN password_changed_at TIMESTAMP;
+
+-- One-time password reset tokens issued by admins, stored as SHA-256 hashes
+CREATE TABLE password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    created_by INT,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY(user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_created_by
+        FOREIGN KEY(created_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
\ No newline at end of file
@@ -0,0 +1,35 @@
+ALTER TABLE users
+    ADD COLUMN mfa_secret VARCHAR(64),
+    ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN mfa_enrolled_at TIMESTAMP,
+    ADD COLUMN mfa_last_used_step BIGINT;
+
+-- One-time recovery codes, stored as SHA-256 hashes
+CREATE TABLE mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY(user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_mfa_recovery_codes_user_code ON mfa_recovery_codes(user_id, code_hash);
+
+-- Pending second login steps: issued after a correct password for MFA-enrolled users
+CREATE TABLE mfa_challenges (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_user
+        FOREIGN KEY(user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- Audit log of every password login attempt
+CREATE TABLE login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(50),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempts_username ON login_attempts(username, created_at DESC);
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip_address, created_at DESC);
+CREATE INDEX idx_login_attempts_created_at ON login_attempts(created_at DESC);
+
+-- Failed attempt counters, keyed by the submitted username (whether or not it exists) or client IP
+CREATE TABLE login_counters (
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('username', 'ip')),
+    identifier VARCHAR(255) NOT NULL,
+    failed_count INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    PRIMARY KEY (kind, identifier)
+);
\ No newline at end of file
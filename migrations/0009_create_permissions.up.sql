@@ -0,0 +1,57 @@
+CREATE TABLE roles (
+    name VARCHAR(20) PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+CREATE TABLE permissions (
+    name VARCHAR(64) PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+CREATE TABLE role_permissions (
+    role VARCHAR(20) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('receptionist', 'Front desk staff'),
+    ('doctor', 'Treating physicians'),
+    ('admin', 'Portal administrators');
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:create', 'Register new patients'),
+    ('patient:read', 'View and list patients'),
+    ('patient:update', 'Edit patient demographics'),
+    ('patient:medical:write', 'Edit diagnosis and clinical notes'),
+    ('patient:delete', 'Delete patients'),
+    ('prescription:create', 'Write prescriptions'),
+    ('prescription:read', 'View prescriptions'),
+    ('document:upload', 'Upload patient documents'),
+    ('document:read', 'View patient documents'),
+    ('document:delete', 'Delete patient documents'),
+    ('user:manage', 'Manage staff accounts'),
+    ('security:audit', 'View login attempts and manage lockouts'),
+    ('role:manage', 'Edit role permissions');
+
+-- Reproduces the role checks that were previously hard-coded on each route
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'patient:create'),
+    ('receptionist', 'patient:read'),
+    ('receptionist', 'patient:update'),
+    ('receptionist', 'patient:medical:write'),
+    ('receptionist', 'patient:delete'),
+    ('receptionist', 'prescription:read'),
+    ('receptionist', 'document:upload'),
+    ('receptionist', 'document:read'),
+    ('receptionist', 'document:delete'),
+    ('doctor', 'patient:read'),
+    ('doctor', 'patient:update'),
+    ('doctor', 'patient:medical:write'),
+    ('doctor', 'prescription:create'),
+    ('doctor', 'prescription:read'),
+    ('doctor', 'document:upload'),
+    ('doctor', 'document:read'),
+    ('admin', 'user:manage'),
+    ('admin', 'security:audit'),
+    ('admin', 'role:manage');
\ No newline at end of file
@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'apikey:manage';
+
+DROP TABLE IF EXISTS api_key_requests;
+DROP TABLE IF EXISTS api_keys;
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by INT REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Every request authenticated with an API key, so its actions stay attributable
+CREATE TABLE api_key_requests (
+    id BIGSERIAL PRIMARY KEY,
+    api_key_id INT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INT NOT NULL,
+    ip_address VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_key_requests_key ON api_key_requests(api_key_id, created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES ('apikey:manage', 'Create and revoke API keys');
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'apikey:manage');
\ No newline at end of file
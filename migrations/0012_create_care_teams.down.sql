@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name IN ('patient:access:all', 'careteam:manage');
+
+DROP TABLE IF EXISTS care_team_members;
\ No newline at end of file
@@ -0,0 +1,36 @@
+-- Doctors assigned to a patient. At most one member is the primary physician.
+CREATE TABLE care_team_members (
+    patient_id INT NOT NULL,
+    doctor_id INT NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    assigned_by INT,
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (patient_id, doctor_id),
+    CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_doctor
+        FOREIGN KEY(doctor_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_assigned_by
+        FOREIGN KEY(assigned_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_care_team_members_primary ON care_team_members(patient_id) WHERE is_primary;
+CREATE INDEX idx_care_team_members_doctor ON care_team_members(doctor_id);
+
+-- Doctors who already prescribed for a patient keep access to them
+INSERT INTO care_team_members (patient_id, doctor_id)
+SELECT DISTINCT patient_id, doctor_id FROM prescriptions;
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:access:all', 'Access every patient, not only those on the caller''s care teams'),
+    ('careteam:manage', 'Assign doctors to patients');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'patient:access:all'),
+    ('receptionist', 'careteam:manage');
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name IN ('patient:emergency_access', 'emergency_access:review');
+
+DROP TABLE IF EXISTS emergency_access_grants;
\ No newline at end of file
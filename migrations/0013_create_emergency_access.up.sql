@@ -0,0 +1,37 @@
+-- Break-the-glass grants: time-boxed access to one patient outside the
+-- doctor's care teams. Every grant stays in the table for review.
+CREATE TABLE emergency_access_grants (
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL,
+    doctor_id INT NOT NULL,
+    reason TEXT NOT NULL,
+    ip_address VARCHAR(45),
+    granted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    reviewed_at TIMESTAMP,
+    reviewed_by INT,
+    review_note TEXT,
+    CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_doctor
+        FOREIGN KEY(doctor_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_reviewed_by
+        FOREIGN KEY(reviewed_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_emergency_access_grants_doctor_patient ON emergency_access_grants(doctor_id, patient_id, expires_at);
+CREATE INDEX idx_emergency_access_grants_pending ON emergency_access_grants(granted_at) WHERE reviewed_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:emergency_access', 'Break the glass to open a patient outside the caller''s care teams'),
+    ('emergency_access:review', 'Review emergency access grants');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('doctor', 'patient:emergency_access'),
+    ('admin', 'emergency_access:review');
\ No newline at end of file
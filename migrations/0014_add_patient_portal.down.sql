@@ -0,0 +1,14 @@
+DELETE FROM permissions WHERE name IN ('portal:self', 'portal:invite');
+DELETE FROM users WHERE role = 'patient';
+DELETE FROM roles WHERE name = 'patient';
+
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS ends_at;
+
+DROP TABLE IF EXISTS patient_invitations;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_patient_link_check,
+    DROP COLUMN IF EXISTS patient_id;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor', 'admin'));
\ No newline at end of file
@@ -0,0 +1,47 @@
+-- Patient portal accounts are users with the patient role linked to exactly
+-- one patient record
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist', 'doctor', 'admin', 'patient'));
+
+ALTER TABLE users
+    ADD COLUMN patient_id INT UNIQUE,
+    ADD CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT users_patient_link_check CHECK ((role = 'patient') = (patient_id IS NOT NULL));
+
+-- Single-use invitations a patient redeems to create their portal account
+CREATE TABLE patient_invitations (
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    created_by INT,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_created_by
+        FOREIGN KEY(created_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_patient_invitations_patient_id ON patient_invitations(patient_id);
+
+-- Prescriptions without an end date stay active until one is set
+ALTER TABLE prescriptions ADD COLUMN ends_at TIMESTAMP;
+
+INSERT INTO roles (name, description) VALUES ('patient', 'Patients using the self-service portal');
+
+INSERT INTO permissions (name, description) VALUES
+    ('portal:self', 'View one''s own record in the patient portal'),
+    ('portal:invite', 'Invite patients to the patient portal');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('patient', 'portal:self'),
+    ('receptionist', 'portal:invite');
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM permissions WHERE name = 'patient:clinical:read';
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- Diagnosis and notes are only returned to callers with this permission;
+-- everyone else receives the demographic view of a patient
+INSERT INTO permissions (name, description) VALUES
+    ('patient:clinical:read', 'View diagnosis and clinical notes');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('doctor', 'patient:clinical:read');
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_patients_phone_digits_trgm;
+DROP INDEX IF EXISTS idx_patients_address_trgm;
+DROP INDEX IF EXISTS idx_patients_name_trgm;
+DROP INDEX IF EXISTS idx_patients_mrn;
+
+ALTER TABLE patients DROP COLUMN IF EXISTS mrn;
+
+DROP EXTENSION IF EXISTS fuzzystrmatch;
+DROP EXTENSION IF EXISTS pg_trgm;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Fuzzy and phonetic patient search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS fuzzystrmatch;
+
+-- Medical record number, searchable alongside name, phone number and address
+ALTER TABLE patients ADD COLUMN mrn VARCHAR(32);
+CREATE UNIQUE INDEX idx_patients_mrn ON patients (UPPER(mrn));
+
+CREATE INDEX idx_patients_name_trgm ON patients USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_patients_address_trgm ON patients USING GIN (address gin_trgm_ops);
+CREATE INDEX idx_patients_phone_digits_trgm ON patients USING GIN ((regexp_replace(phone_number, '\D', '', 'g')) gin_trgm_ops);
\ No newline at end of file
@@ -0,0 +1,11 @@
+DELETE FROM permissions WHERE name = 'patient:restore';
+
+-- Patients still in the trash are removed for good, as before soft delete
+DELETE FROM patients WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_patients_deleted_at;
+
+ALTER TABLE patients
+    DROP CONSTRAINT IF EXISTS fk_deleted_by,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Deleted patients are kept until the retention period has passed
+ALTER TABLE patients
+    ADD COLUMN deleted_at TIMESTAMP,
+    ADD COLUMN deleted_by INT,
+    ADD CONSTRAINT fk_deleted_by
+        FOREIGN KEY(deleted_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL;
+
+CREATE INDEX idx_patients_deleted_at ON patients(deleted_at) WHERE deleted_at IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:restore', 'View deleted patients and restore them');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'patient:restore');
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'patient:merge';
+
+DROP TABLE IF EXISTS patient_merges;
\ No newline at end of file
@@ -0,0 +1,40 @@
+-- Record of a duplicate patient merged into a surviving record. The moved
+-- rows are listed so the merge can be undone.
+CREATE TABLE patient_merges (
+    id SERIAL PRIMARY KEY,
+    survivor_id INT,
+    duplicate_id INT,
+    merged_by INT,
+    merged_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    prescription_ids INT[] NOT NULL DEFAULT '{}',
+    document_ids INT[] NOT NULL DEFAULT '{}',
+    care_team_doctor_ids INT[] NOT NULL DEFAULT '{}',
+    portal_user_id INT,
+    undone_at TIMESTAMP,
+    undone_by INT,
+    CONSTRAINT fk_survivor
+        FOREIGN KEY(survivor_id)
+        REFERENCES patients(id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_duplicate
+        FOREIGN KEY(duplicate_id)
+        REFERENCES patients(id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_merged_by
+        FOREIGN KEY(merged_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_undone_by
+        FOREIGN KEY(undone_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_patient_merges_survivor ON patient_merges(survivor_id);
+CREATE INDEX idx_patient_merges_duplicate ON patient_merges(duplicate_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:merge', 'Merge duplicate patients and undo merges');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'patient:merge');
\ No newline at end of file
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_patients_date_of_birth;
+
+ALTER TABLE patients ADD COLUMN age INT;
+
+UPDATE patients SET age = date_part('year', age(date_of_birth))::int;
+
+ALTER TABLE patients
+    ALTER COLUMN age SET NOT NULL,
+    DROP COLUMN dob_estimated,
+    DROP COLUMN date_of_birth;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Ages are computed from the date of birth on read. Existing patients only
+-- have the age they gave at registration, so their date of birth is estimated
+-- as the middle of the year that age allows.
+ALTER TABLE patients
+    ADD COLUMN date_of_birth DATE,
+    ADD COLUMN dob_estimated BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE patients
+SET date_of_birth = (created_at - make_interval(years => age, months => 6))::date,
+    dob_estimated = TRUE;
+
+ALTER TABLE patients
+    ALTER COLUMN date_of_birth SET NOT NULL,
+    DROP COLUMN age;
+
+CREATE INDEX idx_patients_date_of_birth ON patients(date_of_birth);
\ No newline at end of file
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS patient_emergency_contacts;
+
+ALTER TABLE patients
+    DROP CONSTRAINT IF EXISTS patients_marital_status_check,
+    DROP CONSTRAINT IF EXISTS patients_sex_check,
+    DROP COLUMN IF EXISTS occupation,
+    DROP COLUMN IF EXISTS marital_status,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS postcode,
+    DROP COLUMN IF EXISTS region,
+    DROP COLUMN IF EXISTS city,
+    DROP COLUMN IF EXISTS preferred_language,
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS gender,
+    DROP COLUMN IF EXISTS sex;
\ No newline at end of file
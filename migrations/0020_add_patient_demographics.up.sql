@@ -0,0 +1,35 @@
+-- Optional demographics. The existing address column holds the street line of
+-- the structured address.
+ALTER TABLE patients
+    ADD COLUMN sex VARCHAR(16),
+    ADD COLUMN gender VARCHAR(64),
+    ADD COLUMN email VARCHAR(255),
+    ADD COLUMN preferred_language VARCHAR(35),
+    ADD COLUMN city VARCHAR(100),
+    ADD COLUMN region VARCHAR(100),
+    ADD COLUMN postcode VARCHAR(20),
+    ADD COLUMN country CHAR(2),
+    ADD COLUMN marital_status VARCHAR(16),
+    ADD COLUMN occupation VARCHAR(100),
+    ADD CONSTRAINT patients_sex_check CHECK (sex IN ('female', 'male', 'intersex', 'unknown')),
+    ADD CONSTRAINT patients_marital_status_check CHECK (marital_status IN ('single', 'married', 'partnered', 'separated', 'divorced', 'widowed', 'unknown'));
+
+-- People to call in an emergency. At most one contact is the primary one.
+CREATE TABLE patient_emergency_contacts (
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    relationship VARCHAR(50) NOT NULL,
+    phone_number VARCHAR(30) NOT NULL,
+    email VARCHAR(255),
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_patient_emergency_contacts_patient_id ON patient_emergency_contacts(patient_id);
+CREATE UNIQUE INDEX idx_patient_emergency_contacts_primary ON patient_emergency_contacts(patient_id) WHERE is_primary;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS record_version ON patients;
+DROP FUNCTION IF EXISTS record_patient_version();
+DROP TABLE IF EXISTS patient_versions;
+
+ALTER TABLE patients
+    DROP CONSTRAINT IF EXISTS fk_updated_by,
+    DROP COLUMN IF EXISTS updated_by;
\ No newline at end of file
@@ -0,0 +1,73 @@
+-- Every insert and update of a patient row is kept as an immutable version:
+-- the full row after the change and, per changed column, the old and new
+-- values. Writers record who made the change in updated_by, or deleted_by for
+-- a deletion.
+ALTER TABLE patients
+    ADD COLUMN updated_by INT,
+    ADD CONSTRAINT fk_updated_by
+        FOREIGN KEY(updated_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL;
+
+CREATE TABLE patient_versions (
+    patient_id INT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('create', 'update', 'delete', 'restore')),
+    changed_by INT,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    changes JSONB NOT NULL DEFAULT '{}',
+    snapshot JSONB NOT NULL,
+    PRIMARY KEY (patient_id, version),
+    CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_changed_by
+        FOREIGN KEY(changed_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_patient_versions_changed_at ON patient_versions(patient_id, changed_at);
+
+CREATE OR REPLACE FUNCTION record_patient_version()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_row JSONB := to_jsonb(NEW);
+    old_row JSONB := CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END;
+    diff JSONB;
+    op VARCHAR(10) := 'update';
+    actor INT := NEW.updated_by;
+BEGIN
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', old_row -> n.key, 'new', n.value)), '{}'::jsonb)
+    INTO diff
+    FROM jsonb_each(new_row) AS n
+    WHERE n.key NOT IN ('updated_at', 'updated_by')
+      AND COALESCE(old_row -> n.key, 'null'::jsonb) IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF diff = '{}'::jsonb THEN
+        RETURN NULL;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+        actor := NEW.deleted_by;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    END IF;
+
+    INSERT INTO patient_versions (patient_id, version, operation, changed_by, changed_at, changes, snapshot)
+    SELECT NEW.id, COALESCE(MAX(version), 0) + 1, op, actor, NOW(), diff, new_row
+    FROM patient_versions WHERE patient_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_version
+AFTER INSERT OR UPDATE ON patients
+FOR EACH ROW
+EXECUTE PROCEDURE record_patient_version();
+
+-- Existing patients start their history with their current state
+INSERT INTO patient_versions (patient_id, version, operation, changed_at, snapshot)
+SELECT id, 1, 'create', updated_at, to_jsonb(patients) FROM patients;
\ No newline at end of file
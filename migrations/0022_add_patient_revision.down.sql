@@ -0,0 +1,37 @@
+DROP TRIGGER IF EXISTS bump_revision ON patients;
+DROP FUNCTION IF EXISTS bump_patient_revision();
+
+CREATE OR REPLACE FUNCTION record_patient_version()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_row JSONB := to_jsonb(NEW);
+    old_row JSONB := CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END;
+    diff JSONB;
+    op VARCHAR(10) := 'update';
+    actor INT := NEW.updated_by;
+BEGIN
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', old_row -> n.key, 'new', n.value)), '{}'::jsonb)
+    INTO diff
+    FROM jsonb_each(new_row) AS n
+    WHERE n.key NOT IN ('updated_at', 'updated_by')
+      AND COALESCE(old_row -> n.key, 'null'::jsonb) IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF diff = '{}'::jsonb THEN
+        RETURN NULL;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+        actor := NEW.deleted_by;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    END IF;
+
+    INSERT INTO patient_versions (patient_id, version, operation, changed_by, changed_at, changes, snapshot)
+    SELECT NEW.id, COALESCE(MAX(version), 0) + 1, op, actor, NOW(), diff, new_row
+    FROM patient_versions WHERE patient_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE patients DROP COLUMN IF EXISTS revision;
\ No newline at end of file
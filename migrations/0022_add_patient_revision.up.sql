@@ -0,0 +1,61 @@
+-- Every change to a patient bumps its revision, which clients send back in
+-- If-Match to detect concurrent edits. Revisions only move on real changes
+-- and are the version numbers of the patient's history.
+ALTER TABLE patients ADD COLUMN revision INT NOT NULL DEFAULT 1;
+
+ALTER TABLE patients DISABLE TRIGGER USER;
+UPDATE patients p SET revision = v.version
+FROM (SELECT patient_id, MAX(version) AS version FROM patient_versions GROUP BY patient_id) v
+WHERE v.patient_id = p.id;
+ALTER TABLE patients ENABLE TRIGGER USER;
+
+CREATE OR REPLACE FUNCTION bump_patient_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - ARRAY['updated_at', 'updated_by', 'revision'] IS DISTINCT FROM to_jsonb(OLD) - ARRAY['updated_at', 'updated_by', 'revision'] THEN
+        NEW.revision := OLD.revision + 1;
+    ELSE
+        NEW.revision := OLD.revision;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER bump_revision
+BEFORE UPDATE ON patients
+FOR EACH ROW
+EXECUTE PROCEDURE bump_patient_revision();
+
+CREATE OR REPLACE FUNCTION record_patient_version()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_row JSONB := to_jsonb(NEW);
+    old_row JSONB := CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END;
+    diff JSONB;
+    op VARCHAR(10) := 'update';
+    actor INT := NEW.updated_by;
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.revision = OLD.revision THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', old_row -> n.key, 'new', n.value)), '{}'::jsonb)
+    INTO diff
+    FROM jsonb_each(new_row) AS n
+    WHERE n.key NOT IN ('updated_at', 'updated_by', 'revision')
+      AND COALESCE(old_row -> n.key, 'null'::jsonb) IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+        actor := NEW.deleted_by;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    END IF;
+
+    INSERT INTO patient_versions (patient_id, version, operation, changed_by, changed_at, changes, snapshot)
+    VALUES (NEW.id, NEW.revision, op, actor, NOW(), diff, new_row);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
\ No newline at end of file
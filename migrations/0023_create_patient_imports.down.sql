@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'patient:import';
+
+DROP TABLE IF EXISTS patient_imports;
\ No newline at end of file
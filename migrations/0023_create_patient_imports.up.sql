@@ -0,0 +1,27 @@
+-- A bulk import of patients from a spreadsheet. The validated rows are kept
+-- until the import completes so an interrupted import can be resumed from
+-- next_row, the first row not yet committed.
+CREATE TABLE patient_imports (
+    id SERIAL PRIMARY KEY,
+    filename VARCHAR(255) NOT NULL,
+    mode VARCHAR(12) NOT NULL CHECK (mode IN ('transaction', 'batches')),
+    status VARCHAR(12) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    batch_size INT NOT NULL CHECK (batch_size > 0),
+    total_rows INT NOT NULL,
+    next_row INT NOT NULL DEFAULT 0,
+    rows JSONB NOT NULL,
+    error TEXT,
+    created_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_created_by
+        FOREIGN KEY(created_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:import', 'Import patients in bulk from CSV or XLSX files');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'patient:import');
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'patient:export';
+
+DROP TABLE IF EXISTS patient_exports;
\ No newline at end of file
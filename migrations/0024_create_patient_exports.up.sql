@@ -0,0 +1,33 @@
+-- Exports of the patient list too large to stream are produced in the
+-- background and kept until expires_at. filters are the list filters,
+-- clinical whether diagnosis and notes are included and panel_of the doctor
+-- whose care teams the export is restricted to, as allowed to the requester.
+CREATE TABLE patient_exports (
+    id SERIAL PRIMARY KEY,
+    format VARCHAR(4) NOT NULL CHECK (format IN ('csv', 'xlsx', 'pdf')),
+    filters JSONB NOT NULL DEFAULT '{}',
+    clinical BOOLEAN NOT NULL DEFAULT FALSE,
+    panel_of INT NOT NULL DEFAULT 0,
+    status VARCHAR(12) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    row_count INT,
+    content BYTEA,
+    error TEXT,
+    requested_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    CONSTRAINT fk_requested_by
+        FOREIGN KEY(requested_by)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_patient_exports_pending ON patient_exports(id) WHERE status IN ('pending', 'running');
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient:export', 'Export the patient list to CSV, XLSX or PDF');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'patient:export'),
+    ('doctor', 'patient:export');
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE patient_merges DROP COLUMN IF EXISTS identifier_ids;
+DROP TABLE IF EXISTS patient_identifiers;
+DROP TABLE IF EXISTS patient_mrn_sequences;
\ No newline at end of file
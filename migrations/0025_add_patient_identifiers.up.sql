@@ -0,0 +1,31 @@
+-- New patients are given an MRN generated from a configurable pattern. Each
+-- scope, the pattern with the year filled in, counts its own sequence.
+CREATE TABLE patient_mrn_sequences (
+    scope VARCHAR(32) PRIMARY KEY,
+    last_value BIGINT NOT NULL
+);
+
+-- Identifiers of patients in other systems, such as national IDs and
+-- insurance member IDs. A value identifies one patient per system.
+CREATE TABLE patient_identifiers (
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL,
+    system VARCHAR(32) NOT NULL,
+    value VARCHAR(64) NOT NULL,
+    created_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_patient
+        FOREIGN KEY(patient_id)
+        REFERENCES patients(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_created_by
+        FOREIGN KEY(created_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_patient_identifiers_value ON patient_identifiers (system, UPPER(value));
+CREATE INDEX idx_patient_identifiers_patient ON patient_identifiers(patient_id);
+
+-- Merges move identifiers to the survivor like prescriptions and documents
+ALTER TABLE patient_merges ADD COLUMN identifier_ids INT[] NOT NULL DEFAULT '{}';
\ No newline at end of file
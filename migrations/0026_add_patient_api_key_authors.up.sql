@@ -0,0 +1,62 @@
+-- Changes made with an API key have no user; writers record the key in
+-- updated_by_api_key instead, and every version records it as
+-- changed_by_api_key. Like updated_by it is bookkeeping, not a change.
+ALTER TABLE patients
+    ADD COLUMN updated_by_api_key INT,
+    ADD CONSTRAINT fk_updated_by_api_key
+        FOREIGN KEY(updated_by_api_key)
+        REFERENCES api_keys(id)
+        ON DELETE SET NULL;
+
+ALTER TABLE patient_versions
+    ADD COLUMN changed_by_api_key INT,
+    ADD CONSTRAINT fk_changed_by_api_key
+        FOREIGN KEY(changed_by_api_key)
+        REFERENCES api_keys(id)
+        ON DELETE SET NULL;
+
+CREATE OR REPLACE FUNCTION bump_patient_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - ARRAY['updated_at', 'updated_by', 'updated_by_api_key', 'revision'] IS DISTINCT FROM to_jsonb(OLD) - ARRAY['updated_at', 'updated_by', 'updated_by_api_key', 'revision'] THEN
+        NEW.revision := OLD.revision + 1;
+    ELSE
+        NEW.revision := OLD.revision;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_patient_version()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_row JSONB := to_jsonb(NEW);
+    old_row JSONB := CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END;
+    diff JSONB;
+    op VARCHAR(10) := 'update';
+    actor INT := NEW.updated_by;
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.revision = OLD.revision THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('old', old_row -> n.key, 'new', n.value)), '{}'::jsonb)
+    INTO diff
+    FROM jsonb_each(new_row) AS n
+    WHERE n.key NOT IN ('updated_at', 'updated_by', 'updated_by_api_key', 'revision')
+      AND COALESCE(old_row -> n.key, 'null'::jsonb) IS DISTINCT FROM n.value;
+
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        op := 'delete';
+        actor := NEW.deleted_by;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        op := 'restore';
+    END IF;
+
+    INSERT INTO patient_versions (patient_id, version, operation, changed_by, changed_by_api_key, changed_at, changes, snapshot)
+    VALUES (NEW.id, NEW.revision, op, actor, NEW.updated_by_api_key, NOW(), diff, new_row);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE patient_merges DROP COLUMN IF EXISTS contact_ids;
\ No newline at end of file
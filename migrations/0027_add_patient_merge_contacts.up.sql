@@ -0,0 +1,2 @@
+-- Merges move emergency contacts to the survivor like identifiers
+ALTER TABLE patient_merges ADD COLUMN contact_ids INT[] NOT NULL DEFAULT '{}';
\ No newline at end of file
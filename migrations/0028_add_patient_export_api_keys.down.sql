@@ -0,0 +1,3 @@
+ALTER TABLE patient_exports
+    DROP CONSTRAINT IF EXISTS fk_requested_by_api_key,
+    DROP COLUMN IF EXISTS requested_by_api_key;
\ No newline at end of file
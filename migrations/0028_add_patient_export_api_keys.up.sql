@@ -0,0 +1,7 @@
+-- Exports requested with an API key belong to the key
+ALTER TABLE patient_exports
+    ADD COLUMN requested_by_api_key INT,
+    ADD CONSTRAINT fk_requested_by_api_key
+        FOREIGN KEY(requested_by_api_key)
+        REFERENCES api_keys(id)
+        ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE users SET must_change_password = FALSE
+WHERE username = 'admin'
+  AND password_hash = '$2y$10$T1idEec.BiY/4j2fvrXUZ.sOFufq9HF2TfJuAvk84o3X6MZ8PGoA6';
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- The administrator seeded with the well-known password 'password123' must
+-- choose a new one before using the API
+UPDATE users SET must_change_password = TRUE
+WHERE username = 'admin'
+  AND password_hash = '$2y$10$T1idEec.BiY/4j2fvrXUZ.sOFufq9HF2TfJuAvk84o3X6MZ8PGoA6';
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE patients ALTER COLUMN mrn DROP NOT NULL;
\ No newline at end of file
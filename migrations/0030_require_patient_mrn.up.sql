@@ -0,0 +1,53 @@
+-- Patients registered before MRNs were generated get the next MRN of the
+-- default pattern, MRN-{YYYY}-{SEQ:6}{CHECK}, for the year they were
+-- registered, oldest first. Values already taken are skipped.
+CREATE FUNCTION next_patient_mrn(registered TIMESTAMP)
+RETURNS VARCHAR AS $$
+DECLARE
+    prefix VARCHAR(32) := 'MRN-' || to_char(registered, 'YYYY') || '-';
+    seq BIGINT;
+    digits TEXT;
+    total INT;
+    d INT;
+    candidate VARCHAR(32);
+BEGIN
+    LOOP
+        INSERT INTO patient_mrn_sequences AS s (scope, last_value) VALUES (prefix, 1)
+        ON CONFLICT (scope) DO UPDATE SET last_value = s.last_value + 1
+        RETURNING last_value INTO seq;
+
+        candidate := prefix || lpad(seq::text, 6, '0');
+        digits := regexp_replace(candidate, '[^0-9]', '', 'g');
+        total := 0;
+        FOR i IN 1..length(digits) LOOP
+            d := substr(digits, length(digits) - i + 1, 1)::int;
+            IF i % 2 = 1 THEN
+                d := d * 2;
+                IF d > 9 THEN
+                    d := d - 9;
+                END IF;
+            END IF;
+            total := total + d;
+        END LOOP;
+        candidate := candidate || ((10 - total % 10) % 10)::text;
+
+        IF NOT EXISTS (SELECT 1 FROM patients WHERE UPPER(mrn) = candidate) THEN
+            RETURN candidate;
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    p RECORD;
+BEGIN
+    FOR p IN SELECT id, created_at FROM patients WHERE mrn IS NULL ORDER BY created_at, id LOOP
+        UPDATE patients SET mrn = next_patient_mrn(p.created_at) WHERE id = p.id;
+    END LOOP;
+END;
+$$;
+
+DROP FUNCTION next_patient_mrn(TIMESTAMP);
+
+ALTER TABLE patients ALTER COLUMN mrn SET NOT NULL;
\ No newline at end of file
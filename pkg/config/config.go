@@ -3,25 +3,114 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Port          string
-	DatabaseURL   string
-	JWTSecretKey  string
-	GinMode       string
-	CloudinaryURL string
+	Port            string
+	DatabaseURL     string
+	GinMode         string
+	CloudinaryURL   string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// JWTSigningKeyFile is a PEM private key (RSA or Ed25519). Keys listed in
+	// JWTVerificationKeyFiles are still accepted for verification, e.g. the
+	// previous signing key during a rotation.
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string
+	JWTIssuer               string
+
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+
+	MFAIssuer string
+
+	LoginLockAfter    int
+	LoginLockDuration time.Duration
+	LoginIPLockAfter  int
+
+	// EmergencyAccessTTL bounds how long a break-the-glass grant lasts
+	EmergencyAccessTTL time.Duration
+
+	PortalInviteURL string
+	PortalInviteTTL time.Duration
+
+	// Deleted patients are purged for good once they have been in the trash
+	// for PatientRetention; the purge job runs every PatientPurgeInterval
+	PatientRetention     time.Duration
+	PatientPurgeInterval time.Duration
+
+	// Large patient exports are produced by a job that looks for new exports
+	// every PatientExportPollInterval; they can be downloaded for PatientExportTTL
+	PatientExportPollInterval time.Duration
+	PatientExportTTL          time.Duration
+
+	// MRNPattern is the format of the medical record numbers given to new
+	// patients, e.g. "MRN-{YYYY}-{SEQ:6}{CHECK}"
+	MRNPattern string
+
+	// Single sign-on is enabled when OIDCIssuerURL is set. OIDCRoleMapping maps
+	// values of the OIDCRoleClaim claim to portal roles, e.g. "ward-doctors=doctor".
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCRedirectURL   string
+	OIDCScopes        []string
+	OIDCRoleClaim     string
+	OIDCRoleMapping   string
+	OIDCAutoProvision bool
+	OIDCLinkByEmail   bool
 }
 
 // New creates a new Config instanceb
 func New() *Config {
 	return &Config{
-		Port:          getEnv("PORT", "8080"),
-		DatabaseURL:   getEnv("DATABASE_URL", ""),
-		JWTSecretKey:  getEnv("JWT_SECRET_KEY", "secret"),
-		GinMode:       getEnv("GIN_MODE", "debug"),
-		CloudinaryURL: getEnv("CLOUDINARY_URL", ""),
+		Port:            getEnv("PORT", "8080"),
+		DatabaseURL:     getEnv("DATABASE_URL", ""),
+		GinMode:         getEnv("GIN_MODE", "debug"),
+		CloudinaryURL:   getEnv("CLOUDINARY_URL", ""),
+		AccessTokenTTL:  getDurationEnv("ACCESS_TOKEN_TTL", "15m"),
+		RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", "720h"),
+
+		JWTSigningKeyFile:       os.Getenv("JWT_SIGNING_KEY_FILE"),
+		JWTVerificationKeyFiles: getListEnv("JWT_VERIFICATION_KEY_FILES"),
+		JWTIssuer:               getEnv("JWT_ISSUER", "medical-portal"),
+
+		PasswordResetURL: getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		PasswordResetTTL: getDurationEnv("PASSWORD_RESET_TTL", "1h"),
+
+		MFAIssuer: getEnv("MFA_ISSUER", "Medical Portal"),
+
+		LoginLockAfter:    getIntEnv("LOGIN_LOCK_AFTER", "10"),
+		LoginLockDuration: getDurationEnv("LOGIN_LOCK_DURATION", "15m"),
+		LoginIPLockAfter:  getIntEnv("LOGIN_IP_LOCK_AFTER", "50"),
+
+		EmergencyAccessTTL: getDurationEnv("EMERGENCY_ACCESS_TTL", "4h"),
+
+		PortalInviteURL: getEnv("PORTAL_INVITE_URL", "http://localhost:3000/portal/activate"),
+		PortalInviteTTL: getDurationEnv("PORTAL_INVITE_TTL", "72h"),
+
+		PatientRetention:     getDurationEnv("PATIENT_RETENTION", "87600h"),
+		PatientPurgeInterval: getDurationEnv("PATIENT_PURGE_INTERVAL", "24h"),
+
+		PatientExportPollInterval: getDurationEnv("PATIENT_EXPORT_POLL_INTERVAL", "10s"),
+		PatientExportTTL:          getDurationEnv("PATIENT_EXPORT_TTL", "24h"),
+
+		MRNPattern: getEnv("MRN_PATTERN", "MRN-{YYYY}-{SEQ:6}{CHECK}"),
+
+		OIDCIssuerURL:     os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:      os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:   getEnv("OIDC_REDIRECT_URL", "http://localhost:3000/sso/callback"),
+		OIDCScopes:        getListEnv("OIDC_SCOPES"),
+		OIDCRoleClaim:     getEnv("OIDC_ROLE_CLAIM", "groups"),
+		OIDCRoleMapping:   os.Getenv("OIDC_ROLE_MAPPING"),
+		OIDCAutoProvision: getBoolEnv("OIDC_AUTO_PROVISION", "true"),
+		OIDCLinkByEmail:   getBoolEnv("OIDC_LINK_BY_EMAIL", "false"),
 	}
 }
 
@@ -35,3 +124,44 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getDurationEnv reads an environment variable as a time.Duration (e.g. "15m")
+func getDurationEnv(key, fallback string) time.Duration {
+	value := getEnv(key, fallback)
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("FATAL: Environment variable %s must be a duration: %v", key, err)
+	}
+	return d
+}
+
+// getListEnv reads an optional comma separated environment variable
+func getListEnv(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getIntEnv reads an environment variable as an integer
+func getIntEnv(key, fallback string) int {
+	value := getEnv(key, fallback)
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("FATAL: Environment variable %s must be an integer: %v", key, err)
+	}
+	return n
+}
+
+// getBoolEnv reads an environment variable as a boolean
+func getBoolEnv(key, fallback string) bool {
+	value := getEnv(key, fallback)
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("FATAL: Environment variable %s must be a boolean: %v", key, err)
+	}
+	return b
+}
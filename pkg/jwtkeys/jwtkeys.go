@@ -0,0 +1,324 @@
+// Package jwtkeys manages the asymmetric keys used to sign and verify access
+// tokens. A KeySet has at most one signing key and any number of verification
+// keys, identified by the "kid" JWT header, so keys can be rotated without
+// invalidating tokens signed by the previous key. Services that only verify
+// tokens build a KeySet from the published JWKS document.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const minRSABits = 2048
+
+var (
+	ErrNoSigningKey   = errors.New("key set has no signing key")
+	ErrUnknownKey     = errors.New("token signed with an unknown key")
+	ErrUnsupportedKey = errors.New("unsupported key type; use an RSA (2048 bits or more) or Ed25519 key")
+)
+
+// Key is a public key, optionally with its private half, identified by its
+// RFC 7638 JWK thumbprint
+type Key struct {
+	ID        string
+	Algorithm string
+	public    crypto.PublicKey
+	private   crypto.Signer
+}
+
+// NewKey wraps an RSA or Ed25519 private or public key
+func NewKey(k interface{}) (*Key, error) {
+	key := &Key{}
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		key.private, key.public, key.Algorithm = k, &k.PublicKey, AlgRS256
+	case *rsa.PublicKey:
+		key.public, key.Algorithm = k, AlgRS256
+	case ed25519.PrivateKey:
+		key.private, key.public, key.Algorithm = k, k.Public(), AlgEdDSA
+	case ed25519.PublicKey:
+		key.public, key.Algorithm = k, AlgEdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if pub, ok := key.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, ErrUnsupportedKey
+	}
+
+	key.ID = thumbprint(key.JWK())
+	return key, nil
+}
+
+// GenerateEd25519 creates a new random Ed25519 signing key
+func GenerateEd25519() (*Key, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(priv)
+}
+
+// ParsePEM parses the first PEM block of data. PKCS#8 and PKCS#1 private keys
+// and PKIX and PKCS#1 public keys are accepted.
+func ParsePEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		k   interface{}
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		k, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		k, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(k)
+}
+
+// LoadPEMFile reads and parses a PEM encoded key file
+func LoadPEMFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK returns the public half of the key in JSON Web Key form
+func (k *Key) JWK() JWK {
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: AlgRS256,
+			Kid: k.ID,
+			N:   b64(pub.N.Bytes()),
+			E:   b64(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: AlgEdDSA,
+			Kid: k.ID,
+			Crv: "Ed25519",
+			X:   b64(pub),
+		}
+	}
+	return JWK{}
+}
+
+// JWK is a public JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set document as served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet holds the signing key and every key tokens are still accepted from
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+}
+
+// NewKeySet builds a key set. signing may be nil for a verification-only set;
+// it is always accepted for verification as well.
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	if signing != nil && signing.private == nil {
+		return nil, errors.New("signing key must include the private key")
+	}
+
+	ks := &KeySet{signing: signing, keys: make(map[string]*Key)}
+	all := verification
+	if signing != nil {
+		all = append([]*Key{signing}, verification...)
+	}
+	for _, k := range all {
+		if _, dup := ks.keys[k.ID]; dup {
+			continue
+		}
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k.ID)
+	}
+	if len(ks.keys) == 0 {
+		return nil, errors.New("key set is empty")
+	}
+	return ks, nil
+}
+
+// SigningKeyID returns the kid of the current signing key
+func (ks *KeySet) SigningKeyID() string {
+	if ks.signing == nil {
+		return ""
+	}
+	return ks.signing.ID
+}
+
+// Sign signs the claims with the current signing key and sets the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(ks.signing.signingMethod(), claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// Keyfunc resolves the verification key of a token from its kid header, for
+// use with jwt.Parse. The token's alg must match the algorithm of the key.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("%w: algorithm mismatch", ErrUnknownKey)
+	}
+	return key.public, nil
+}
+
+// Algorithms lists the algorithms of the keys in the set, for jwt.WithValidMethods
+func (ks *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, id := range ks.order {
+		alg := ks.keys[id].Algorithm
+		if !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWKS returns the public keys of the set
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.order))}
+	for _, id := range ks.order {
+		set.Keys = append(set.Keys, ks.keys[id].JWK())
+	}
+	return set
+}
+
+// ParseJWKS builds a verification-only key set from a JWKS document. Keys of
+// unsupported types are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set JWKSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var pub interface{}
+		switch {
+		case jwk.Kty == "RSA":
+			n, errN := unb64(jwk.N)
+			e, errE := unb64(jwk.E)
+			if errN != nil || errE != nil {
+				return nil, fmt.Errorf("invalid RSA key %q", jwk.Kid)
+			}
+			pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+			x, err := unb64(jwk.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("invalid Ed25519 key %q", jwk.Kid)
+			}
+			pub = ed25519.PublicKey(x)
+		default:
+			continue
+		}
+
+		key, err := NewKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.Kid != "" {
+			key.ID = jwk.Kid
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(nil, keys...)
+}
+
+// thumbprint computes the RFC 7638 thumbprint of a public JWK
+func thumbprint(jwk JWK) string {
+	var canonical string
+	switch jwk.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return b64(sum[:])
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func unb64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,366 @@
+// Package oidc implements the relying party side of the OpenID Connect
+// authorization code flow with PKCE (RFC 7636): provider discovery, the
+// authorization request, the code exchange and ID token verification against
+// the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
+)
+
+var (
+	ErrDiscovery       = errors.New("OIDC provider discovery failed")
+	ErrTokenExchange   = errors.New("OIDC code exchange failed")
+	ErrInvalidIDToken  = errors.New("OIDC ID token is invalid")
+	ErrMissingIDToken  = errors.New("OIDC token response has no ID token")
+	ErrPKCEUnsupported = errors.New("OIDC provider does not support S256 PKCE")
+)
+
+const (
+	// clockSkew is tolerated when checking exp, iat and nbf of ID tokens
+	clockSkew = time.Minute
+	// keyRefreshInterval limits how often unknown kids trigger a JWKS refetch
+	keyRefreshInterval = time.Minute
+)
+
+// Config describes the portal as a client of an OpenID provider
+type Config struct {
+	// IssuerURL is the provider's issuer; discovery reads
+	// IssuerURL/.well-known/openid-configuration
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes requested in addition to "openid"
+	Scopes     []string
+	HTTPClient *http.Client
+}
+
+// Metadata is the subset of the provider's discovery document the flow needs
+type Metadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// IDToken holds the verified claims of an ID token
+type IDToken struct {
+	Issuer  string
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+// String returns a string claim, or "" if it is missing or not a string
+func (t *IDToken) String(name string) string {
+	s, _ := t.Claims[name].(string)
+	return s
+}
+
+// Bool returns a boolean claim. Some providers send booleans as strings.
+func (t *IDToken) Bool(name string) bool {
+	switch v := t.Claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// Strings returns a claim holding a list of strings, such as group
+// memberships. A single string value is split on spaces and commas.
+func (t *IDToken) Strings(name string) []string {
+	switch v := t.Claims[name].(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return nil
+}
+
+// Provider is an OpenID provider. Its discovery document is fetched on first
+// use, so the portal starts even while the provider is unreachable.
+type Provider struct {
+	cfg Config
+
+	mu            sync.Mutex
+	meta          *Metadata
+	keys          *jwtkeys.KeySet
+	keysFetchedAt time.Time
+}
+
+// NewProvider creates a provider for the given client configuration
+func NewProvider(cfg Config) *Provider {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	return &Provider{cfg: cfg}
+}
+
+// Metadata returns the provider's discovery document, fetching it if needed
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.metadataLocked(ctx)
+}
+
+func (p *Provider) metadataLocked(ctx context.Context) (*Metadata, error) {
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta Metadata
+	if err := p.getJSON(ctx, p.cfg.IssuerURL+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscovery, meta.Issuer, p.cfg.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is incomplete", ErrDiscovery)
+	}
+	// Providers that do not advertise PKCE methods may still support it; only
+	// reject providers that advertise methods without S256
+	if len(meta.CodeChallengeMethodsSupported) > 0 && !contains(meta.CodeChallengeMethodsSupported, "S256") {
+		return nil, ErrPKCEUnsupported
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// AuthCodeURL returns the URL to send the user's browser to. state and nonce
+// bind the eventual callback and ID token to this login attempt; the code
+// verifier is kept by the portal and only its S256 challenge is sent.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code at the token endpoint
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret == "" {
+		// Public client: PKCE alone authenticates the exchange
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		if oauthErr.Error != "" {
+			return nil, fmt.Errorf("%w: %s %s", ErrTokenExchange, oauthErr.Error, oauthErr.ErrorDescription)
+		}
+		return nil, fmt.Errorf("%w: token endpoint returned %s", ErrTokenExchange, resp.Status)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	if token.IDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+	return &token, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, lifetime and nonce of
+// an ID token and returns its claims
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	parse := func(keys *jwtkeys.KeySet) error {
+		_, err := jwt.ParseWithClaims(raw, claims, keys.Keyfunc,
+			jwt.WithValidMethods(keys.Algorithms()),
+			jwt.WithIssuer(meta.Issuer),
+			jwt.WithAudience(p.cfg.ClientID),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(clockSkew),
+		)
+		return err
+	}
+
+	keys, err := p.verificationKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	err = parse(keys)
+	if err != nil && errors.Is(err, jwtkeys.ErrUnknownKey) {
+		// The provider may have rotated its signing key since we last looked
+		if keys, err = p.verificationKeys(ctx, true); err != nil {
+			return nil, err
+		}
+		err = parse(keys)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, fmt.Errorf("%w: token was issued to another client", ErrInvalidIDToken)
+		}
+	}
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	return &IDToken{Issuer: meta.Issuer, Subject: sub, Claims: claims}, nil
+}
+
+// verificationKeys returns the provider's signing keys. With refresh set they
+// are refetched, at most once per keyRefreshInterval.
+func (p *Provider) verificationKeys(ctx context.Context, refresh bool) (*jwtkeys.KeySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil && (!refresh || time.Since(p.keysFetchedAt) < keyRefreshInterval) {
+		return p.keys, nil
+	}
+	meta, err := p.metadataLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := p.getJSON(ctx, meta.JWKSURI, &raw); err != nil {
+		return nil, fmt.Errorf("%w: fetching keys: %v", ErrDiscovery, err)
+	}
+	keys, err := jwtkeys.ParseJWKS(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parsing keys: %v", ErrDiscovery, err)
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	return keys, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// NewCodeVerifier returns a random PKCE code verifier
+func NewCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of a code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
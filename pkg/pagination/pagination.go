@@ -0,0 +1,192 @@
+// Package pagination implements keyset (cursor) pagination for list endpoints.
+// Clients page through results with an opaque cursor instead of an offset, so
+// pages stay stable while rows are inserted and deep pages stay cheap.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	ErrInvalidSort   = errors.New("invalid sort key")
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// Request is the page a client asked for. Sort names a sort key, prefixed
+// with "-" for descending order; Cursor is the next_cursor of the previous page.
+type Request struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page; Total
+// counts every row matching the filters, across all pages.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+// Map converts the items of a page, keeping its cursor and total
+func Map[T, U any](p *Page[T], f func(*T) U) *Page[U] {
+	items := make([]U, 0, len(p.Items))
+	for i := range p.Items {
+		items = append(items, f(&p.Items[i]))
+	}
+	return &Page[U]{Items: items, NextCursor: p.NextCursor, Total: p.Total}
+}
+
+// Column is a sortable column. Cast is the SQL type cursor values are cast
+// to: timestamp, date, int or text. Value renders a row's value of the column
+// for the cursor.
+type Column[T any] struct {
+	Expr  string
+	Cast  string
+	Value func(*T) string
+}
+
+// Sorter describes the sort keys of a listing. Rows are always ordered by the
+// ID column last, so rows with equal sort values have a stable order.
+type Sorter[T any] struct {
+	Keys     map[string]Column[T]
+	Default  string
+	IDColumn string
+	ID       func(*T) int
+}
+
+// Query is the keyset part of a listing query, prepared from a Request
+type Query struct {
+	// Where selects the rows after the cursor; it is empty on the first page
+	Where   string
+	Args    []interface{}
+	OrderBy string
+	// Limit is one more than the page size, so the repository can tell
+	// whether another page follows
+	Limit int
+
+	sort  string
+	limit int
+}
+
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Prepare validates the request and builds the keyset clauses. nextArg is the
+// position of the first placeholder the clauses may use.
+func (s Sorter[T]) Prepare(req Request, nextArg int) (*Query, error) {
+	sort := req.Sort
+	if sort == "" {
+		sort = s.Default
+	}
+	desc := strings.HasPrefix(sort, "-")
+	col, ok := s.Keys[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSort, req.Sort)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	dir, op := "ASC", ">"
+	if desc {
+		dir, op = "DESC", "<"
+	}
+	q := &Query{
+		OrderBy: fmt.Sprintf("%s %s, %s %s", col.Expr, dir, s.IDColumn, dir),
+		Limit:   limit + 1,
+		sort:    sort,
+		limit:   limit,
+	}
+
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// A cursor only makes sense for the order it was issued for
+		if c.Sort != sort || !validValue(col.Cast, c.Value) {
+			return nil, ErrInvalidCursor
+		}
+		q.Where = fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", col.Expr, s.IDColumn, op, nextArg, col.Cast, nextArg+1)
+		q.Args = []interface{}{c.Value, c.ID}
+	}
+	return q, nil
+}
+
+// Page trims the rows fetched with q to the page size and sets the cursor of
+// the next page
+func (s Sorter[T]) Page(q *Query, rows []T, total int) *Page[T] {
+	if rows == nil {
+		rows = []T{}
+	}
+	page := &Page[T]{Items: rows, Total: total}
+	if len(rows) > q.limit {
+		page.Items = rows[:q.limit]
+		last := &page.Items[q.limit-1]
+		col := s.Keys[strings.TrimPrefix(q.sort, "-")]
+		page.NextCursor = encodeCursor(cursor{Sort: q.sort, Value: col.Value(last), ID: s.ID(last)})
+	}
+	return page
+}
+
+const (
+	timeLayout = "2006-01-02 15:04:05.999999"
+	dateLayout = "2006-01-02"
+)
+
+// FormatTime renders a TIMESTAMP column value for a cursor without losing precision
+func FormatTime(t time.Time) string {
+	return t.Format(timeLayout)
+}
+
+// validValue reports whether a cursor value can be cast to the SQL type, so
+// that a tampered cursor is rejected rather than failing the query
+func validValue(cast, value string) bool {
+	var err error
+	switch cast {
+	case "timestamp":
+		_, err = time.Parse(timeLayout, value)
+	case "date":
+		_, err = time.Parse(dateLayout, value)
+	case "int":
+		_, err = strconv.ParseInt(value, 10, 64)
+	}
+	return err == nil
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
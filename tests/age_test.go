@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+func TestAgeAt(t *testing.T) {
+	now := time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		dob  time.Time
+		want patient.Age
+	}{
+		{date(1980, 3, 15), patient.Age{Value: 46, Unit: patient.AgeYears}},
+		{date(1980, 3, 16), patient.Age{Value: 45, Unit: patient.AgeYears}},
+		{date(2024, 3, 15), patient.Age{Value: 2, Unit: patient.AgeYears}},
+		{date(2024, 3, 16), patient.Age{Value: 23, Unit: patient.AgeMonths}},
+		{date(2026, 2, 15), patient.Age{Value: 1, Unit: patient.AgeMonths}},
+		{date(2026, 2, 20), patient.Age{Value: 3, Unit: patient.AgeWeeks}},
+		{date(2026, 3, 12), patient.Age{Value: 3, Unit: patient.AgeDays}},
+		{date(2026, 3, 15), patient.Age{Value: 0, Unit: patient.AgeDays}},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, patient.AgeAt(tc.dob, now), tc.dob.Format(patient.DateLayout))
+	}
+	assert.Equal(t, "3 weeks", patient.AgeAt(date(2026, 2, 20), now).String())
+}
+
+func TestParseDateOfBirth(t *testing.T) {
+	now := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+
+	dob, err := patient.ParseDateOfBirth("2026-03-15", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, dob.Day())
+
+	_, err = patient.ParseDateOfBirth("1895-03-16", now)
+	assert.NoError(t, err)
+
+	for _, s := range []string{"2026-03-16", "1895-03-15", "15/03/1990"} {
+		_, err = patient.ParseDateOfBirth(s, now)
+		assert.ErrorIs(t, err, patient.ErrInvalidDateOfBirth, s)
+	}
+}
+
+func TestCreatePatient_RejectsFutureDateOfBirth(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+
+	future := time.Now().AddDate(0, 0, 2).Format(patient.DateLayout)
+	_, err := svc.CreatePatient(context.Background(), testReceptionist, patient.CreatePatientRequest{Name: "Baby Doe", DateOfBirth: future, Address: "1 Main St"})
+	assert.ErrorIs(t, err, patient.ErrInvalidDateOfBirth)
+
+	_, err = svc.UpdatePatient(context.Background(), testReceptionist, 3, patient.UpdatePatientRequest{Name: "Baby Doe", DateOfBirth: future, Address: "1 Main St"})
+	assert.ErrorIs(t, err, patient.ErrInvalidDateOfBirth)
+	repo.AssertNotCalled(t, "FindDuplicates", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
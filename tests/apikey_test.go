@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+type mockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *mockAPIKeyRepository) CreateAPIKey(ctx context.Context, key *auth.APIKey, ttl time.Duration) error {
+	return m.Called(ctx, key, ttl).Error(0)
+}
+func (m *mockAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]auth.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]auth.APIKey), args.Error(1)
+}
+func (m *mockAPIKeyRepository) GetAPIKeyByID(ctx context.Context, id int) (*auth.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.APIKey), args.Error(1)
+}
+func (m *mockAPIKeyRepository) UseAPIKey(ctx context.Context, keyHash string) (*auth.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.APIKey), args.Error(1)
+}
+func (m *mockAPIKeyRepository) RevokeAPIKey(ctx context.Context, id, revokedBy int) error {
+	return m.Called(ctx, id, revokedBy).Error(0)
+}
+func (m *mockAPIKeyRepository) RecordAPIKeyRequest(ctx context.Context, r *auth.APIKeyRequest) error {
+	return m.Called(ctx, r).Error(0)
+}
+func (m *mockAPIKeyRepository) ListAPIKeyRequests(ctx context.Context, keyID, limit int) ([]auth.APIKeyRequest, error) {
+	args := m.Called(ctx, keyID, limit)
+	return args.Get(0).([]auth.APIKeyRequest), args.Error(1)
+}
+
+func newTestAPIKeyService(keys *mockAPIKeyRepository) auth.APIKeyService {
+	permissions := new(mockPermissionRepository)
+	permissions.On("ListPermissions", mock.Anything).Return(testPermissionCatalog, nil)
+	return auth.NewAPIKeyService(keys, authz.NewService(permissions))
+}
+
+func TestCreateAPIKey_StoresOnlyHash(t *testing.T) {
+	keys := new(mockAPIKeyRepository)
+	svc := newTestAPIKeyService(keys)
+
+	var stored *auth.APIKey
+	keys.On("CreateAPIKey", mock.Anything, mock.Anything, 30*24*time.Hour).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*auth.APIKey)
+		stored.ID = 7
+	}).Return(nil)
+
+	created, err := svc.CreateAPIKey(context.Background(), 1, auth.CreateAPIKeyRequest{
+		Name:          "Lab system",
+		Scopes:        []string{authz.PatientRead, authz.PatientCreate, authz.PatientRead},
+		ExpiresInDays: 30,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(created.Key, auth.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(created.Key, stored.Prefix))
+	sum := sha256.Sum256([]byte(created.Key))
+	assert.Equal(t, hex.EncodeToString(sum[:]), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, created.Key)
+	assert.Equal(t, []string{authz.PatientCreate, authz.PatientRead}, []string(stored.Scopes))
+	assert.Equal(t, 1, *stored.CreatedBy)
+}
+
+func TestCreateAPIKey_RejectsScopes(t *testing.T) {
+	keys := new(mockAPIKeyRepository)
+	svc := newTestAPIKeyService(keys)
+
+	_, err := svc.CreateAPIKey(context.Background(), 1, auth.CreateAPIKeyRequest{Name: "x", Scopes: []string{"patient:everything"}})
+	assert.ErrorIs(t, err, auth.ErrUnknownScope)
+
+	for _, scope := range []string{authz.RoleManage, authz.PrescriptionCreate} {
+		_, err = svc.CreateAPIKey(context.Background(), 1, auth.CreateAPIKeyRequest{Name: "x", Scopes: []string{scope}})
+		assert.ErrorIs(t, err, auth.ErrScopeNotAllowed, scope)
+	}
+	keys.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	keys := new(mockAPIKeyRepository)
+	svc := newTestAPIKeyService(keys)
+	tokens := new(mockAuthService)
+	roles := new(mockPermissionRepository)
+
+	const key = auth.APIKeyPrefix + "0123456789abcdef"
+	sum := sha256.Sum256([]byte(key))
+	keys.On("UseAPIKey", mock.Anything, hex.EncodeToString(sum[:])).Return(&auth.APIKey{ID: 7, Name: "Lab system", Scopes: []string{authz.PatientRead}}, nil)
+	keys.On("UseAPIKey", mock.Anything, mock.Anything).Return(nil, auth.ErrInvalidAPIKey)
+	keys.On("RecordAPIKeyRequest", mock.Anything, mock.Anything).Return(nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware(auth.NewCredentialVerifier(tokens, svc)), auth.APIKeyAuditMiddleware(svc), middleware.LoadPermissions(authz.NewService(roles)))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/patients", middleware.RequirePermission(authz.PatientRead), ok)
+	r.POST("/patients", middleware.RequirePermission(authz.PatientCreate), ok)
+	r.PUT("/me/password", middleware.UserOnlyMiddleware(), ok)
+
+	cases := []struct {
+		method, path, header, value string
+		want                        int
+	}{
+		{"GET", "/patients", "X-API-Key", key, http.StatusOK},
+		{"GET", "/patients", "Authorization", "Bearer " + key, http.StatusOK},
+		{"POST", "/patients", "X-API-Key", key, http.StatusForbidden},
+		{"PUT", "/me/password", "X-API-Key", key, http.StatusForbidden},
+		{"GET", "/patients", "X-API-Key", auth.APIKeyPrefix + "revoked", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set(tc.header, tc.value)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.want, w.Code, "%s %s", tc.method, tc.path)
+	}
+
+	// Keys are never checked as JWTs, and their permissions come from their scopes, not a role
+	tokens.AssertNotCalled(t, "VerifyAccessToken", mock.Anything, mock.Anything)
+	roles.AssertNotCalled(t, "GetRolePermissions", mock.Anything, mock.Anything)
+
+	// Every authenticated request, allowed or not, is attributed to the key
+	keys.AssertNumberOfCalls(t, "RecordAPIKeyRequest", 4)
+	keys.AssertCalled(t, "RecordAPIKeyRequest", mock.Anything, mock.MatchedBy(func(e *auth.APIKeyRequest) bool {
+		return e.APIKeyID == 7 && e.Method == "POST" && e.Path == "/patients" && e.Status == http.StatusForbidden
+	}))
+}
@@ -13,15 +13,48 @@ import (
     "github.com/stretchr/testify/mock"
 
     "github.com/kyash99252/Medical-Portal/internal/auth"
+    "github.com/kyash99252/Medical-Portal/internal/middleware"
 )
 
 type mockAuthService struct {
     mock.Mock
 }
 
-func (m *mockAuthService) Login(ctx context.Context, username, password string) (string, error) {
+func (m *mockAuthService) Login(ctx context.Context, username, password string, client auth.ClientInfo) (*auth.LoginResult, error) {
     args := m.Called(ctx, username, password)
-    return args.String(0), args.Error(1)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*auth.LoginResult), args.Error(1)
+}
+func (m *mockAuthService) StartSession(ctx context.Context, user *auth.User, client auth.ClientInfo) (*auth.TokenPair, error) {
+    args := m.Called(ctx, user, client)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*auth.TokenPair), args.Error(1)
+}
+func (m *mockAuthService) Refresh(ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
+    args := m.Called(ctx, refreshToken)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*auth.TokenPair), args.Error(1)
+}
+func (m *mockAuthService) Logout(ctx context.Context, identity *middleware.Identity) error {
+    args := m.Called(ctx, identity)
+    return args.Error(0)
+}
+func (m *mockAuthService) LogoutAll(ctx context.Context, userID int) error {
+    args := m.Called(ctx, userID)
+    return args.Error(0)
+}
+func (m *mockAuthService) VerifyAccessToken(ctx context.Context, token string) (*middleware.Identity, error) {
+    args := m.Called(ctx, token)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*middleware.Identity), args.Error(1)
 }
 func (m *mockAuthService) HashPassword(password string) (string, error) {
     args := m.Called(password)
@@ -37,13 +70,14 @@ func TestLogin_Success(t *testing.T) {
     h := auth.NewHandler(mockSvc)
 
     req := auth.LoginRequest{Username: "user", Password: "pass"}
-    mockSvc.On("Login", mock.Anything, req.Username, req.Password).Return("token123", nil)
+    mockSvc.On("Login", mock.Anything, req.Username, req.Password).Return(&auth.LoginResult{Tokens: &auth.TokenPair{AccessToken: "token123", RefreshToken: "refresh123", ExpiresIn: 900}}, nil)
 
     // Use gin's test context
     w := performRequest(h.Login, "POST", req)
 
     assert.Equal(t, 200, w.Code)
     assert.Contains(t, w.Body.String(), "token123")
+    assert.Contains(t, w.Body.String(), "refresh123")
 }
 
 func TestLogin_InvalidCredentials(t *testing.T) {
@@ -51,7 +85,7 @@ func TestLogin_InvalidCredentials(t *testing.T) {
     h := auth.NewHandler(mockSvc)
 
     req := auth.LoginRequest{Username: "user", Password: "wrong"}
-    mockSvc.On("Login", mock.Anything, req.Username, req.Password).Return("", auth.ErrInvalidCredentials)
+    mockSvc.On("Login", mock.Anything, req.Username, req.Password).Return(nil, auth.ErrInvalidCredentials)
 
     w := performRequest(h.Login, "POST", req)
 
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+type mockPermissionRepository struct {
+	mock.Mock
+}
+
+func (m *mockPermissionRepository) ListRoles(ctx context.Context) ([]authz.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]authz.Role), args.Error(1)
+}
+func (m *mockPermissionRepository) ListPermissions(ctx context.Context) ([]authz.Permission, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]authz.Permission), args.Error(1)
+}
+func (m *mockPermissionRepository) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockPermissionRepository) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	return m.Called(ctx, role, permissions).Error(0)
+}
+
+var testPermissionCatalog = []authz.Permission{
+	{Name: authz.PatientRead}, {Name: authz.PatientCreate}, {Name: authz.PrescriptionCreate}, {Name: authz.RoleManage},
+}
+
+func TestRequirePermission(t *testing.T) {
+	repo := new(mockPermissionRepository)
+	svc := authz.NewService(repo)
+	repo.On("GetRolePermissions", mock.Anything, "receptionist").Return([]string{authz.PatientCreate, authz.PatientRead}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{UserID: 1, Role: "receptionist"})
+	}, middleware.LoadPermissions(svc))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/patients", middleware.RequirePermission(authz.PatientCreate), ok)
+	r.POST("/patients/1/prescriptions", middleware.RequirePermission(authz.PrescriptionCreate), ok)
+
+	for path, want := range map[string]int{
+		"/patients":                 http.StatusOK,
+		"/patients/1/prescriptions": http.StatusForbidden,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, path)
+	}
+
+	// Permissions are cached between requests
+	repo.AssertNumberOfCalls(t, "GetRolePermissions", 1)
+}
+
+func TestRolePermissions_UnknownRoleGrantsNothing(t *testing.T) {
+	repo := new(mockPermissionRepository)
+	svc := authz.NewService(repo)
+	repo.On("GetRolePermissions", mock.Anything, "janitor").Return(nil, authz.ErrRoleNotFound)
+
+	permissions, err := svc.RolePermissions(context.Background(), "janitor")
+
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+}
+
+func TestUpdateRolePermissions_InvalidatesCache(t *testing.T) {
+	repo := new(mockPermissionRepository)
+	svc := authz.NewService(repo)
+
+	repo.On("GetRolePermissions", mock.Anything, "doctor").Return([]string{authz.PatientRead}, nil).Once()
+	_, err := svc.RolePermissions(context.Background(), "doctor")
+	require.NoError(t, err)
+
+	repo.On("ListPermissions", mock.Anything).Return(testPermissionCatalog, nil)
+	repo.On("SetRolePermissions", mock.Anything, "doctor", []string{authz.PatientRead, authz.PrescriptionCreate}).Return(nil)
+	repo.On("ListRoles", mock.Anything).Return([]authz.Role{{Name: "doctor", Permissions: []string{authz.PatientRead, authz.PrescriptionCreate}}}, nil)
+
+	role, err := svc.UpdateRolePermissions(context.Background(), "admin", "doctor", []string{authz.PrescriptionCreate, authz.PatientRead, authz.PatientRead})
+	require.NoError(t, err)
+	assert.Equal(t, "doctor", role.Name)
+
+	repo.On("GetRolePermissions", mock.Anything, "doctor").Return([]string{authz.PatientRead, authz.PrescriptionCreate}, nil).Once()
+	permissions, err := svc.RolePermissions(context.Background(), "doctor")
+	require.NoError(t, err)
+	assert.Contains(t, permissions, authz.PrescriptionCreate)
+}
+
+func TestUpdateRolePermissions_Validation(t *testing.T) {
+	repo := new(mockPermissionRepository)
+	svc := authz.NewService(repo)
+	repo.On("ListPermissions", mock.Anything).Return(testPermissionCatalog, nil)
+
+	_, err := svc.UpdateRolePermissions(context.Background(), "admin", "doctor", []string{"patient:teleport"})
+	assert.ErrorIs(t, err, authz.ErrUnknownPermission)
+
+	_, err = svc.UpdateRolePermissions(context.Background(), "admin", "admin", []string{authz.PatientRead})
+	assert.ErrorIs(t, err, authz.ErrCannotRemoveOwnAdmin)
+
+	repo.AssertNotCalled(t, "SetRolePermissions", mock.Anything, mock.Anything, mock.Anything)
+}
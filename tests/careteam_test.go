@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/prescription"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+type mockCareTeamRepository struct {
+	mock.Mock
+}
+
+func (m *mockCareTeamRepository) ListMembers(ctx context.Context, patientID int) ([]careteam.Member, error) {
+	args := m.Called(ctx, patientID)
+	return args.Get(0).([]careteam.Member), args.Error(1)
+}
+func (m *mockCareTeamRepository) IsMember(ctx context.Context, patientID, doctorID int) (bool, error) {
+	args := m.Called(ctx, patientID, doctorID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockCareTeamRepository) IsActiveDoctor(ctx context.Context, userID int) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockCareTeamRepository) AddMember(ctx context.Context, member *careteam.Member) error {
+	return m.Called(ctx, member).Error(0)
+}
+func (m *mockCareTeamRepository) RemoveMember(ctx context.Context, patientID, doctorID int) error {
+	return m.Called(ctx, patientID, doctorID).Error(0)
+}
+
+type mockPrescriptionRepository struct {
+	mock.Mock
+}
+
+func (m *mockPrescriptionRepository) Create(ctx context.Context, p *prescription.Prescription, durationDays *int) error {
+	return m.Called(ctx, p, durationDays).Error(0)
+}
+func (m *mockPrescriptionRepository) GetByPatientID(ctx context.Context, patientID int, req pagination.Request) (*pagination.Page[prescription.Prescription], error) {
+	args := m.Called(ctx, patientID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[prescription.Prescription]), args.Error(1)
+}
+func (m *mockPrescriptionRepository) GetActiveByPatientID(ctx context.Context, patientID int) ([]prescription.Prescription, error) {
+	args := m.Called(ctx, patientID)
+	return args.Get(0).([]prescription.Prescription), args.Error(1)
+}
+
+var (
+	testDoctor = &middleware.Identity{
+		UserID:      5,
+		Role:        "doctor",
+		Permissions: []string{authz.PatientRead, authz.PrescriptionCreate},
+	}
+	testReceptionist = &middleware.Identity{
+		UserID:      2,
+		Role:        "receptionist",
+		Permissions: []string{authz.PatientRead, authz.PatientAccessAll, authz.CareTeamManage},
+	}
+)
+
+func TestCheckPatientAccess(t *testing.T) {
+	repo := new(mockCareTeamRepository)
+	svc := careteam.NewService(repo, new(mockEmergencyRepository))
+	ctx := context.Background()
+
+	repo.On("IsMember", mock.Anything, 10, 5).Return(true, nil)
+	repo.On("IsMember", mock.Anything, 11, 5).Return(false, nil)
+
+	assert.NoError(t, svc.CheckPatientAccess(ctx, testDoctor, 10))
+	assert.ErrorIs(t, svc.CheckPatientAccess(ctx, testDoctor, 11), careteam.ErrAccessDenied)
+
+	// Broad access does not consult the care team
+	assert.NoError(t, svc.CheckPatientAccess(ctx, testReceptionist, 11))
+	repo.AssertNotCalled(t, "IsMember", mock.Anything, 11, 2)
+
+	apiKey := &middleware.Identity{APIKeyID: 3, Permissions: []string{authz.PatientRead}}
+	assert.ErrorIs(t, svc.CheckPatientAccess(ctx, apiKey, 10), careteam.ErrAccessDenied)
+	assert.ErrorIs(t, svc.CheckPatientAccess(ctx, nil, 10), careteam.ErrAccessDenied)
+}
+
+func TestAddCareTeamMember_RequiresDoctor(t *testing.T) {
+	repo := new(mockCareTeamRepository)
+	svc := careteam.NewService(repo, new(mockEmergencyRepository))
+
+	repo.On("IsActiveDoctor", mock.Anything, 2).Return(false, nil)
+	repo.On("IsActiveDoctor", mock.Anything, 5).Return(true, nil)
+	repo.On("AddMember", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.AddMember(context.Background(), 2, 10, careteam.AddMemberRequest{DoctorID: 2})
+	assert.ErrorIs(t, err, careteam.ErrNotADoctor)
+
+	member, err := svc.AddMember(context.Background(), 2, 10, careteam.AddMemberRequest{DoctorID: 5, Primary: true})
+	require.NoError(t, err)
+	assert.Equal(t, 10, member.PatientID)
+	assert.True(t, member.IsPrimary)
+	require.NotNil(t, member.AssignedBy)
+	assert.Equal(t, 2, *member.AssignedBy)
+}
+
+func TestCreatePrescription_DeniedOutsideCareTeam(t *testing.T) {
+	teams := new(mockCareTeamRepository)
+	prescriptions := new(mockPrescriptionRepository)
+	svc := prescription.NewService(prescriptions, careteam.NewService(teams, new(mockEmergencyRepository)))
+
+	teams.On("IsMember", mock.Anything, 10, 5).Return(true, nil)
+	teams.On("IsMember", mock.Anything, 11, 5).Return(false, nil)
+	prescriptions.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	req := prescription.CreateRequest{Medication: "Amoxicillin", Dosage: "500mg", Frequency: "3x daily"}
+
+	_, err := svc.CreatePrescription(context.Background(), testDoctor, 11, req)
+	assert.ErrorIs(t, err, careteam.ErrAccessDenied)
+	prescriptions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+
+	p, err := svc.CreatePrescription(context.Background(), testDoctor, 10, req)
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.DoctorID)
+
+	// API keys have no doctor to prescribe as
+	apiKey := &middleware.Identity{APIKeyID: 3, Permissions: []string{authz.PatientAccessAll, authz.PrescriptionCreate}}
+	_, err = svc.CreatePrescription(context.Background(), apiKey, 10, req)
+	assert.ErrorIs(t, err, prescription.ErrNoPrescriber)
+	prescriptions.AssertNumberOfCalls(t, "Create", 1)
+}
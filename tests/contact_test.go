@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+type mockContactRepository struct {
+	mock.Mock
+}
+
+func (m *mockContactRepository) ListContacts(ctx context.Context, patientID int) ([]patient.EmergencyContact, error) {
+	args := m.Called(ctx, patientID)
+	return args.Get(0).([]patient.EmergencyContact), args.Error(1)
+}
+func (m *mockContactRepository) CreateContact(ctx context.Context, c *patient.EmergencyContact) error {
+	return m.Called(ctx, c).Error(0)
+}
+func (m *mockContactRepository) UpdateContact(ctx context.Context, c *patient.EmergencyContact) error {
+	return m.Called(ctx, c).Error(0)
+}
+func (m *mockContactRepository) DeleteContact(ctx context.Context, patientID, id int) error {
+	return m.Called(ctx, patientID, id).Error(0)
+}
+
+func TestAddContact_Validates(t *testing.T) {
+	repo := new(mockContactRepository)
+	teams := new(mockCareTeamRepository)
+	svc := patient.NewContactService(repo, careteam.NewService(teams, new(mockEmergencyRepository)))
+	ctx := context.Background()
+
+	email := " Mary@Example.com "
+	req := patient.EmergencyContactRequest{Name: " Mary Doe ", Relationship: "Spouse", PhoneNumber: "+1 (555) 010-0199", Email: &email, IsPrimary: true}
+
+	repo.On("ListContacts", mock.Anything, 10).Return([]patient.EmergencyContact{}, nil)
+	repo.On("CreateContact", mock.Anything, mock.MatchedBy(func(c *patient.EmergencyContact) bool {
+		return c.PatientID == 10 && c.Name == "Mary Doe" && c.Relationship == "spouse" && *c.Email == "mary@example.com" && c.IsPrimary
+	})).Return(nil)
+	c, err := svc.AddContact(ctx, testReceptionist, 10, req)
+	require.NoError(t, err)
+	assert.Equal(t, "+1 (555) 010-0199", c.PhoneNumber)
+
+	bad := req
+	bad.PhoneNumber = "call me"
+	_, err = svc.AddContact(ctx, testReceptionist, 10, bad)
+	assert.ErrorIs(t, err, patient.ErrInvalidContactPhone)
+
+	bad = req
+	bad.Relationship = "  "
+	_, err = svc.AddContact(ctx, testReceptionist, 10, bad)
+	assert.ErrorIs(t, err, patient.ErrInvalidContact)
+
+	repo.On("ListContacts", mock.Anything, 11).Return(make([]patient.EmergencyContact, patient.MaxEmergencyContacts), nil)
+	_, err = svc.AddContact(ctx, testReceptionist, 11, req)
+	assert.ErrorIs(t, err, patient.ErrTooManyContacts)
+
+	// Doctors only manage contacts of patients on their care team
+	teams.On("IsMember", mock.Anything, 12, 5).Return(false, nil)
+	_, err = svc.AddContact(ctx, testDoctor, 12, req)
+	assert.ErrorIs(t, err, careteam.ErrAccessDenied)
+	repo.AssertNumberOfCalls(t, "CreateContact", 1)
+}
+
+func TestContactHandler_Errors(t *testing.T) {
+	repo := new(mockContactRepository)
+	h := patient.NewContactHandler(patient.NewContactService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository))))
+
+	repo.On("DeleteContact", mock.Anything, 10, 3).Return(patient.ErrContactNotFound)
+	repo.On("DeleteContact", mock.Anything, 10, 4).Return(nil)
+	repo.On("UpdateContact", mock.Anything, mock.Anything).Return(patient.ErrContactNotFound)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/patients/:id/emergency-contacts/:contact_id", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.UpdateContact)
+	r.DELETE("/patients/:id/emergency-contacts/:contact_id", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.DeleteContact)
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{"DELETE", "/patients/10/emergency-contacts/3", "", http.StatusNotFound},
+		{"DELETE", "/patients/10/emergency-contacts/4", "", http.StatusNoContent},
+		{"DELETE", "/patients/10/emergency-contacts/x", "", http.StatusBadRequest},
+		{"PUT", "/patients/10/emergency-contacts/3", `{"name":"Mary","relationship":"sister","phone_number":"5550100"}`, http.StatusNotFound},
+		{"PUT", "/patients/10/emergency-contacts/3", `{"name":"Mary","relationship":"sister"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.method+" "+tc.path)
+	}
+}
+
+func TestCreatePatient_NormalizesDetails(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+
+	email, city, occupation := "John.Doe@Example.COM", "  Lisbon ", "   "
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(p *patient.Patient) bool {
+		return *p.Email == "john.doe@example.com" && *p.City == "Lisbon" && p.Occupation == nil
+	})).Return(nil)
+
+	_, err := svc.CreatePatient(context.Background(), testReceptionist, patient.CreatePatientRequest{
+		Name: "John Doe", DateOfBirth: "1980-01-02", Address: "1 Rua Augusta", ConfirmDuplicate: true,
+		Details: patient.Details{Email: &email, City: &city, Occupation: &occupation},
+	})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePatientHandler_ValidatesDetails(t *testing.T) {
+	h := patient.NewHandler(new(mockPatientService))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/patients", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.CreatePatient)
+
+	for _, field := range []string{`"sex":"robot"`, `"country":"Portugal"`, `"email":"not-an-email"`, `"marital_status":"complicated"`, `"preferred_language":"not a tag"`} {
+		body := `{"name":"John Doe","date_of_birth":"1980-01-02","address":"1 Rua Augusta",` + field + `}`
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/patients", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, field)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+)
+
+type mockEmergencyRepository struct {
+	mock.Mock
+}
+
+func (m *mockEmergencyRepository) CreateGrant(ctx context.Context, g *careteam.Grant, ttl time.Duration) error {
+	return m.Called(ctx, g, ttl).Error(0)
+}
+func (m *mockEmergencyRepository) GetGrant(ctx context.Context, id int) (*careteam.Grant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*careteam.Grant), args.Error(1)
+}
+func (m *mockEmergencyRepository) HasActiveGrant(ctx context.Context, patientID, doctorID int) (bool, error) {
+	args := m.Called(ctx, patientID, doctorID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockEmergencyRepository) ListGrants(ctx context.Context, pendingOnly bool) ([]careteam.Grant, error) {
+	args := m.Called(ctx, pendingOnly)
+	return args.Get(0).([]careteam.Grant), args.Error(1)
+}
+func (m *mockEmergencyRepository) ReviewGrant(ctx context.Context, id, reviewerID int, note *string, revoke bool) error {
+	return m.Called(ctx, id, reviewerID, note, revoke).Error(0)
+}
+
+func TestCheckPatientAccess_HonorsEmergencyGrant(t *testing.T) {
+	teams := new(mockCareTeamRepository)
+	grants := new(mockEmergencyRepository)
+	svc := careteam.NewService(teams, grants)
+	ctx := context.Background()
+
+	erDoctor := &middleware.Identity{
+		UserID:      8,
+		Role:        "doctor",
+		Permissions: []string{authz.PatientRead, authz.EmergencyAccess},
+	}
+	teams.On("IsMember", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	grants.On("HasActiveGrant", mock.Anything, 10, 8).Return(true, nil)
+	grants.On("HasActiveGrant", mock.Anything, 11, 8).Return(false, nil)
+
+	assert.NoError(t, svc.CheckPatientAccess(ctx, erDoctor, 10))
+	assert.ErrorIs(t, svc.CheckPatientAccess(ctx, erDoctor, 11), careteam.ErrAccessDenied)
+
+	// Grants stop counting once the permission is taken away
+	revoked := &middleware.Identity{UserID: 8, Role: "doctor", Permissions: []string{authz.PatientRead}}
+	assert.ErrorIs(t, svc.CheckPatientAccess(ctx, revoked, 10), careteam.ErrAccessDenied)
+}
+
+func TestBreakGlass_RecordsReasonAndTTL(t *testing.T) {
+	grants := new(mockEmergencyRepository)
+	svc := careteam.NewEmergencyService(grants, 2*time.Hour)
+
+	var stored *careteam.Grant
+	grants.On("CreateGrant", mock.Anything, mock.Anything, 2*time.Hour).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*careteam.Grant)
+		stored.ID = 4
+	}).Return(nil)
+
+	grant, err := svc.BreakGlass(context.Background(), testDoctor, 10,
+		careteam.BreakGlassRequest{Reason: "  Unconscious patient in ER  "}, "10.0.0.7")
+	require.NoError(t, err)
+	assert.Equal(t, 4, grant.ID)
+	assert.Equal(t, 5, stored.DoctorID)
+	assert.Equal(t, 10, stored.PatientID)
+	assert.Equal(t, "Unconscious patient in ER", stored.Reason)
+	require.NotNil(t, stored.IPAddress)
+	assert.Equal(t, "10.0.0.7", *stored.IPAddress)
+
+	_, err = svc.BreakGlass(context.Background(), &middleware.Identity{APIKeyID: 3}, 10,
+		careteam.BreakGlassRequest{Reason: "Integration needs access"}, "")
+	assert.ErrorIs(t, err, careteam.ErrAccessDenied)
+}
+
+func TestReviewGrant_RejectsSelfReview(t *testing.T) {
+	grants := new(mockEmergencyRepository)
+	svc := careteam.NewEmergencyService(grants, 0)
+
+	grants.On("GetGrant", mock.Anything, 4).Return(&careteam.Grant{ID: 4, DoctorID: 5}, nil)
+	grants.On("ReviewGrant", mock.Anything, 4, 1, mock.Anything, true).Return(nil)
+
+	_, err := svc.ReviewGrant(context.Background(), 5, 4, careteam.ReviewGrantRequest{})
+	assert.ErrorIs(t, err, careteam.ErrSelfReview)
+	grants.AssertNotCalled(t, "ReviewGrant", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	_, err = svc.ReviewGrant(context.Background(), 1, 4, careteam.ReviewGrantRequest{Revoke: true})
+	require.NoError(t, err)
+	grants.AssertCalled(t, "ReviewGrant", mock.Anything, 4, 1, mock.Anything, true)
+}
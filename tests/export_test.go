@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+type mockExportRepository struct {
+	mock.Mock
+}
+
+func (m *mockExportRepository) CreateExport(ctx context.Context, e *patient.Export) error {
+	args := m.Called(ctx, e)
+	e.ID = 1
+	return args.Error(0)
+}
+func (m *mockExportRepository) GetExport(ctx context.Context, id int) (*patient.Export, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Export), args.Error(1)
+}
+func (m *mockExportRepository) GetExportContent(ctx context.Context, id int) ([]byte, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]byte), args.Error(1)
+}
+func (m *mockExportRepository) ClaimExport(ctx context.Context, staleAfter time.Duration) (*patient.Export, error) {
+	args := m.Called(ctx, staleAfter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Export), args.Error(1)
+}
+func (m *mockExportRepository) CompleteExport(ctx context.Context, id, rowCount int, content []byte, ttl time.Duration) error {
+	return m.Called(ctx, id, rowCount, content, ttl).Error(0)
+}
+func (m *mockExportRepository) FailExport(ctx context.Context, id int, reason string, ttl time.Duration) error {
+	return m.Called(ctx, id, reason, ttl).Error(0)
+}
+func (m *mockExportRepository) DeleteExpiredExports(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func exportPatient(id int, name string) patient.Patient {
+	diagnosis := "Asthma"
+	return patient.Patient{
+		ID:          id,
+		Name:        name,
+		DateOfBirth: time.Date(1980, 3, 4, 0, 0, 0, 0, time.UTC),
+		Address:     "1 Main St",
+		Diagnosis:   &diagnosis,
+	}
+}
+
+func TestExportPatients_CSVByPage(t *testing.T) {
+	repo, patients := new(mockExportRepository), new(mockPatientRepository)
+	svc := patient.NewExportService(repo, patients, time.Hour)
+
+	patients.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.Cursor == "" && o.Limit == pagination.MaxLimit })).
+		Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{exportPatient(1, "Ann Lee")}, NextCursor: "next", Total: 2}, nil)
+	patients.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.Cursor == "next" })).
+		Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{exportPatient(2, "Bo Chen")}, Total: 2}, nil)
+
+	var buf bytes.Buffer
+	e, err := svc.ExportPatients(context.Background(), testReceptionist, patient.ExportRequest{Format: patient.ExportCSV}, &buf)
+	require.NoError(t, err)
+	assert.Nil(t, e)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "ID,MRN,Name,Date of birth"))
+	assert.NotContains(t, buf.String(), "Diagnosis")
+	assert.NotContains(t, buf.String(), "Asthma")
+	assert.Contains(t, lines[2], "Bo Chen")
+
+	buf.Reset()
+	_, err = svc.ExportPatients(context.Background(), testClinician, patient.ExportRequest{Format: patient.ExportCSV}, &buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Diagnosis,Notes")
+	assert.Contains(t, buf.String(), "Asthma")
+}
+
+func TestExportPatients_RestrictsDoctorsToTheirPanel(t *testing.T) {
+	repo, patients := new(mockExportRepository), new(mockPatientRepository)
+	svc := patient.NewExportService(repo, patients, time.Hour)
+
+	patients.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.PanelOf == 5 })).
+		Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{}}, nil)
+
+	var buf bytes.Buffer
+	_, err := svc.ExportPatients(context.Background(), testDoctor, patient.ExportRequest{Format: patient.ExportCSV, ListOptions: patient.ListOptions{PanelOf: 99}}, &buf)
+	require.NoError(t, err)
+	patients.AssertExpectations(t)
+}
+
+func TestExportPatients_QueuesLargeExports(t *testing.T) {
+	repo, patients := new(mockExportRepository), new(mockPatientRepository)
+	svc := patient.NewExportService(repo, patients, time.Hour)
+
+	patients.On("List", mock.Anything, mock.Anything).
+		Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{exportPatient(1, "Ann Lee")}, Total: patient.ExportSyncLimit + 1}, nil)
+	repo.On("CreateExport", mock.Anything, mock.MatchedBy(func(e *patient.Export) bool {
+		return e.Format == patient.ExportXLSX && e.Status == patient.ExportPending && !e.Clinical && *e.RequestedBy == 2
+	})).Return(nil)
+
+	var buf bytes.Buffer
+	e, err := svc.ExportPatients(context.Background(), testReceptionist, patient.ExportRequest{Format: patient.ExportXLSX}, &buf)
+	require.NoError(t, err)
+	require.NotNil(t, e)
+	assert.Equal(t, 1, e.ID)
+	assert.Zero(t, buf.Len())
+
+	// The background job produces it as a workbook
+	claimed := &patient.Export{ID: 1, Format: patient.ExportXLSX, Status: patient.ExportRunning}
+	repo.On("ClaimExport", mock.Anything, mock.Anything).Return(claimed, nil).Once()
+	var content []byte
+	repo.On("CompleteExport", mock.Anything, 1, 1, mock.Anything, time.Hour).Run(func(args mock.Arguments) {
+		content = args.Get(3).([]byte)
+	}).Return(nil)
+	ok, err := svc.ProcessPending(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	rows, err := f.GetRows("Patients")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Ann Lee", rows[1][2])
+	assert.NotContains(t, rows[0], "Diagnosis")
+
+	repo.On("ClaimExport", mock.Anything, mock.Anything).Return(nil, nil).Once()
+	ok, err = svc.ProcessPending(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestExportPatients_OnlyRequesterDownloads(t *testing.T) {
+	repo, patients := new(mockExportRepository), new(mockPatientRepository)
+	svc := patient.NewExportService(repo, patients, time.Hour)
+
+	requester := 2
+	repo.On("GetExport", mock.Anything, 1).Return(&patient.Export{ID: 1, Format: patient.ExportCSV, Status: patient.ExportRunning, RequestedBy: &requester}, nil).Once()
+	_, _, err := svc.DownloadExport(context.Background(), testReceptionist, 1)
+	assert.ErrorIs(t, err, patient.ErrExportNotReady)
+
+	repo.On("GetExport", mock.Anything, 1).Return(&patient.Export{ID: 1, Format: patient.ExportCSV, Status: patient.ExportCompleted, RequestedBy: &requester}, nil)
+	_, _, err = svc.DownloadExport(context.Background(), testDoctor, 1)
+	assert.ErrorIs(t, err, patient.ErrExportNotFound)
+
+	repo.On("GetExportContent", mock.Anything, 1).Return([]byte("ID\n"), nil)
+	_, content, err := svc.DownloadExport(context.Background(), testReceptionist, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "ID\n", string(content))
+
+	// Exports requested with an API key belong to the key
+	key, otherKey := &middleware.Identity{APIKeyID: 3}, &middleware.Identity{APIKeyID: 4}
+	keyID := 3
+	repo.On("GetExport", mock.Anything, 2).Return(&patient.Export{ID: 2, Format: patient.ExportCSV, Status: patient.ExportCompleted, RequestedByAPIKey: &keyID}, nil)
+	_, err = svc.GetExport(context.Background(), otherKey, 2)
+	assert.ErrorIs(t, err, patient.ErrExportNotFound)
+	_, err = svc.GetExport(context.Background(), testReceptionist, 2)
+	assert.ErrorIs(t, err, patient.ErrExportNotFound)
+	e, err := svc.GetExport(context.Background(), key, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, e.ID)
+}
+
+func TestExportPatients_Handler(t *testing.T) {
+	repo, patients := new(mockExportRepository), new(mockPatientRepository)
+	h := patient.NewExportHandler(patient.NewExportService(repo, patients, time.Hour))
+
+	patients.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.AgeMin == nil })).
+		Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{exportPatient(1, "Ann Lee")}, Total: 1}, nil)
+	patients.On("List", mock.Anything, mock.Anything).
+		Return(&pagination.Page[patient.Patient]{Total: patient.ExportSyncLimit + 1}, nil)
+	repo.On("CreateExport", mock.Anything, mock.Anything).Return(nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/patients/export", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.ExportPatients)
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/patients/export?"+query, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("format=pdf")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".pdf")
+	assert.True(t, strings.HasPrefix(w.Body.String(), "%PDF"))
+
+	w = get("format=csv&age_min=30")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/v1/patient-exports/1", w.Header().Get("Location"))
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+
+	assert.Equal(t, http.StatusBadRequest, get("format=docx").Code)
+
+	// Receptionists cannot learn who has a diagnosis through the filter
+	assert.Equal(t, http.StatusForbidden, get("format=csv&has_diagnosis=true").Code)
+}
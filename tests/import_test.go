@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+type mockImportRepository struct {
+	mock.Mock
+}
+
+func (m *mockImportRepository) CreateImport(ctx context.Context, imp *patient.Import) error {
+	args := m.Called(ctx, imp)
+	imp.ID = 1
+	return args.Error(0)
+}
+func (m *mockImportRepository) GetImport(ctx context.Context, id int) (*patient.Import, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Import), args.Error(1)
+}
+func (m *mockImportRepository) ListImports(ctx context.Context) ([]patient.Import, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]patient.Import), args.Error(1)
+}
+func (m *mockImportRepository) CommitBatch(ctx context.Context, id, from int, patients []*patient.Patient) (*patient.Import, error) {
+	args := m.Called(ctx, id, from, patients)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Import), args.Error(1)
+}
+func (m *mockImportRepository) FailImport(ctx context.Context, id int, reason string) error {
+	return m.Called(ctx, id, reason).Error(0)
+}
+
+const importCSV = `Full name,DOB,Street,E-mail
+John Smith,17/05/1990,1 Main St,john@example.com
+Jane Roe,01/02/1985,2 High St,not-an-email
+,03/04/1970,3 Low St,
+Mary Major,31/12/2999,4 Side St,
+Ann Lee,05/06/1975,5 Park Ave,
+ann lee,05/06/1975,5 Park Ave,
+`
+
+const importMapping = `{"name": "Full name", "date_of_birth": "DOB", "address": "Street", "email": "E-mail"}`
+
+func newImportFixture() (*mockImportRepository, *mockPatientRepository, patient.ImportService) {
+	repo, patients := new(mockImportRepository), new(mockPatientRepository)
+	patients.On("FindDuplicates", mock.Anything, mock.MatchedBy(func(p *patient.Patient) bool { return p.Name == "John Smith" }), 0).
+		Return([]patient.DuplicateCandidate{{Patient: patient.Patient{ID: 9}}}, nil)
+	patients.On("FindDuplicates", mock.Anything, mock.Anything, 0).Return([]patient.DuplicateCandidate{}, nil)
+	return repo, patients, patient.NewImportService(repo, patients)
+}
+
+func TestImportPatients_DryRunReportsRows(t *testing.T) {
+	repo, _, svc := newImportFixture()
+
+	report, err := svc.ImportPatients(context.Background(), testReceptionist, "patients.csv", strings.NewReader(importCSV),
+		patient.ImportOptions{Mapping: importMapping, DateFormat: "02/01/2006", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.TotalRows)
+	assert.Equal(t, 3, report.ValidRows)
+	assert.Equal(t, 2, report.Duplicates)
+
+	byRow := make(map[int]patient.RowIssue)
+	for _, issue := range report.Issues {
+		byRow[issue.Row] = issue
+	}
+	assert.Equal(t, []int{9}, byRow[2].DuplicateOf)
+	assert.Equal(t, "email", byRow[3].Field)
+	assert.Equal(t, "name", byRow[4].Field)
+	assert.Equal(t, "date_of_birth", byRow[5].Field)
+	assert.Equal(t, "same patient as row 6", byRow[7].Error)
+	repo.AssertNotCalled(t, "CreateImport", mock.Anything, mock.Anything)
+}
+
+func TestImportPatients_RejectsInvalidRows(t *testing.T) {
+	repo, _, svc := newImportFixture()
+
+	_, err := svc.ImportPatients(context.Background(), testReceptionist, "patients.csv", strings.NewReader(importCSV),
+		patient.ImportOptions{Mapping: importMapping, DateFormat: "02/01/2006"})
+	var rejected *patient.ImportError
+	require.ErrorAs(t, err, &rejected)
+	assert.Len(t, rejected.Report.Issues, 5)
+	repo.AssertNotCalled(t, "CreateImport", mock.Anything, mock.Anything)
+
+	_, err = svc.ImportPatients(context.Background(), testReceptionist, "patients.csv", strings.NewReader(importCSV),
+		patient.ImportOptions{Mapping: `{"name": "Full name", "date_of_birth": "Birthday", "address": "Street"}`})
+	assert.ErrorIs(t, err, patient.ErrInvalidMapping)
+	_, err = svc.ImportPatients(context.Background(), testReceptionist, "patients.txt", strings.NewReader(importCSV), patient.ImportOptions{})
+	assert.ErrorIs(t, err, patient.ErrInvalidImportFile)
+}
+
+func TestImportPatients_CommitsInOneTransaction(t *testing.T) {
+	repo, _, svc := newImportFixture()
+	csv := "name,date_of_birth,address,dob_estimated\nJohn Smith,1990-05-17,1 Main St,no\nAnn Lee,1975-06-05,5 Park Ave,yes\n"
+
+	repo.On("CreateImport", mock.Anything, mock.MatchedBy(func(imp *patient.Import) bool {
+		return imp.Mode == patient.ImportTransaction && imp.BatchSize == 1 && imp.TotalRows == 1 && imp.Rows[0].Row == 3
+	})).Return(nil)
+	repo.On("CommitBatch", mock.Anything, 1, 0, mock.MatchedBy(func(ps []*patient.Patient) bool {
+		return len(ps) == 1 && ps[0].Name == "Ann Lee" && ps[0].DOBEstimated && *ps[0].UpdatedBy == 2
+	})).Return(&patient.Import{ID: 1, Status: patient.ImportCompleted, TotalRows: 1, NextRow: 1}, nil)
+
+	report, err := svc.ImportPatients(context.Background(), testReceptionist, "patients.csv", strings.NewReader(csv),
+		patient.ImportOptions{Duplicates: patient.DuplicatesSkip})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Duplicates)
+	assert.Equal(t, patient.ImportCompleted, report.Import.Status)
+	repo.AssertNumberOfCalls(t, "CommitBatch", 1)
+}
+
+func TestImportPatients_ResumesFailedBatches(t *testing.T) {
+	repo, _, svc := newImportFixture()
+	csv := "name,date_of_birth,address\nAnn Lee,1975-06-05,5 Park Ave\nBo Chen,1982-01-09,6 Elm St\nCy Dunn,1990-10-10,7 Oak Rd\n"
+
+	repo.On("CreateImport", mock.Anything, mock.Anything).Return(nil)
+	repo.On("CommitBatch", mock.Anything, 1, 0, mock.Anything).Return(&patient.Import{ID: 1, Status: patient.ImportRunning, BatchSize: 2, TotalRows: 3, NextRow: 2}, nil).Once()
+	repo.On("CommitBatch", mock.Anything, 1, 2, mock.Anything).Return(nil, errors.New("connection reset")).Once()
+	repo.On("FailImport", mock.Anything, 1, "connection reset").Return(nil)
+
+	failed := &patient.Import{ID: 1, Status: patient.ImportFailed, BatchSize: 2, TotalRows: 3, NextRow: 2}
+	repo.On("GetImport", mock.Anything, 1).Return(failed, nil).Once()
+	report, err := svc.ImportPatients(context.Background(), testReceptionist, "patients.csv", strings.NewReader(csv),
+		patient.ImportOptions{Mode: patient.ImportBatches, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, patient.ImportFailed, report.Import.Status)
+
+	// Resuming commits the remaining row only
+	resumable := *failed
+	resumable.Rows = patient.ImportRows{
+		{Row: 2, Patient: patient.CreatePatientRequest{Name: "Ann Lee", DateOfBirth: "1975-06-05", Address: "5 Park Ave"}},
+		{Row: 3, Patient: patient.CreatePatientRequest{Name: "Bo Chen", DateOfBirth: "1982-01-09", Address: "6 Elm St"}},
+		{Row: 4, Patient: patient.CreatePatientRequest{Name: "Cy Dunn", DateOfBirth: "1990-10-10", Address: "7 Oak Rd"}},
+	}
+	repo.On("GetImport", mock.Anything, 1).Return(&resumable, nil).Once()
+	repo.On("CommitBatch", mock.Anything, 1, 2, mock.MatchedBy(func(ps []*patient.Patient) bool { return len(ps) == 1 && ps[0].Name == "Cy Dunn" })).
+		Return(&patient.Import{ID: 1, Status: patient.ImportCompleted, TotalRows: 3, NextRow: 3}, nil).Once()
+	imp, err := svc.ResumeImport(context.Background(), testReceptionist, 1)
+	require.NoError(t, err)
+	assert.Equal(t, patient.ImportCompleted, imp.Status)
+
+	repo.On("GetImport", mock.Anything, 2).Return(&patient.Import{ID: 2, Status: patient.ImportCompleted}, nil)
+	_, err = svc.ResumeImport(context.Background(), testReceptionist, 2)
+	assert.ErrorIs(t, err, patient.ErrImportCompleted)
+}
+
+func TestImportPatients_ReadsXLSX(t *testing.T) {
+	_, _, svc := newImportFixture()
+
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	require.NoError(t, f.SetSheetRow(sheet, "A1", &[]interface{}{"Name", "Date of birth", "Address", "Country"}))
+	require.NoError(t, f.SetSheetRow(sheet, "A2", &[]interface{}{"Ann Lee", 27550, "5 Park Ave", "pt"}))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	report, err := svc.ImportPatients(context.Background(), testReceptionist, "patients.xlsx", &buf, patient.ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.ValidRows)
+	assert.Empty(t, report.Issues)
+}
+
+func TestImportPatients_Handler(t *testing.T) {
+	_, _, svc := newImportFixture()
+	h := patient.NewImportHandler(svc)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/patients/import", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.ImportPatients)
+
+	upload := func(fields map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "patients.csv")
+		part.Write([]byte(importCSV))
+		for k, v := range fields {
+			form.WriteField(k, v)
+		}
+		form.Close()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/patients/import", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload(map[string]string{"mapping": importMapping, "date_format": "02/01/2006", "dry_run": "true"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"valid_rows":3`)
+
+	w = upload(map[string]string{"mapping": importMapping, "date_format": "02/01/2006"})
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"duplicate_of":[9]`)
+
+	assert.Equal(t, http.StatusBadRequest, upload(map[string]string{"mapping": `{"name": "Nope"}`}).Code)
+	assert.Equal(t, http.StatusBadRequest, upload(map[string]string{"mode": "later"}).Code)
+}
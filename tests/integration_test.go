@@ -4,11 +4,13 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -17,6 +19,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
 	"github.com/kyash99252/Medical-Portal/internal/middleware"
 	"github.com/kyash99252/Medical-Portal/internal/patient"
 	"github.com/kyash99252/Medical-Portal/pkg/config"
@@ -67,9 +71,17 @@ func TestMain(m *testing.M) {
 func setupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 	r := gin.New()
 	userRepo := auth.NewPostgresRepository(db)
-	patientRepo := patient.NewPostgresRepository(db)
-	authSvc := auth.NewService(userRepo, cfg.JWTSecretKey)
-	patientSvc := patient.NewService(patientRepo)
+	tokenRepo := auth.NewPostgresTokenRepository(db)
+	mrnFormat, _ := patient.ParseMRNFormat(patient.DefaultMRNPattern)
+	patientRepo := patient.NewPostgresRepository(db, mrnFormat)
+	authSvc := auth.NewService(userRepo, tokenRepo, auth.Options{
+		Keys:            testKeys,
+		AccessTokenTTL:  cfg.AccessTokenTTL,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+	})
+	permissionSvc := authz.NewService(authz.NewPostgresRepository(db))
+	careTeamSvc := careteam.NewService(careteam.NewPostgresRepository(db), careteam.NewPostgresEmergencyRepository(db))
+	patientSvc := patient.NewService(patientRepo, careTeamSvc)
 	authHandler := auth.NewHandler(authSvc)
 	patientHandler := patient.NewHandler(patientSvc)
 	
@@ -77,7 +89,7 @@ func setupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 	{
 		v1.POST("/login", authHandler.Login)
 		authRoutes := v1.Group("/")
-		authRoutes.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		authRoutes.Use(middleware.AuthMiddleware(authSvc), middleware.LoadPermissions(permissionSvc))
 		{
 			p := authRoutes.Group("/patients")
 			{
@@ -122,13 +134,13 @@ func TestPatientCRUD_Receptionist(t *testing.T) {
 	clearPatientsTable()
 	require.NotEmpty(t, receptionistToken, "Receptionist token is empty")
 	
-	var createdPatient patient.Patient
+	var createdPatient patient.Demographics
 
 	// 1. Create Patient
 	t.Run("Create Patient", func(t *testing.T) {
 		createReq := patient.CreatePatientRequest{
 			Name: "Integration Test Patient",
-			Age: 30,
+			DateOfBirth: "1996-01-15",
 			Address: "123 Test St",
 		}
 		body, _ := json.Marshal(createReq)
@@ -160,7 +172,7 @@ func TestPatientCRUD_Receptionist(t *testing.T) {
 	t.Run("Update Patient", func(t *testing.T) {
 		updateReq := patient.UpdatePatientRequest{
 			Name: "Updated Patient Name",
-			Age: 31,
+			DateOfBirth: "1995-01-15",
 			Address: "456 Updated St",
 		}
 		body, _ := json.Marshal(updateReq)
@@ -172,10 +184,10 @@ func TestPatientCRUD_Receptionist(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var updatedPatient patient.Patient
+		var updatedPatient patient.Demographics
 		json.Unmarshal(w.Body.Bytes(), &updatedPatient)
 		assert.Equal(t, "Updated Patient Name", updatedPatient.Name)
-		assert.Equal(t, 31, updatedPatient.Age)
+		assert.Equal(t, "1995-01-15", updatedPatient.DateOfBirth)
 	})
 
 	// 4. Delete Patient
@@ -195,13 +207,18 @@ func TestPatientAccess_Doctor(t *testing.T) {
 
 	// Pre-populate a patient using the DB directly for this test
 	var patientID int
-	err := db.QueryRow(`INSERT INTO patients (name, age, address) VALUES ($1, $2, $3) RETURNING id`,
-		"Doctor Test Patient", 55, "789 Clinic Rd").Scan(&patientID)
+	err := db.QueryRow(`INSERT INTO patients (mrn, name, date_of_birth, address) VALUES ($1, $2, $3, $4) RETURNING id`,
+		"MRN-TEST-2", "Doctor Test Patient", "1971-04-09", "789 Clinic Rd").Scan(&patientID)
+	require.NoError(t, err)
+
+	// Doctors only see patients on their care team
+	_, err = db.Exec(`INSERT INTO care_team_members (patient_id, doctor_id, is_primary)
+		SELECT $1, id, TRUE FROM users WHERE username = 'doctor'`, patientID)
 	require.NoError(t, err)
 
 	// 1. Doctor CANNOT create a patient
 	t.Run("Doctor Cannot Create Patient", func(t *testing.T) {
-		createReq := patient.CreatePatientRequest{Name: "Illegal", Age: 1, Address: "No"}
+		createReq := patient.CreatePatientRequest{Name: "Illegal", DateOfBirth: "2000-01-01", Address: "No"}
 		body, _ := json.Marshal(createReq)
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/v1/patients", bytes.NewReader(body))
@@ -236,8 +253,26 @@ func TestPatientAccess_Doctor(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var updatedPatient patient.Patient
+		var updatedPatient patient.ClinicalRecord
 		json.Unmarshal(w.Body.Bytes(), &updatedPatient)
 		assert.Equal(t, "Hypertension", *updatedPatient.Diagnosis)
 	})
-}
\ No newline at end of file
+}
+
+func TestGetAsOf_SnapshotBeforeRevisions(t *testing.T) {
+	clearPatientsTable()
+
+	var patientID int
+	err := db.QueryRow(`INSERT INTO patients (mrn, name, date_of_birth, address) VALUES ($1, $2, $3, $4) RETURNING id`,
+		"MRN-TEST-1", "Seeded Patient", "1960-05-01", "1 Old Rd").Scan(&patientID)
+	require.NoError(t, err)
+
+	// Versions seeded for existing patients were taken before the revision column existed
+	_, err = db.Exec(`UPDATE patient_versions SET snapshot = snapshot - 'revision' WHERE patient_id = $1`, patientID)
+	require.NoError(t, err)
+
+	p, err := patient.NewPostgresVersionRepository(db).GetAsOf(context.Background(), patientID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "Seeded Patient", p.Name)
+	assert.Equal(t, 1, p.Revision)
+}
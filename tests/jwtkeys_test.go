@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
+)
+
+func TestKeyRotation_OldTokensStayValid(t *testing.T) {
+	oldKey, err := jwtkeys.GenerateEd25519()
+	require.NoError(t, err)
+	newKey, err := jwtkeys.GenerateEd25519()
+	require.NoError(t, err)
+
+	before, err := jwtkeys.NewKeySet(oldKey)
+	require.NoError(t, err)
+	after, err := jwtkeys.NewKeySet(newKey, oldKey)
+	require.NoError(t, err)
+
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	user := newTestUser(t, 2, "doctor", true)
+	users.On("GetUserByUsername", mock.Anything, "doctor").Return(user, nil)
+	users.On("GetUserByID", mock.Anything, 2).Return(user, nil)
+	tokens.On("CreateSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("CreateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("IsRevoked", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	opts := testAuthOptions
+	opts.Keys = before
+	result, err := auth.NewService(users, tokens, opts).Login(context.Background(), "doctor", "password123", auth.ClientInfo{})
+	require.NoError(t, err)
+
+	opts.Keys = after
+	rotated := auth.NewService(users, tokens, opts)
+	identity, err := rotated.VerifyAccessToken(context.Background(), result.Tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 2, identity.UserID)
+
+	// New tokens are signed with the new key
+	result, err = rotated.Login(context.Background(), "doctor", "password123", auth.ClientInfo{})
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(result.Tokens.AccessToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, newKey.ID, parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+}
+
+func TestVerifyAccessToken_RejectsSharedSecretAndUnknownKeys(t *testing.T) {
+	svc := auth.NewService(new(mockUserRepository), new(mockTokenRepository), testAuthOptions)
+	claims := jwt.MapClaims{"sub": 1, "jti": "j", "sid": "s", "iss": testAuthOptions.Issuer, "exp": time.Now().Add(time.Hour).Unix()}
+
+	// The old shared-secret scheme, even with a matching kid
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hs.Header["kid"] = testKeys.SigningKeyID()
+	hsToken, err := hs.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = svc.VerifyAccessToken(context.Background(), hsToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// A well-formed token from a key that is not in the set
+	otherKey, err := jwtkeys.GenerateEd25519()
+	require.NoError(t, err)
+	other, err := jwtkeys.NewKeySet(otherKey)
+	require.NoError(t, err)
+	foreign, err := other.Sign(claims)
+	require.NoError(t, err)
+	_, err = svc.VerifyAccessToken(context.Background(), foreign)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestJWKS_VerifiesWithoutSigningKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	signing, err := jwtkeys.ParsePEM(privPEM)
+	require.NoError(t, err)
+	assert.Equal(t, jwtkeys.AlgRS256, signing.Algorithm)
+
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	public, err := jwtkeys.ParsePEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	require.NoError(t, err)
+	assert.Equal(t, signing.ID, public.ID, "kid is the key thumbprint, identical for both halves")
+
+	keys, err := jwtkeys.NewKeySet(signing)
+	require.NoError(t, err)
+	token, err := keys.Sign(jwt.MapClaims{"sub": 1})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", auth.JWKSHandler(keys))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"d"`, "private key material must not be published")
+
+	verifier, err := jwtkeys.ParseJWKS(w.Body.Bytes())
+	require.NoError(t, err)
+	parsed, err := jwt.Parse(token, verifier.Keyfunc, jwt.WithValidMethods(verifier.Algorithms()))
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	_, err = verifier.Sign(jwt.MapClaims{})
+	assert.ErrorIs(t, err, jwtkeys.ErrNoSigningKey)
+
+	var doc map[string][]map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "RSA", doc["keys"][0]["kty"])
+	assert.Equal(t, signing.ID, doc["keys"][0]["kid"])
+}
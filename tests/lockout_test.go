@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+)
+
+type mockAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *mockAttemptRepository) RecordAttempt(ctx context.Context, a *auth.LoginAttempt) error {
+	return m.Called(ctx, a).Error(0)
+}
+func (m *mockAttemptRepository) ListAttempts(ctx context.Context, filter auth.LoginAttemptFilter) ([]auth.LoginAttempt, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]auth.LoginAttempt), args.Error(1)
+}
+func (m *mockAttemptRepository) GetCounter(ctx context.Context, kind, identifier string) (*auth.LoginCounterState, error) {
+	args := m.Called(ctx, kind, identifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.LoginCounterState), args.Error(1)
+}
+func (m *mockAttemptRepository) RegisterFailure(ctx context.Context, kind, identifier string, window time.Duration, lockAfter int, lockDuration time.Duration) error {
+	return m.Called(ctx, kind, identifier, window, lockAfter, lockDuration).Error(0)
+}
+func (m *mockAttemptRepository) ResetCounter(ctx context.Context, kind, identifier string) error {
+	return m.Called(ctx, kind, identifier).Error(0)
+}
+func (m *mockAttemptRepository) ListLockouts(ctx context.Context) ([]auth.LoginLockout, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]auth.LoginLockout), args.Error(1)
+}
+
+var testClient = auth.ClientInfo{IPAddress: "10.0.0.1", UserAgent: "test-agent"}
+
+func TestThrottledLogin_LockedUsernameSkipsPasswordCheck(t *testing.T) {
+	inner := new(mockAuthService)
+	attempts := new(mockAttemptRepository)
+	svc := auth.NewThrottledService(inner, attempts, auth.DefaultLockoutPolicy())
+
+	attempts.On("GetCounter", mock.Anything, auth.CounterUsername, "doctor").Return(&auth.LoginCounterState{FailedCount: 10, LockSecondsRemaining: 600}, nil)
+	attempts.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(a *auth.LoginAttempt) bool {
+		return !a.Success && *a.FailureReason == "locked" && *a.IPAddress == "10.0.0.1"
+	})).Return(nil)
+
+	_, err := svc.Login(context.Background(), "Doctor", "password123", testClient)
+
+	var locked *auth.LoginLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.ErrorIs(t, err, auth.ErrLoginLocked)
+	assert.Equal(t, 10*time.Minute, locked.RetryAfter)
+	inner.AssertNotCalled(t, "Login", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestThrottledLogin_ProgressiveDelay(t *testing.T) {
+	inner := new(mockAuthService)
+	attempts := new(mockAttemptRepository)
+	svc := auth.NewThrottledService(inner, attempts, auth.DefaultLockoutPolicy())
+
+	// 5 failures: 1s after the 3rd, doubled twice = 4s, of which 1s has passed
+	attempts.On("GetCounter", mock.Anything, auth.CounterUsername, "doctor").Return(&auth.LoginCounterState{FailedCount: 5, SecondsSinceFailure: 1}, nil)
+	attempts.On("RecordAttempt", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.Login(context.Background(), "doctor", "password123", testClient)
+
+	var locked *auth.LoginLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.Equal(t, 3*time.Second, locked.RetryAfter)
+}
+
+func TestThrottledLogin_FailureCountsUsernameAndIP(t *testing.T) {
+	inner := new(mockAuthService)
+	attempts := new(mockAttemptRepository)
+	policy := auth.DefaultLockoutPolicy()
+	svc := auth.NewThrottledService(inner, attempts, policy)
+
+	attempts.On("GetCounter", mock.Anything, mock.Anything, mock.Anything).Return(&auth.LoginCounterState{}, nil)
+	inner.On("Login", mock.Anything, "ghost", "guess").Return(nil, auth.ErrInvalidCredentials)
+	attempts.On("RegisterFailure", mock.Anything, auth.CounterUsername, "ghost", policy.Window, policy.LockAfter, policy.LockDuration).Return(nil)
+	attempts.On("RegisterFailure", mock.Anything, auth.CounterIP, "10.0.0.1", policy.Window, policy.IPLockAfter, policy.LockDuration).Return(nil)
+	attempts.On("RecordAttempt", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := svc.Login(context.Background(), "ghost", "guess", testClient)
+
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	attempts.AssertNumberOfCalls(t, "RegisterFailure", 2)
+}
+
+func TestThrottledLogin_SuccessResetsCounterOnlyWhenTokensIssued(t *testing.T) {
+	inner := new(mockAuthService)
+	attempts := new(mockAttemptRepository)
+	svc := auth.NewThrottledService(inner, attempts, auth.DefaultLockoutPolicy())
+
+	attempts.On("GetCounter", mock.Anything, mock.Anything, mock.Anything).Return(&auth.LoginCounterState{}, nil)
+	// A password that still needs a second factor is not a successful login
+	attempts.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(a *auth.LoginAttempt) bool {
+		return a.Username == "mfa-user" && !a.Success && *a.FailureReason == "mfa_required"
+	})).Return(nil).Once()
+	attempts.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(a *auth.LoginAttempt) bool {
+		return a.Username == "doctor" && a.Success && a.FailureReason == nil
+	})).Return(nil).Once()
+	inner.On("Login", mock.Anything, "mfa-user", "password123").Return(&auth.LoginResult{MFAToken: "challenge"}, nil)
+	inner.On("Login", mock.Anything, "doctor", "password123").Return(&auth.LoginResult{Tokens: &auth.TokenPair{AccessToken: "access"}}, nil)
+	attempts.On("ResetCounter", mock.Anything, auth.CounterUsername, "doctor").Return(nil)
+
+	_, err := svc.Login(context.Background(), "mfa-user", "password123", testClient)
+	require.NoError(t, err)
+	attempts.AssertNotCalled(t, "ResetCounter", mock.Anything, auth.CounterUsername, "mfa-user")
+
+	_, err = svc.Login(context.Background(), "doctor", "password123", testClient)
+	require.NoError(t, err)
+	attempts.AssertCalled(t, "ResetCounter", mock.Anything, auth.CounterUsername, "doctor")
+	attempts.AssertExpectations(t)
+}
+
+func TestThrottledCompleteLogin_FailedChallengesLockAccount(t *testing.T) {
+	users := new(mockUserRepository)
+	mfa := new(mockMFARepository)
+	tokens := new(mockTokenRepository)
+	attempts := new(mockAttemptRepository)
+	policy := auth.DefaultLockoutPolicy()
+	policy.DelayAfter = 0
+	inner := auth.NewMFAService(users, mfa, tokens, new(mockAuthService), auth.MFAOptions{})
+	svc := auth.NewThrottledMFAService(inner, users, tokens, attempts, policy)
+
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	users.On("GetUserByID", mock.Anything, 2).Return(&auth.User{ID: 2, Username: "Doctor", IsActive: true, MFAEnabled: true, MFASecret: &secret}, nil)
+	tokens.On("GetMFAChallenge", mock.Anything, mock.Anything).Return(&auth.MFAChallenge{ID: 7, UserID: 2}, nil)
+	tokens.On("RecordMFAChallengeFailure", mock.Anything, 7, 5).Return(nil)
+	mfa.On("ConsumeRecoveryCode", mock.Anything, 2, mock.Anything).Return(auth.ErrInvalidMFACode)
+
+	state := &auth.LoginCounterState{}
+	attempts.On("GetCounter", mock.Anything, auth.CounterUsername, "doctor").Return(state, nil)
+	attempts.On("GetCounter", mock.Anything, auth.CounterIP, "10.0.0.1").Return(&auth.LoginCounterState{}, nil)
+	attempts.On("RegisterFailure", mock.Anything, auth.CounterUsername, "doctor", policy.Window, policy.LockAfter, policy.LockDuration).Run(func(mock.Arguments) {
+		state.FailedCount++
+		if state.FailedCount >= policy.LockAfter {
+			state.LockSecondsRemaining = int64(policy.LockDuration.Seconds())
+		}
+	}).Return(nil)
+	attempts.On("RegisterFailure", mock.Anything, auth.CounterIP, "10.0.0.1", policy.Window, policy.IPLockAfter, policy.LockDuration).Return(nil)
+	attempts.On("RecordAttempt", mock.Anything, mock.Anything).Return(nil)
+
+	// Every password login starts a fresh challenge, but wrong codes add up
+	// on the account
+	for i := 0; i < policy.LockAfter; i++ {
+		_, err := svc.CompleteLogin(context.Background(), "challenge-"+strconv.Itoa(i), "wrong-code", testClient)
+		require.ErrorIs(t, err, auth.ErrInvalidMFACode)
+	}
+
+	_, err := svc.CompleteLogin(context.Background(), "challenge-new", "wrong-code", testClient)
+	assert.ErrorIs(t, err, auth.ErrLoginLocked)
+	mfa.AssertNumberOfCalls(t, "ConsumeRecoveryCode", policy.LockAfter)
+	attempts.AssertCalled(t, "RecordAttempt", mock.Anything, mock.MatchedBy(func(a *auth.LoginAttempt) bool {
+		return a.Username == "Doctor" && !a.Success && *a.FailureReason == "invalid_mfa_code"
+	}))
+}
+
+func TestLoginHandler_LockedReturns429(t *testing.T) {
+	mockSvc := new(mockAuthService)
+	h := auth.NewHandler(mockSvc)
+
+	req := auth.LoginRequest{Username: "nobody", Password: "pass"}
+	mockSvc.On("Login", mock.Anything, req.Username, req.Password).Return(nil, &auth.LoginLockedError{RetryAfter: 1500 * time.Millisecond})
+
+	w := performRequest(h.Login, "POST", req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.NotContains(t, w.Body.String(), "nobody")
+}
+
+func TestUnlockUser_ResetsNormalizedUsername(t *testing.T) {
+	users := new(mockUserRepository)
+	attempts := new(mockAttemptRepository)
+	svc := auth.NewLockoutService(users, attempts)
+
+	users.On("GetUserByID", mock.Anything, 4).Return(&auth.User{ID: 4, Username: "DrHouse"}, nil)
+	attempts.On("ResetCounter", mock.Anything, auth.CounterUsername, "drhouse").Return(nil)
+
+	require.NoError(t, svc.UnlockUser(context.Background(), 4))
+
+	users.On("GetUserByID", mock.Anything, 99).Return(nil, auth.ErrUserNotFound)
+	assert.True(t, errors.Is(svc.UnlockUser(context.Background(), 99), auth.ErrUserNotFound))
+}
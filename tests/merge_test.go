@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+type mockMergeRepository struct {
+	mock.Mock
+}
+
+func (m *mockMergeRepository) Merge(ctx context.Context, survivorID, duplicateID int, mergedBy patient.Actor) (*patient.Merge, error) {
+	args := m.Called(ctx, survivorID, duplicateID, mergedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Merge), args.Error(1)
+}
+func (m *mockMergeRepository) GetMerge(ctx context.Context, id int) (*patient.Merge, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Merge), args.Error(1)
+}
+func (m *mockMergeRepository) ListMerges(ctx context.Context, patientID int) ([]patient.Merge, error) {
+	args := m.Called(ctx, patientID)
+	return args.Get(0).([]patient.Merge), args.Error(1)
+}
+func (m *mockMergeRepository) Undo(ctx context.Context, id int, undoneBy patient.Actor) (*patient.Merge, error) {
+	args := m.Called(ctx, id, undoneBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Merge), args.Error(1)
+}
+
+func TestCreatePatient_ProbableDuplicate(t *testing.T) {
+	repo := new(mockPatientRepository)
+	teams := new(mockCareTeamRepository)
+	svc := patient.NewService(repo, careteam.NewService(teams, new(mockEmergencyRepository)))
+	ctx := context.Background()
+
+	existing := patient.DuplicateCandidate{
+		Patient: patient.Patient{ID: 4, Name: "John Doe", DateOfBirth: time.Date(1985, 2, 11, 0, 0, 0, 0, time.UTC)},
+		Score:   0.8,
+		Reasons: []string{"name", "date_of_birth"},
+	}
+	repo.On("FindDuplicates", mock.Anything, mock.Anything, 0).Return([]patient.DuplicateCandidate{existing}, nil)
+
+	req := patient.CreatePatientRequest{Name: "Jon Doe", DateOfBirth: "1985-02-11", Address: "1 Main St"}
+	_, err := svc.CreatePatient(ctx, testReceptionist, req)
+	var dup *patient.DuplicateError
+	require.ErrorAs(t, err, &dup)
+	assert.ErrorIs(t, err, patient.ErrProbableDuplicate)
+	assert.Equal(t, 4, dup.Candidates[0].ID)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// Confirming skips the check
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	req.ConfirmDuplicate = true
+	p, err := svc.CreatePatient(ctx, testReceptionist, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Jon Doe", p.Name)
+	repo.AssertNumberOfCalls(t, "FindDuplicates", 1)
+
+	// Doctors are only warned about patients on their own panel
+	repo.On("FindDuplicates", mock.Anything, mock.Anything, testDoctor.UserID).Return([]patient.DuplicateCandidate{}, nil)
+	req.ConfirmDuplicate = false
+	_, err = svc.CreatePatient(ctx, testDoctor, req)
+	require.NoError(t, err)
+}
+
+func TestCreatePatientHandler_Conflict(t *testing.T) {
+	mockSvc := new(mockPatientService)
+	h := patient.NewHandler(mockSvc)
+
+	diagnosis := "Asthma"
+	mockSvc.On("CreatePatient", mock.Anything, mock.Anything, mock.Anything).Return(nil, &patient.DuplicateError{
+		Candidates: []patient.DuplicateCandidate{{
+			Patient: patient.Patient{ID: 4, Name: "John Doe", Diagnosis: &diagnosis},
+			Score:   0.8,
+			Reasons: []string{"name"},
+		}},
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/patients", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.CreatePatient)
+
+	body, _ := json.Marshal(patient.CreatePatientRequest{Name: "Jon Doe", DateOfBirth: "1985-02-11", Address: "1 Main St"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patients", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"duplicates"`)
+	assert.Contains(t, w.Body.String(), "John Doe")
+	assert.NotContains(t, w.Body.String(), "Asthma")
+}
+
+func TestMergePatients(t *testing.T) {
+	repo := new(mockMergeRepository)
+	teams := new(mockCareTeamRepository)
+	svc := patient.NewMergeService(repo, careteam.NewService(teams, new(mockEmergencyRepository)))
+	ctx := context.Background()
+
+	_, err := svc.MergePatients(ctx, testReceptionist, 4, patient.MergeRequest{DuplicateID: 4})
+	assert.ErrorIs(t, err, patient.ErrMergeSelf)
+
+	survivor, duplicate := 4, 9
+	merged := &patient.Merge{ID: 1, SurvivorID: &survivor, DuplicateID: &duplicate}
+	repo.On("Merge", mock.Anything, 4, 9, mock.MatchedBy(func(by patient.Actor) bool { return by.UserID != nil && *by.UserID == 2 })).Return(merged, nil)
+	m, err := svc.MergePatients(ctx, testReceptionist, 4, patient.MergeRequest{DuplicateID: 9})
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.ID)
+
+	// Doctors need access to both patients
+	teams.On("IsMember", mock.Anything, 4, 5).Return(true, nil)
+	teams.On("IsMember", mock.Anything, 9, 5).Return(false, nil)
+	_, err = svc.MergePatients(ctx, testDoctor, 4, patient.MergeRequest{DuplicateID: 9})
+	assert.ErrorIs(t, err, careteam.ErrAccessDenied)
+	repo.AssertNumberOfCalls(t, "Merge", 1)
+}
+
+func TestUndoMerge(t *testing.T) {
+	repo := new(mockMergeRepository)
+	svc := patient.NewMergeService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+	ctx := context.Background()
+
+	repo.On("GetMerge", mock.Anything, 99).Return(nil, patient.ErrMergeNotFound)
+	_, err := svc.UndoMerge(ctx, testReceptionist, 99)
+	assert.ErrorIs(t, err, patient.ErrMergeNotFound)
+
+	survivor, duplicate := 4, 9
+	repo.On("GetMerge", mock.Anything, 1).Return(&patient.Merge{ID: 1, SurvivorID: &survivor, DuplicateID: &duplicate}, nil)
+	repo.On("Undo", mock.Anything, 1, mock.Anything).Return(nil, patient.ErrMergeAlreadyUndone)
+	_, err = svc.UndoMerge(ctx, testReceptionist, 1)
+	assert.ErrorIs(t, err, patient.ErrMergeAlreadyUndone)
+}
+
+func TestMergeHandler_Errors(t *testing.T) {
+	repo := new(mockMergeRepository)
+	h := patient.NewMergeHandler(patient.NewMergeService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository))))
+
+	repo.On("Merge", mock.Anything, 4, 9, mock.Anything).Return(nil, patient.ErrMergeConflict)
+	repo.On("Merge", mock.Anything, 4, 10, mock.Anything).Return(nil, patient.ErrPatientNotFound)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/patients/:id/merge", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.MergePatients)
+
+	cases := []struct {
+		body string
+		code int
+	}{
+		{`{"duplicate_id": 9}`, http.StatusConflict},
+		{`{"duplicate_id": 10}`, http.StatusNotFound},
+		{`{"duplicate_id": 4}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/patients/4/merge", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.body)
+	}
+}
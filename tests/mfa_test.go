@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/pkg/totp"
+)
+
+type mockMFARepository struct {
+	mock.Mock
+}
+
+func (m *mockMFARepository) GetMFAStatus(ctx context.Context, userID int) (*auth.MFAStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.MFAStatus), args.Error(1)
+}
+func (m *mockMFARepository) SetPendingMFASecret(ctx context.Context, userID int, secret string) error {
+	return m.Called(ctx, userID, secret).Error(0)
+}
+func (m *mockMFARepository) EnableMFA(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	return m.Called(ctx, userID, recoveryCodeHashes).Error(0)
+}
+func (m *mockMFARepository) DisableMFA(ctx context.Context, userID int) error {
+	return m.Called(ctx, userID).Error(0)
+}
+func (m *mockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	return m.Called(ctx, userID, recoveryCodeHashes).Error(0)
+}
+func (m *mockMFARepository) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	return m.Called(ctx, userID, codeHash).Error(0)
+}
+func (m *mockMFARepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+// RFC 6238 appendix B test vectors for SHA-1, truncated to 6 digits
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // base32 of "12345678901234567890"
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range vectors {
+		got, err := totp.CodeAt(secret, totp.Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "time %d", unix)
+	}
+
+	step, ok := totp.Validate(secret, "287082", time.Unix(59+totp.Period, 0), 1)
+	assert.True(t, ok, "codes from the previous step are accepted")
+	assert.Equal(t, int64(1), step)
+
+	_, ok = totp.Validate(secret, "287082", time.Unix(59+3*totp.Period, 0), 1)
+	assert.False(t, ok)
+}
+
+func TestLogin_MFAEnrolledUserGetsChallenge(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	user := newTestUser(t, 2, "doctor", true)
+	user.MFAEnabled = true
+	users.On("GetUserByUsername", mock.Anything, "doctor").Return(user, nil)
+	tokens.On("CreateMFAChallenge", mock.Anything, 2, mock.Anything, 5*time.Minute).Return(nil)
+
+	result, err := svc.Login(context.Background(), "doctor", "password123", auth.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Tokens, "no tokens before the second factor")
+	assert.NotEmpty(t, result.MFAToken)
+	assert.Equal(t, sha256Hex(result.MFAToken), tokens.Calls[0].Arguments.String(2))
+	tokens.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginHandler_MFAChallenge(t *testing.T) {
+	mockSvc := new(mockAuthService)
+	h := auth.NewHandler(mockSvc)
+
+	req := auth.LoginRequest{Username: "doctor", Password: "password123"}
+	mockSvc.On("Login", mock.Anything, req.Username, req.Password).Return(&auth.LoginResult{MFAToken: "challenge123", MFAExpiresIn: 300}, nil)
+
+	w := performRequest(h.Login, "POST", req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"mfa_required":true`)
+	assert.Contains(t, w.Body.String(), "challenge123")
+	assert.NotContains(t, w.Body.String(), `"token"`)
+}
+
+func TestCompleteLogin_TOTPCodeCannotBeReplayed(t *testing.T) {
+	users := new(mockUserRepository)
+	mfa := new(mockMFARepository)
+	tokens := new(mockTokenRepository)
+	authSvc := new(mockAuthService)
+	svc := auth.NewMFAService(users, mfa, tokens, authSvc, auth.MFAOptions{})
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	user := &auth.User{ID: 2, Username: "doctor", IsActive: true, MFAEnabled: true, MFASecret: &secret}
+	code, err := totp.CodeAt(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	tokens.On("GetMFAChallenge", mock.Anything, sha256Hex("challenge")).Return(&auth.MFAChallenge{ID: 7, UserID: 2}, nil)
+	users.On("GetUserByID", mock.Anything, 2).Return(user, nil)
+	mfa.On("UseTOTPStep", mock.Anything, 2, mock.Anything).Return(true, nil).Once()
+	tokens.On("ConsumeMFAChallenge", mock.Anything, 7).Return(nil)
+	authSvc.On("StartSession", mock.Anything, user, mock.Anything).Return(&auth.TokenPair{AccessToken: "access"}, nil)
+
+	pair, err := svc.CompleteLogin(context.Background(), "challenge", code, auth.ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "access", pair.AccessToken)
+
+	// The same code presented again is rejected and counted against the challenge
+	mfa.On("UseTOTPStep", mock.Anything, 2, mock.Anything).Return(false, nil)
+	tokens.On("RecordMFAChallengeFailure", mock.Anything, 7, 5).Return(nil)
+
+	_, err = svc.CompleteLogin(context.Background(), "challenge", code, auth.ClientInfo{})
+	assert.ErrorIs(t, err, auth.ErrInvalidMFACode)
+	tokens.AssertCalled(t, "RecordMFAChallengeFailure", mock.Anything, 7, 5)
+}
+
+func TestCompleteLogin_RecoveryCode(t *testing.T) {
+	users := new(mockUserRepository)
+	mfa := new(mockMFARepository)
+	tokens := new(mockTokenRepository)
+	authSvc := new(mockAuthService)
+	svc := auth.NewMFAService(users, mfa, tokens, authSvc, auth.MFAOptions{})
+
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	user := &auth.User{ID: 2, Username: "doctor", IsActive: true, MFAEnabled: true, MFASecret: &secret}
+
+	tokens.On("GetMFAChallenge", mock.Anything, mock.Anything).Return(&auth.MFAChallenge{ID: 7, UserID: 2}, nil)
+	users.On("GetUserByID", mock.Anything, 2).Return(user, nil)
+	mfa.On("ConsumeRecoveryCode", mock.Anything, 2, sha256Hex("a1b2c3d4e5")).Return(nil)
+	tokens.On("ConsumeMFAChallenge", mock.Anything, 7).Return(nil)
+	authSvc.On("StartSession", mock.Anything, user, mock.Anything).Return(&auth.TokenPair{AccessToken: "access"}, nil)
+
+	_, err := svc.CompleteLogin(context.Background(), "challenge", "A1B2C-3D4E5", auth.ClientInfo{})
+
+	require.NoError(t, err)
+	mfa.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestActivateMFA_ReturnsRecoveryCodes(t *testing.T) {
+	users := new(mockUserRepository)
+	mfa := new(mockMFARepository)
+	tokens := new(mockTokenRepository)
+	authSvc := new(mockAuthService)
+	svc := auth.NewMFAService(users, mfa, tokens, authSvc, auth.MFAOptions{Issuer: "Clinic"})
+
+	users.On("GetUserByID", mock.Anything, 2).Return(&auth.User{ID: 2, Username: "doctor"}, nil).Once()
+	mfa.On("SetPendingMFASecret", mock.Anything, 2, mock.Anything).Return(nil)
+
+	enrollment, err := svc.Enroll(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/Clinic:doctor?")
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+	users.On("GetUserByID", mock.Anything, 2).Return(&auth.User{ID: 2, Username: "doctor", MFASecret: &enrollment.Secret}, nil)
+	mfa.On("UseTOTPStep", mock.Anything, 2, mock.Anything).Return(true, nil)
+	mfa.On("EnableMFA", mock.Anything, 2, mock.Anything).Return(nil)
+
+	code, err := totp.CodeAt(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	resp, err := svc.Activate(context.Background(), 2, code)
+
+	require.NoError(t, err)
+	require.Len(t, resp.RecoveryCodes, 10)
+	storedHashes := mfa.Calls[len(mfa.Calls)-1].Arguments.Get(2).([]string)
+	assert.NotContains(t, storedHashes, resp.RecoveryCodes[0], "recovery codes are stored hashed")
+}
+
+func TestResetMFA_CannotResetOwn(t *testing.T) {
+	svc := auth.NewMFAService(new(mockUserRepository), new(mockMFARepository), new(mockTokenRepository), new(mockAuthService), auth.MFAOptions{})
+
+	err := svc.ResetMFA(context.Background(), 1, 1)
+
+	assert.ErrorIs(t, err, auth.ErrCannotModifySelf)
+}
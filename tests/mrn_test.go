@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+type mockIdentifierRepository struct {
+	mock.Mock
+}
+
+func (m *mockIdentifierRepository) ListIdentifiers(ctx context.Context, patientID int) ([]patient.Identifier, error) {
+	args := m.Called(ctx, patientID)
+	return args.Get(0).([]patient.Identifier), args.Error(1)
+}
+func (m *mockIdentifierRepository) CreateIdentifier(ctx context.Context, i *patient.Identifier) error {
+	return m.Called(ctx, i).Error(0)
+}
+func (m *mockIdentifierRepository) DeleteIdentifier(ctx context.Context, patientID, id int) error {
+	return m.Called(ctx, patientID, id).Error(0)
+}
+func (m *mockIdentifierRepository) FindPatientID(ctx context.Context, system, value string) (int, error) {
+	args := m.Called(ctx, system, value)
+	return args.Int(0), args.Error(1)
+}
+
+func TestMRNFormat(t *testing.T) {
+	registered := time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC)
+
+	f, err := patient.ParseMRNFormat(patient.DefaultMRNPattern)
+	require.NoError(t, err)
+	assert.Equal(t, "MRN-2026-", f.Scope(registered))
+	// Luhn over 2026000042: the check digit makes the sum a multiple of 10
+	assert.Equal(t, "MRN-2026-0000425", f.Format(registered, 42))
+	// The sequence outgrows its padding rather than wrapping
+	assert.Equal(t, "MRN-2026-12345670", f.Format(registered, 1234567))
+
+	f, err = patient.ParseMRNFormat("H{SEQ}")
+	require.NoError(t, err)
+	assert.Equal(t, "H", f.Scope(registered))
+	assert.Equal(t, "H7", f.Format(registered, 7))
+
+	f, err = patient.ParseMRNFormat("{YY}{CHECK}-{SEQ:4}")
+	require.NoError(t, err)
+	assert.Equal(t, "26-", f.Scope(registered))
+	assert.Len(t, f.Format(registered, 1), 8)
+
+	for _, pattern := range []string{"MRN-{YYYY}", "{SEQ}{SEQ}", "{SEQ}{CHECK}{CHECK}", "{SEQ:4}{MONTH}", "ABCDEFGHIJKLMNOPQRSTUVWXYZ-{YYYY}-{SEQ:8}"} {
+		_, err := patient.ParseMRNFormat(pattern)
+		assert.ErrorIs(t, err, patient.ErrInvalidMRNPattern, pattern)
+	}
+}
+
+func TestGetPatientByMRN(t *testing.T) {
+	repo := new(mockPatientRepository)
+	teams := new(mockCareTeamRepository)
+	svc := patient.NewService(repo, careteam.NewService(teams, new(mockEmergencyRepository)))
+
+	repo.On("GetByMRN", mock.Anything, "MRN-2026-0000425").Return(&patient.Patient{ID: 7, Name: "Ann Lee"}, nil)
+	repo.On("GetByMRN", mock.Anything, mock.Anything).Return(nil, patient.ErrPatientNotFound)
+
+	p, err := svc.GetPatientByMRN(context.Background(), testReceptionist, " mrn-2026-0000425 ")
+	require.NoError(t, err)
+	assert.Equal(t, 7, p.ID)
+
+	_, err = svc.GetPatientByMRN(context.Background(), testReceptionist, "MRN-2026-0000001")
+	assert.ErrorIs(t, err, patient.ErrPatientNotFound)
+
+	teams.On("IsMember", mock.Anything, 7, 5).Return(false, nil)
+	// Without access the MRN looks like it does not exist
+	_, err = svc.GetPatientByMRN(context.Background(), testDoctor, "MRN-2026-0000425")
+	assert.ErrorIs(t, err, patient.ErrPatientNotFound)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/patients/by-mrn/:mrn", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, patient.NewHandler(svc).GetPatientByMRN)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/by-mrn/MRN-2026-0000425", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Ann Lee"`)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/patients/by-mrn/NOPE", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestIdentifiers(t *testing.T) {
+	repo := new(mockIdentifierRepository)
+	patients := new(mockPatientRepository)
+	teams := new(mockCareTeamRepository)
+	svc := patient.NewIdentifierService(repo, patients, careteam.NewService(teams, new(mockEmergencyRepository)))
+	ctx := context.Background()
+
+	repo.On("CreateIdentifier", mock.Anything, mock.MatchedBy(func(i *patient.Identifier) bool {
+		return i.PatientID == 7 && i.System == patient.IdentifierNationalID && i.Value == "AB 12 34 56 C" && *i.CreatedBy == 2
+	})).Return(nil).Once()
+	i, err := svc.AddIdentifier(ctx, testReceptionist, 7, patient.IdentifierRequest{System: "national_id", Value: " AB 12 34 56 C "})
+	require.NoError(t, err)
+	assert.Equal(t, "AB 12 34 56 C", i.Value)
+
+	_, err = svc.AddIdentifier(ctx, testReceptionist, 7, patient.IdentifierRequest{System: "National ID", Value: "AB123456C"})
+	assert.ErrorIs(t, err, patient.ErrInvalidIdentifier)
+
+	// The same value in the same system identifies one patient only
+	repo.On("CreateIdentifier", mock.Anything, mock.Anything).Return(patient.ErrIdentifierTaken).Once()
+	_, err = svc.AddIdentifier(ctx, testReceptionist, 8, patient.IdentifierRequest{System: "national_id", Value: "ab 12 34 56 c"})
+	assert.ErrorIs(t, err, patient.ErrIdentifierTaken)
+
+	repo.On("FindPatientID", mock.Anything, patient.IdentifierInsuranceMember, "XK-99").Return(7, nil)
+	patients.On("GetByID", mock.Anything, 7).Return(&patient.Patient{ID: 7, Name: "Ann Lee"}, nil)
+	p, err := svc.FindPatient(ctx, testReceptionist, "insurance_member_id", "XK-99")
+	require.NoError(t, err)
+	assert.Equal(t, 7, p.ID)
+
+	teams.On("IsMember", mock.Anything, 7, 5).Return(false, nil)
+	_, err = svc.FindPatient(ctx, testDoctor, "insurance_member_id", "XK-99")
+	assert.ErrorIs(t, err, patient.ErrPatientNotFound)
+	_, err = svc.FindPatient(ctx, testReceptionist, "", "XK-99")
+	assert.ErrorIs(t, err, patient.ErrInvalidIdentifier)
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+type row struct {
+	ID   int
+	Name string
+}
+
+var rowSorter = pagination.Sorter[row]{
+	Keys: map[string]pagination.Column[row]{
+		"name": {Expr: "name", Cast: "text", Value: func(r *row) string { return r.Name }},
+	},
+	Default:  "name",
+	IDColumn: "id",
+	ID:       func(r *row) int { return r.ID },
+}
+
+func TestSorter_CursorRoundTrip(t *testing.T) {
+	q, err := rowSorter.Prepare(pagination.Request{Limit: 2}, 2)
+	require.NoError(t, err)
+	assert.Empty(t, q.Where)
+	assert.Equal(t, "name ASC, id ASC", q.OrderBy)
+	assert.Equal(t, 3, q.Limit)
+
+	page := rowSorter.Page(q, []row{{1, "Ann"}, {2, "Bob"}, {3, "Cid"}}, 5)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, 5, page.Total)
+	require.NotEmpty(t, page.NextCursor)
+
+	q, err = rowSorter.Prepare(pagination.Request{Limit: 2, Cursor: page.NextCursor}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "(name, id) > ($2::text, $3)", q.Where)
+	assert.Equal(t, []interface{}{"Bob", 2}, q.Args)
+
+	// The last page carries no cursor
+	page = rowSorter.Page(q, []row{{3, "Cid"}}, 5)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestSorter_RejectsBadInput(t *testing.T) {
+	_, err := rowSorter.Prepare(pagination.Request{Sort: "diagnosis"}, 1)
+	assert.ErrorIs(t, err, pagination.ErrInvalidSort)
+
+	_, err = rowSorter.Prepare(pagination.Request{Cursor: "not-a-cursor"}, 1)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	// A cursor issued for one order cannot be replayed against another
+	q, _ := rowSorter.Prepare(pagination.Request{Limit: 1}, 1)
+	page := rowSorter.Page(q, []row{{1, "Ann"}, {2, "Bob"}}, 2)
+	_, err = rowSorter.Prepare(pagination.Request{Sort: "-name", Cursor: page.NextCursor}, 1)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	// Values that do not parse as the column's type are rejected before they
+	// reach the query
+	typed := pagination.Sorter[row]{
+		Keys: map[string]pagination.Column[row]{
+			"created": {Expr: "created_at", Cast: "timestamp", Value: func(r *row) string { return r.Name }},
+			"born":    {Expr: "date_of_birth", Cast: "date", Value: func(r *row) string { return r.Name }},
+			"rank":    {Expr: "rank", Cast: "int", Value: func(r *row) string { return r.Name }},
+		},
+		Default:  "created",
+		IDColumn: "id",
+		ID:       func(r *row) int { return r.ID },
+	}
+	cursorFor := func(sort, value string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(`{"s":"` + sort + `","v":"` + value + `","id":1}`))
+	}
+	for sort, value := range map[string]string{"created": "2026-03-09 10:00:00.123456", "born": "1980-03-04", "rank": "42"} {
+		_, err = typed.Prepare(pagination.Request{Sort: sort, Cursor: cursorFor(sort, value)}, 1)
+		assert.NoError(t, err, sort)
+		_, err = typed.Prepare(pagination.Request{Sort: sort, Cursor: cursorFor(sort, "yesterday")}, 1)
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor, sort)
+	}
+
+	q, err = rowSorter.Prepare(pagination.Request{Limit: 10000, Sort: "-name"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, pagination.MaxLimit+1, q.Limit)
+	assert.Equal(t, "name DESC, id DESC", q.OrderBy)
+}
+
+func TestListPatients_RestrictsDoctorsToPanel(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+	empty := &pagination.Page[patient.Patient]{Items: []patient.Patient{}}
+
+	repo.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.PanelOf == 5 })).Return(empty, nil).Once()
+	_, err := svc.ListPatients(context.Background(), testDoctor, patient.ListOptions{})
+	require.NoError(t, err)
+
+	// A client cannot widen its own listing by setting the panel itself
+	repo.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.PanelOf == 0 })).Return(empty, nil).Once()
+	_, err = svc.ListPatients(context.Background(), testReceptionist, patient.ListOptions{PanelOf: 5})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	page, err := svc.ListPatients(context.Background(), nil, patient.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+}
+
+func TestListPatients_DiagnosisFilterRequiresClinicalAccess(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+	hasDiagnosis := true
+
+	_, err := svc.ListPatients(context.Background(), testReceptionist, patient.ListOptions{HasDiagnosis: &hasDiagnosis})
+	assert.ErrorIs(t, err, patient.ErrFilterForbidden)
+	_, err = svc.ListDeletedPatients(context.Background(), testReceptionist, patient.ListOptions{HasDiagnosis: &hasDiagnosis})
+	assert.ErrorIs(t, err, patient.ErrFilterForbidden)
+	repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+
+	repo.On("List", mock.Anything, mock.MatchedBy(func(o patient.ListOptions) bool { return o.HasDiagnosis != nil })).
+		Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{}}, nil).Once()
+	_, err = svc.ListPatients(context.Background(), testClinician, patient.ListOptions{HasDiagnosis: &hasDiagnosis})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestListPatients_ParsesFiltersAndEnvelope(t *testing.T) {
+	mockSvc := new(mockPatientService)
+	h := patient.NewHandler(mockSvc)
+
+	var got patient.ListOptions
+	dob := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("ListPatients", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		got = args.Get(2).(patient.ListOptions)
+	}).Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{{ID: 1, Name: "Jane", DateOfBirth: dob}}, NextCursor: "abc", Total: 3}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/patients", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.ListPatients)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients?age_min=30&created_to=2026-01-31&has_diagnosis=true&doctor_id=5&sort=-name&limit=10", nil)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"items":[{"id":1,"name":"Jane","date_of_birth":"1990-05-17","dob_estimated":false,"age":{"value":`+strconv.Itoa(patient.AgeInYears(dob, time.Now()))+`,"unit":"years"},"address":"","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","revision":0}],"next_cursor":"abc","total":3}`, w.Body.String())
+
+	require.NotNil(t, got.AgeMin)
+	assert.Equal(t, 30, *got.AgeMin)
+	require.NotNil(t, got.CreatedTo)
+	assert.Equal(t, "2026-01-31", got.CreatedTo.Format("2006-01-02"))
+	require.NotNil(t, got.HasDiagnosis)
+	assert.True(t, *got.HasDiagnosis)
+	require.NotNil(t, got.DoctorID)
+	assert.Equal(t, 5, *got.DoctorID)
+	assert.Equal(t, "-name", got.Sort)
+	assert.Equal(t, 10, got.Limit)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/patients?limit=1000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
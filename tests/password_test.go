@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/notify"
+)
+
+var testPasswordOptions = auth.PasswordOptions{
+	ResetURL: "http://localhost:3000/reset-password",
+	ResetTTL: time.Hour,
+}
+
+func TestIssuePasswordReset_DeliversSingleUseLink(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	authSvc := new(mockAuthService)
+	sender := notify.NewMemorySender()
+	svc := auth.NewPasswordService(users, tokens, authSvc, sender, testPasswordOptions)
+
+	email := "house@example.com"
+	users.On("GetUserByID", mock.Anything, 5).Return(&auth.User{ID: 5, Username: "house", Email: &email, IsActive: true}, nil)
+	users.On("CreatePasswordResetToken", mock.Anything, 5, 1, mock.Anything, time.Hour).Return(time.Now().Add(time.Hour), nil)
+	users.On("SetMustChangePassword", mock.Anything, 5, true).Return(nil)
+
+	resp, err := svc.IssuePasswordReset(context.Background(), 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 5, resp.UserID)
+
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "house@example.com", messages[0].To)
+
+	token := extractResetToken(t, messages[0].Body)
+	storedHash := users.Calls[1].Arguments.String(3)
+	assert.Equal(t, sha256Hex(token), storedHash, "only the hash of the emailed token may be stored")
+
+	// Redeeming the delivered token sets the password and signs the user out everywhere
+	users.On("ConsumePasswordResetToken", mock.Anything, storedHash).Return(5, nil).Once()
+	authSvc.On("HashPassword", "brand-new-password").Return("new-hash", nil)
+	users.On("UpdatePassword", mock.Anything, 5, "new-hash", false).Return(nil)
+	authSvc.On("LogoutAll", mock.Anything, 5).Return(nil)
+
+	err = svc.ResetPassword(context.Background(), auth.ResetPasswordRequest{Token: token, NewPassword: "brand-new-password"})
+	require.NoError(t, err)
+
+	// The token is single use
+	users.On("ConsumePasswordResetToken", mock.Anything, storedHash).Return(0, auth.ErrInvalidResetToken)
+	err = svc.ResetPassword(context.Background(), auth.ResetPasswordRequest{Token: token, NewPassword: "another-password"})
+	assert.ErrorIs(t, err, auth.ErrInvalidResetToken)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	authSvc := new(mockAuthService)
+	svc := auth.NewPasswordService(users, tokens, authSvc, notify.NewMemorySender(), testPasswordOptions)
+
+	users.On("GetUserByID", mock.Anything, 2).Return(&auth.User{ID: 2, PasswordHash: "hash"}, nil)
+	authSvc.On("CheckPasswordHash", "wrong", "hash").Return(false)
+
+	err := svc.ChangePassword(context.Background(), &middleware.Identity{UserID: 2, SessionID: "sess"}, auth.ChangePasswordRequest{
+		CurrentPassword: "wrong",
+		NewPassword:     "password456",
+	})
+
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	users.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestChangePassword_KeepsCurrentSession(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	authSvc := new(mockAuthService)
+	svc := auth.NewPasswordService(users, tokens, authSvc, notify.NewMemorySender(), testPasswordOptions)
+
+	users.On("GetUserByID", mock.Anything, 2).Return(&auth.User{ID: 2, PasswordHash: "hash", MustChangePassword: true}, nil)
+	authSvc.On("CheckPasswordHash", "password123", "hash").Return(true)
+	authSvc.On("HashPassword", "password456").Return("new-hash", nil)
+	users.On("UpdatePassword", mock.Anything, 2, "new-hash", false).Return(nil)
+	tokens.On("RevokeOtherSessions", mock.Anything, 2, "sess").Return(nil)
+
+	err := svc.ChangePassword(context.Background(), &middleware.Identity{UserID: 2, SessionID: "sess"}, auth.ChangePasswordRequest{
+		CurrentPassword: "password123",
+		NewPassword:     "password456",
+	})
+
+	require.NoError(t, err)
+	tokens.AssertCalled(t, "RevokeOtherSessions", mock.Anything, 2, "sess")
+}
+
+func TestPasswordChangeMiddleware_BlocksPendingChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/protected", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, &middleware.Identity{UserID: 2, PasswordChangeRequired: true})
+	}, middleware.PasswordChangeMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Password change required")
+}
+
+func extractResetToken(t *testing.T, body string) string {
+	for _, field := range strings.Fields(body) {
+		if u, err := url.Parse(field); err == nil && u.Query().Get("token") != "" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no reset link found in message body: %q", body)
+	return ""
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+var (
+	testFrontDesk = &middleware.Identity{
+		UserID:      2,
+		Role:        "receptionist",
+		Permissions: []string{authz.PatientRead, authz.PatientAccessAll, authz.PatientUpdate},
+	}
+	testAttending = &middleware.Identity{
+		UserID:      5,
+		Role:        "doctor",
+		Permissions: []string{authz.PatientRead, authz.PatientAccessAll, authz.PatientClinicalRead, authz.PatientMedicalWrite},
+	}
+)
+
+func TestMergePatch(t *testing.T) {
+	var target, patch interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"b","c":{"d":"e","f":"g"}}`), &target))
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"z","c":{"f":null}}`), &patch))
+	assert.Equal(t, mustJSON(t, map[string]interface{}{"a": "z", "c": map[string]interface{}{"d": "e"}}), mustJSON(t, patient.MergePatch(target, patch)))
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	apply := func(doc, ops string) (string, error) {
+		var d interface{}
+		var o []patient.PatchOperation
+		require.NoError(t, json.Unmarshal([]byte(doc), &d))
+		require.NoError(t, json.Unmarshal([]byte(ops), &o))
+		out, err := patient.ApplyJSONPatch(d, o)
+		if err != nil {
+			return "", err
+		}
+		raw, _ := json.Marshal(out)
+		return string(raw), nil
+	}
+
+	out, err := apply(`{"a":1,"list":["x","y"]}`, `[
+		{"op":"add","path":"/list/1","value":"w"},
+		{"op":"add","path":"/list/-","value":"z"},
+		{"op":"remove","path":"/list/0"},
+		{"op":"replace","path":"/a","value":2},
+		{"op":"copy","from":"/a","path":"/b"},
+		{"op":"move","from":"/b","path":"/c~1d"},
+		{"op":"test","path":"/list","value":["w","y","z"]}
+	]`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":2,"c/d":2,"list":["w","y","z"]}`, out)
+
+	_, err = apply(`{"a":1}`, `[{"op":"test","path":"/a","value":2}]`)
+	assert.ErrorIs(t, err, patient.ErrPatchTestFailed)
+	_, err = apply(`{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`)
+	assert.ErrorIs(t, err, patient.ErrInvalidPatch)
+	_, err = apply(`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`)
+	assert.ErrorIs(t, err, patient.ErrInvalidPatch)
+	_, err = apply(`{"a":1}`, `[{"op":"frobnicate","path":"/a"}]`)
+	assert.ErrorIs(t, err, patient.ErrInvalidPatch)
+}
+
+func patchFixture() *patient.Patient {
+	phone, diagnosis := "555-0100", "Asthma"
+	return &patient.Patient{
+		ID:          3,
+		Name:        "Jane Doe",
+		PhoneNumber: &phone,
+		DateOfBirth: time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC),
+		Address:     "1 Main St",
+		Diagnosis:   &diagnosis,
+		Revision:    4,
+	}
+}
+
+func newPatchService(repo *mockPatientRepository) patient.Service {
+	return patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+}
+
+func TestPatchPatient_MergePatchClearsPhone(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := newPatchService(repo)
+	repo.On("GetByID", mock.Anything, 3).Return(patchFixture(), nil)
+	repo.On("Patch", mock.Anything, mock.MatchedBy(func(p *patient.Patient) bool {
+		return p.PhoneNumber == nil && p.Email != nil && *p.Email == "jane@example.com" &&
+			p.Name == "Jane Doe" && p.Diagnosis != nil && *p.Diagnosis == "Asthma" &&
+			p.Revision == 4 && *p.UpdatedBy == 2
+	})).Return(nil)
+
+	_, err := svc.PatchPatient(context.Background(), testFrontDesk, 3, patient.PatchRequest{
+		Type: patient.MergePatchType,
+		Body: []byte(`{"phone_number": null, "email": "Jane@Example.com"}`),
+	})
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "Patch", 1)
+}
+
+func TestPatchPatient_FieldChecks(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := newPatchService(repo)
+	repo.On("GetByID", mock.Anything, 3).Return(patchFixture(), nil)
+
+	patch := func(caller *middleware.Identity, patchType, body string) error {
+		_, err := svc.PatchPatient(context.Background(), caller, 3, patient.PatchRequest{Type: patchType, Body: []byte(body)})
+		return err
+	}
+
+	// Receptionists can neither see nor change clinical fields
+	assert.ErrorIs(t, patch(testFrontDesk, patient.MergePatchType, `{"diagnosis": "Flu"}`), patient.ErrFieldForbidden)
+	assert.ErrorIs(t, patch(testFrontDesk, patient.JSONPatchType, `[{"op":"remove","path":"/diagnosis"}]`), patient.ErrInvalidPatch)
+	// Clinicians without write access cannot change them either, nor demographics
+	assert.ErrorIs(t, patch(testClinician, patient.MergePatchType, `{"notes": "Follow up"}`), patient.ErrFieldForbidden)
+	assert.ErrorIs(t, patch(testAttending, patient.MergePatchType, `{"name": "Jane Smith"}`), patient.ErrFieldForbidden)
+	// The patched patient must still be valid
+	assert.ErrorIs(t, patch(testFrontDesk, patient.MergePatchType, `{"name": null}`), patient.ErrInvalidPatch)
+	assert.ErrorIs(t, patch(testFrontDesk, patient.MergePatchType, `{"mrn": "MRN-1"}`), patient.ErrInvalidPatch)
+	assert.ErrorIs(t, patch(testFrontDesk, patient.MergePatchType, `{"country": "Portugal"}`), patient.ErrInvalidPatch)
+	assert.ErrorIs(t, patch(testFrontDesk, patient.MergePatchType, `{"date_of_birth": "2990-01-01"}`), patient.ErrInvalidDateOfBirth)
+	assert.ErrorIs(t, patch(testFrontDesk, patient.JSONPatchType, `[{"op":"test","path":"/name","value":"John"}]`), patient.ErrPatchTestFailed)
+	repo.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything)
+
+	repo.On("Patch", mock.Anything, mock.MatchedBy(func(p *patient.Patient) bool {
+		return p.Diagnosis != nil && *p.Diagnosis == "Flu" && p.Notes == nil
+	})).Return(nil).Once()
+	assert.NoError(t, patch(testAttending, patient.JSONPatchType, `[{"op":"replace","path":"/diagnosis","value":"Flu"}]`))
+}
+
+func TestPatchPatient_Revisions(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := newPatchService(repo)
+	stale, fresh := patchFixture(), patchFixture()
+	fresh.Revision = 5
+	repo.On("GetByID", mock.Anything, 3).Return(stale, nil).Once()
+	repo.On("GetByID", mock.Anything, 3).Return(fresh, nil)
+	repo.On("Patch", mock.Anything, mock.MatchedBy(func(p *patient.Patient) bool { return p.Revision == 4 })).Return(patient.ErrRevisionMismatch).Once()
+	repo.On("Patch", mock.Anything, mock.MatchedBy(func(p *patient.Patient) bool { return p.Revision == 5 })).Return(nil).Once()
+
+	// Without If-Match a concurrent change is merged by patching again
+	_, err := svc.PatchPatient(context.Background(), testFrontDesk, 3, patient.PatchRequest{Type: patient.MergePatchType, Body: []byte(`{"city": "Porto"}`)})
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "Patch", 2)
+
+	// With If-Match it is refused
+	_, err = svc.PatchPatient(context.Background(), testFrontDesk, 3, patient.PatchRequest{Type: patient.MergePatchType, Body: []byte(`{"city": "Porto"}`), IfRevision: 4})
+	var rev *patient.RevisionError
+	require.ErrorAs(t, err, &rev)
+	assert.Equal(t, 5, rev.Current.Revision)
+	repo.AssertNumberOfCalls(t, "Patch", 2)
+}
+
+func TestPatchPatient_Handler(t *testing.T) {
+	mockSvc := new(mockPatientService)
+	h := patient.NewHandler(mockSvc)
+	body := `{"phone_number": null}`
+	mockSvc.On("PatchPatient", mock.Anything, mock.Anything, 3, patient.PatchRequest{Type: patient.MergePatchType, Body: []byte(body), IfRevision: 4}).
+		Return(&patient.Patient{ID: 3, Name: "Jane Doe", Revision: 5}, nil)
+	mockSvc.On("PatchPatient", mock.Anything, mock.Anything, 3, patient.PatchRequest{Type: patient.JSONPatchType, Body: []byte(`[]`)}).
+		Return(nil, patient.ErrFieldForbidden)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PATCH("/patients/:id", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testFrontDesk)
+	}, h.PatchPatient)
+
+	send := func(contentType, ifMatch, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/patients/3", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("If-Match", ifMatch)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(patient.MergePatchType, `"4"`, body)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusForbidden, send(patient.JSONPatchType, "", `[]`).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, send("application/json", "", body).Code)
+}
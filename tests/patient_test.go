@@ -2,73 +2,106 @@ package tests
 
 import (
     "context"
-    "errors"
     "testing"
 	"bytes"
     "encoding/json"
     "fmt"
     "net/http"
     "net/http/httptest"
+    "time"
 
 	"github.com/gin-gonic/gin"
     "github.com/stretchr/testify/assert"
     "github.com/stretchr/testify/mock"
 
+    "github.com/kyash99252/Medical-Portal/internal/middleware"
     "github.com/kyash99252/Medical-Portal/internal/patient"
+    "github.com/kyash99252/Medical-Portal/pkg/pagination"
 )
 
 type mockPatientService struct {
     mock.Mock
 }
 
-func (m *mockPatientService) CreatePatient(ctx context.Context, req patient.CreatePatientRequest) (*patient.Patient, error) {
-    args := m.Called(ctx, req)
+func (m *mockPatientService) CreatePatient(ctx context.Context, caller *middleware.Identity, req patient.CreatePatientRequest) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, req)
     if args.Get(0) == nil {
         return nil, args.Error(1)
     }
     return args.Get(0).(*patient.Patient), args.Error(1)
 }
-func (m *mockPatientService) GetPatient(ctx context.Context, id int) (*patient.Patient, error) {
-    args := m.Called(ctx, id)
+func (m *mockPatientService) GetPatient(ctx context.Context, caller *middleware.Identity, id int) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, id)
     if args.Get(0) == nil {
         return nil, args.Error(1)
     }
     return args.Get(0).(*patient.Patient), args.Error(1)
 }
-func (m *mockPatientService) ListAllPatients(ctx context.Context) ([]patient.Patient, error) {
-    args := m.Called(ctx)
-    return args.Get(0).([]patient.Patient), args.Error(1)
+func (m *mockPatientService) GetPatientByMRN(ctx context.Context, caller *middleware.Identity, mrn string) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, mrn)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*patient.Patient), args.Error(1)
 }
-func (m *mockPatientService) UpdatePatient(ctx context.Context, id int, req patient.UpdatePatientRequest) (*patient.Patient, error) {
-    args := m.Called(ctx, id, req)
+func (m *mockPatientService) ListPatients(ctx context.Context, caller *middleware.Identity, opts patient.ListOptions) (*pagination.Page[patient.Patient], error) {
+    args := m.Called(ctx, caller, opts)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*pagination.Page[patient.Patient]), args.Error(1)
+}
+func (m *mockPatientService) UpdatePatient(ctx context.Context, caller *middleware.Identity, id int, req patient.UpdatePatientRequest) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, id, req)
     if args.Get(0) == nil {
         return nil, args.Error(1)
     }
     return args.Get(0).(*patient.Patient), args.Error(1)
 }
-func (m *mockPatientService) UpdatePatientMedical(ctx context.Context, id int, req patient.UpdatePatientMedicalRequest) (*patient.Patient, error) {
-    args := m.Called(ctx, id, req)
+func (m *mockPatientService) UpdatePatientMedical(ctx context.Context, caller *middleware.Identity, id int, req patient.UpdatePatientMedicalRequest) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, id, req)
     if args.Get(0) == nil {
         return nil, args.Error(1)
     }
     return args.Get(0).(*patient.Patient), args.Error(1)
 }
-func (m *mockPatientService) DeletePatient(ctx context.Context, id int) error {
-    args := m.Called(ctx, id)
+func (m *mockPatientService) DeletePatient(ctx context.Context, caller *middleware.Identity, id int) error {
+    args := m.Called(ctx, caller, id)
     return args.Error(0)
 }
-func (m *mockPatientService) SearchPatients(ctx context.Context, name string) ([]patient.Patient, error) {
-    args := m.Called(ctx, name)
-    return args.Get(0).([]patient.Patient), args.Error(1)
+func (m *mockPatientService) PatchPatient(ctx context.Context, caller *middleware.Identity, id int, req patient.PatchRequest) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, id, req)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*patient.Patient), args.Error(1)
+}
+func (m *mockPatientService) RestorePatient(ctx context.Context, caller *middleware.Identity, id int) (*patient.Patient, error) {
+    args := m.Called(ctx, caller, id)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*patient.Patient), args.Error(1)
+}
+func (m *mockPatientService) ListDeletedPatients(ctx context.Context, caller *middleware.Identity, opts patient.ListOptions) (*pagination.Page[patient.Patient], error) {
+    args := m.Called(ctx, caller, opts)
+    if args.Get(0) == nil {
+        return nil, args.Error(1)
+    }
+    return args.Get(0).(*pagination.Page[patient.Patient]), args.Error(1)
+}
+func (m *mockPatientService) SearchPatients(ctx context.Context, caller *middleware.Identity, opts patient.SearchOptions) ([]patient.SearchResult, error) {
+    args := m.Called(ctx, caller, opts)
+    return args.Get(0).([]patient.SearchResult), args.Error(1)
 }
 
 func TestCreatePatient_Success(t *testing.T) {
     mockSvc := new(mockPatientService)
     h := patient.NewHandler(mockSvc)
 
-    req := patient.CreatePatientRequest{Name: "John", Age: 30, Address: "123 St"}
-    pat := &patient.Patient{ID: 1, Name: "John", Age: 30, Address: "123 St"}
-    mockSvc.On("CreatePatient", mock.Anything, req).Return(pat, nil)
+    req := patient.CreatePatientRequest{Name: "John", DateOfBirth: "1994-06-01", Address: "123 St"}
+    pat := &patient.Patient{ID: 1, Name: "John", DateOfBirth: time.Date(1994, 6, 1, 0, 0, 0, 0, time.UTC), Address: "123 St"}
+    mockSvc.On("CreatePatient", mock.Anything, mock.Anything, req).Return(pat, nil)
 
     w := performPatientRequest(h.CreatePatient, "POST", req)
 
@@ -80,7 +113,7 @@ func TestGetPatient_NotFound(t *testing.T) {
     mockSvc := new(mockPatientService)
     h := patient.NewHandler(mockSvc)
 
-    mockSvc.On("GetPatient", mock.Anything, 99).Return(nil, errors.New("patient not found"))
+    mockSvc.On("GetPatient", mock.Anything, mock.Anything, 99).Return(nil, patient.ErrPatientNotFound)
     w := performPatientRequestWithID(h.GetPatient, "GET", 99, nil)
 
     assert.Equal(t, 404, w.Code)
@@ -90,7 +123,7 @@ func TestGetPatient_NotFound(t *testing.T) {
 func TestListPatients_Empty(t *testing.T) {
     mockSvc := new(mockPatientService)
     h := patient.NewHandler(mockSvc)
-    mockSvc.On("ListAllPatients", mock.Anything).Return([]patient.Patient{}, nil)
+    mockSvc.On("ListPatients", mock.Anything, mock.Anything, mock.Anything).Return(&pagination.Page[patient.Patient]{Items: []patient.Patient{}}, nil)
     w := performPatientRequest(h.ListPatients, "GET", nil)
     assert.Equal(t, 200, w.Code)
     assert.Contains(t, w.Body.String(), "[]")
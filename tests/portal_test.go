@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/document"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/notify"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+	"github.com/kyash99252/Medical-Portal/internal/portal"
+	"github.com/kyash99252/Medical-Portal/internal/prescription"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+type mockPortalRepository struct {
+	mock.Mock
+}
+
+func (m *mockPortalRepository) HasAccount(ctx context.Context, patientID int) (bool, error) {
+	args := m.Called(ctx, patientID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockPortalRepository) CreateInvitation(ctx context.Context, patientID, createdBy int, email, tokenHash string, ttl time.Duration) (time.Time, error) {
+	args := m.Called(ctx, patientID, createdBy, email, tokenHash, ttl)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (m *mockPortalRepository) ActivateAccount(ctx context.Context, tokenHash string, u *auth.User) error {
+	return m.Called(ctx, tokenHash, u).Error(0)
+}
+
+type mockPatientRepository struct {
+	mock.Mock
+}
+
+func (m *mockPatientRepository) Create(ctx context.Context, p *patient.Patient) error {
+	return m.Called(ctx, p).Error(0)
+}
+func (m *mockPatientRepository) GetByID(ctx context.Context, id int) (*patient.Patient, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Patient), args.Error(1)
+}
+func (m *mockPatientRepository) GetByMRN(ctx context.Context, mrn string) (*patient.Patient, error) {
+	args := m.Called(ctx, mrn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Patient), args.Error(1)
+}
+func (m *mockPatientRepository) List(ctx context.Context, opts patient.ListOptions) (*pagination.Page[patient.Patient], error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[patient.Patient]), args.Error(1)
+}
+func (m *mockPatientRepository) Update(ctx context.Context, p *patient.Patient) error {
+	return m.Called(ctx, p).Error(0)
+}
+func (m *mockPatientRepository) UpdateMedical(ctx context.Context, id int, diagnosis, notes string, updatedBy patient.Actor, ifRevision int) error {
+	return m.Called(ctx, id, diagnosis, notes, updatedBy, ifRevision).Error(0)
+}
+func (m *mockPatientRepository) Patch(ctx context.Context, p *patient.Patient) error {
+	return m.Called(ctx, p).Error(0)
+}
+func (m *mockPatientRepository) Delete(ctx context.Context, id int, deletedBy patient.Actor) error {
+	return m.Called(ctx, id, deletedBy).Error(0)
+}
+func (m *mockPatientRepository) Restore(ctx context.Context, id int, restoredBy patient.Actor) error {
+	return m.Called(ctx, id, restoredBy).Error(0)
+}
+func (m *mockPatientRepository) ListExpired(ctx context.Context, retention time.Duration, limit int) ([]int, error) {
+	args := m.Called(ctx, retention, limit)
+	return args.Get(0).([]int), args.Error(1)
+}
+func (m *mockPatientRepository) DocumentPublicIDs(ctx context.Context, patientID int) ([]string, error) {
+	args := m.Called(ctx, patientID)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockPatientRepository) Purge(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *mockPatientRepository) Search(ctx context.Context, opts patient.SearchOptions) ([]patient.SearchResult, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).([]patient.SearchResult), args.Error(1)
+}
+func (m *mockPatientRepository) FindDuplicates(ctx context.Context, p *patient.Patient, panelOf int) ([]patient.DuplicateCandidate, error) {
+	args := m.Called(ctx, p, panelOf)
+	return args.Get(0).([]patient.DuplicateCandidate), args.Error(1)
+}
+
+type mockDocumentRepository struct {
+	mock.Mock
+}
+
+func (m *mockDocumentRepository) Create(ctx context.Context, doc *document.Document) error {
+	return m.Called(ctx, doc).Error(0)
+}
+func (m *mockDocumentRepository) GetByID(ctx context.Context, id int) (*document.Document, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*document.Document), args.Error(1)
+}
+func (m *mockDocumentRepository) GetByPatientID(ctx context.Context, patientID int, req pagination.Request) (*pagination.Page[document.Document], error) {
+	args := m.Called(ctx, patientID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[document.Document]), args.Error(1)
+}
+func (m *mockDocumentRepository) Delete(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type fakePasswordHasher struct{}
+
+func (fakePasswordHasher) HashPassword(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+type portalFixture struct {
+	repo          *mockPortalRepository
+	patients      *mockPatientRepository
+	prescriptions *mockPrescriptionRepository
+	documents     *mockDocumentRepository
+	sender        *notify.MemorySender
+	svc           portal.Service
+}
+
+func newPortalFixture() *portalFixture {
+	f := &portalFixture{
+		repo:          new(mockPortalRepository),
+		patients:      new(mockPatientRepository),
+		prescriptions: new(mockPrescriptionRepository),
+		documents:     new(mockDocumentRepository),
+		sender:        notify.NewMemorySender(),
+	}
+	f.svc = portal.NewService(f.repo, f.patients, f.prescriptions, f.documents, fakePasswordHasher{}, f.sender, portal.Options{
+		InviteURL: "https://portal.example.org/activate",
+		InviteTTL: 72 * time.Hour,
+	})
+	return f
+}
+
+func TestPortalInvite_DeliversLinkWithoutReturningToken(t *testing.T) {
+	f := newPortalFixture()
+	expiresAt := time.Now().Add(72 * time.Hour)
+
+	var storedHash string
+	f.patients.On("GetByID", mock.Anything, 7).Return(&patient.Patient{ID: 7, Name: "Jane Smith"}, nil)
+	f.repo.On("HasAccount", mock.Anything, 7).Return(false, nil)
+	f.repo.On("CreateInvitation", mock.Anything, 7, 2, "jane@example.org", mock.Anything, 72*time.Hour).Run(func(args mock.Arguments) {
+		storedHash = args.String(4)
+	}).Return(expiresAt, nil)
+
+	resp, err := f.svc.Invite(context.Background(), 2, 7, portal.InviteRequest{Email: "jane@example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, 7, resp.PatientID)
+	assert.Equal(t, expiresAt, resp.ExpiresAt)
+
+	messages := f.sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "jane@example.org", messages[0].To)
+
+	i := strings.Index(messages[0].Body, "https://portal.example.org/activate?")
+	require.GreaterOrEqual(t, i, 0)
+	link, err := url.Parse(strings.TrimSpace(messages[0].Body[i:]))
+	require.NoError(t, err)
+	token := link.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	sum := sha256.Sum256([]byte(token))
+	assert.Equal(t, hex.EncodeToString(sum[:]), storedHash)
+
+	f.repo.On("HasAccount", mock.Anything, 8).Return(true, nil)
+	f.patients.On("GetByID", mock.Anything, 8).Return(&patient.Patient{ID: 8}, nil)
+	_, err = f.svc.Invite(context.Background(), 2, 8, portal.InviteRequest{Email: "john@example.org"})
+	assert.ErrorIs(t, err, portal.ErrAccountExists)
+}
+
+func TestPortalActivate_CreatesPatientAccount(t *testing.T) {
+	f := newPortalFixture()
+
+	var created *auth.User
+	f.repo.On("ActivateAccount", mock.Anything, auth.HashOpaqueToken("invite-token"), mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(2).(*auth.User)
+	}).Return(nil)
+
+	_, err := f.svc.Activate(context.Background(), portal.ActivateRequest{Token: "invite-token", Username: "jane", Password: "s3cret-pass"})
+	require.NoError(t, err)
+	assert.Equal(t, auth.RolePatient, created.Role)
+	assert.Equal(t, "hashed:s3cret-pass", created.PasswordHash)
+	assert.True(t, created.IsActive)
+}
+
+func TestPortal_OnlyServesOwnRecord(t *testing.T) {
+	f := newPortalFixture()
+	diagnosis, notes := "Hypertension", "Discussed family history"
+	f.patients.On("GetByID", mock.Anything, 7).Return(&patient.Patient{
+		ID: 7, Name: "Jane Smith", DateOfBirth: time.Date(1991, 8, 20, 0, 0, 0, 0, time.UTC), Address: "456 Oak Ave", Diagnosis: &diagnosis, Notes: &notes,
+	}, nil)
+	f.prescriptions.On("GetActiveByPatientID", mock.Anything, 7).Return([]prescription.Prescription{{ID: 1, PatientID: 7}}, nil)
+
+	caller := &middleware.Identity{UserID: 30, Role: auth.RolePatient, PatientID: 7}
+
+	profile, err := f.svc.GetProfile(context.Background(), caller)
+	require.NoError(t, err)
+	body, err := json.Marshal(profile)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "Jane Smith")
+	assert.NotContains(t, string(body), notes)
+	assert.NotContains(t, string(body), diagnosis)
+
+	prescriptions, err := f.svc.ListPrescriptions(context.Background(), caller)
+	require.NoError(t, err)
+	assert.Len(t, prescriptions, 1)
+	f.prescriptions.AssertNotCalled(t, "GetByPatientID", mock.Anything, mock.Anything, mock.Anything)
+
+	// Staff accounts have no record of their own
+	_, err = f.svc.GetProfile(context.Background(), testReceptionist)
+	assert.ErrorIs(t, err, portal.ErrNotAPatient)
+	_, err = f.svc.ListDocuments(context.Background(), testDoctor, pagination.Request{})
+	assert.ErrorIs(t, err, portal.ErrNotAPatient)
+}
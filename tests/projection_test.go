@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+	"github.com/kyash99252/Medical-Portal/pkg/pagination"
+)
+
+func TestProject_HidesClinicalFieldsWithoutPermission(t *testing.T) {
+	diagnosis, notes := "Hypertension", "Prescribed beta-blockers"
+	p := &patient.Patient{ID: 1, Name: "John Doe", DateOfBirth: time.Date(1980, 3, 2, 0, 0, 0, 0, time.UTC), Address: "123 Main St", Diagnosis: &diagnosis, Notes: &notes}
+
+	body, err := json.Marshal(patient.Project(testReceptionist, p))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "John Doe")
+	assert.NotContains(t, string(body), "diagnosis")
+	assert.NotContains(t, string(body), "notes")
+
+	clinician := &middleware.Identity{UserID: 5, Role: "doctor", Permissions: []string{authz.PatientRead, authz.PatientClinicalRead}}
+	body, err = json.Marshal(patient.Project(clinician, p))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"diagnosis":"Hypertension"`)
+	assert.Contains(t, string(body), `"notes":"Prescribed beta-blockers"`)
+
+	// A caller without an identity only ever sees demographics
+	body, err = json.Marshal(patient.Project(nil, p))
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "Hypertension")
+}
+
+func TestListPatients_ProjectsPerCaller(t *testing.T) {
+	diagnosis := "Asthma"
+	mockSvc := new(mockPatientService)
+	h := patient.NewHandler(mockSvc)
+	mockSvc.On("ListPatients", mock.Anything, mock.Anything, mock.Anything).Return(&pagination.Page[patient.Patient]{
+		Items: []patient.Patient{{ID: 1, Name: "Jane", Diagnosis: &diagnosis}},
+		Total: 1,
+	}, nil)
+
+	list := func(caller *middleware.Identity) string {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/patients", func(c *gin.Context) {
+			c.Set(middleware.ContextKeyIdentity, caller)
+		}, h.ListPatients)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/patients", nil)
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	assert.NotContains(t, list(testReceptionist), "Asthma")
+	clinician := &middleware.Identity{UserID: 5, Role: "doctor", Permissions: []string{authz.PatientClinicalRead}}
+	assert.Contains(t, list(clinician), "Asthma")
+}
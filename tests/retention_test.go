@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+type fakeFileStore struct {
+	deleted []string
+	failOn  string
+}
+
+func (f *fakeFileStore) DeleteFile(ctx context.Context, publicID string) error {
+	if publicID == f.failOn {
+		return errors.New("storage unavailable")
+	}
+	f.deleted = append(f.deleted, publicID)
+	return nil
+}
+
+func TestDeletePatient_SoftDeletesAndRecordsActor(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+
+	repo.On("Delete", mock.Anything, 10, mock.MatchedBy(func(by patient.Actor) bool { return *by.UserID == 2 && by.APIKeyID == nil })).Return(nil)
+	require.NoError(t, svc.DeletePatient(context.Background(), testReceptionist, 10))
+
+	// API keys have no user; the key is recorded instead
+	apiKey := &middleware.Identity{APIKeyID: 3, Permissions: testReceptionist.Permissions}
+	repo.On("Delete", mock.Anything, 11, mock.MatchedBy(func(by patient.Actor) bool { return by.UserID == nil && *by.APIKeyID == 3 })).Return(nil)
+	require.NoError(t, svc.DeletePatient(context.Background(), apiKey, 11))
+
+	repo.On("Restore", mock.Anything, 10, mock.MatchedBy(func(by patient.Actor) bool { return by.UserID != nil && *by.UserID == 2 })).Return(nil)
+	repo.On("GetByID", mock.Anything, 10).Return(&patient.Patient{ID: 10, Name: "John Doe"}, nil)
+	p, err := svc.RestorePatient(context.Background(), testReceptionist, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", p.Name)
+
+	repo.On("Restore", mock.Anything, 12, mock.Anything).Return(patient.ErrPatientNotFound)
+	_, err = svc.RestorePatient(context.Background(), testReceptionist, 12)
+	assert.ErrorIs(t, err, patient.ErrPatientNotFound)
+}
+
+func TestPurgeExpired_DeletesFilesBeforeRecord(t *testing.T) {
+	repo := new(mockPatientRepository)
+	files := &fakeFileStore{failOn: "patient_docs/patient_8/scan.pdf"}
+	svc := patient.NewRetentionService(repo, files, 24*time.Hour)
+
+	repo.On("ListExpired", mock.Anything, 24*time.Hour, mock.Anything).Return([]int{7, 8}, nil)
+	repo.On("DocumentPublicIDs", mock.Anything, 7).Return([]string{"patient_docs/patient_7/xray.png"}, nil)
+	repo.On("DocumentPublicIDs", mock.Anything, 8).Return([]string{"patient_docs/patient_8/scan.pdf"}, nil)
+	repo.On("Purge", mock.Anything, 7).Return(nil)
+
+	n, err := svc.PurgeExpired(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"patient_docs/patient_7/xray.png"}, files.deleted)
+	// A patient whose files could not be deleted stays in the trash
+	repo.AssertNotCalled(t, "Purge", mock.Anything, 8)
+}
+
+func TestPurgeExpired_ContinuesPastFailure(t *testing.T) {
+	repo := new(mockPatientRepository)
+	files := &fakeFileStore{failOn: "patient_docs/patient_8/scan.pdf"}
+	svc := patient.NewRetentionService(repo, files, 24*time.Hour)
+
+	repo.On("ListExpired", mock.Anything, 24*time.Hour, mock.Anything).Return([]int{7, 8, 9, 10}, nil)
+	repo.On("DocumentPublicIDs", mock.Anything, 7).Return([]string{}, nil)
+	repo.On("DocumentPublicIDs", mock.Anything, 8).Return([]string{"patient_docs/patient_8/scan.pdf"}, nil)
+	repo.On("DocumentPublicIDs", mock.Anything, 9).Return([]string{"patient_docs/patient_9/xray.png"}, nil)
+	repo.On("DocumentPublicIDs", mock.Anything, 10).Return([]string{}, nil)
+	repo.On("Purge", mock.Anything, 7).Return(nil)
+	repo.On("Purge", mock.Anything, 9).Return(nil)
+	repo.On("Purge", mock.Anything, 10).Return(errors.New("connection reset"))
+
+	n, err := svc.PurgeExpired(context.Background())
+	assert.Equal(t, 2, n)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "purge patient 8: storage unavailable")
+	assert.Contains(t, err.Error(), "purge patient 10: connection reset")
+	// Patients after the failing one are still purged
+	assert.Equal(t, []string{"patient_docs/patient_9/xray.png"}, files.deleted)
+	repo.AssertCalled(t, "Purge", mock.Anything, 9)
+	repo.AssertNotCalled(t, "Purge", mock.Anything, 8)
+}
+
+func TestPurgeExpired_DisabledWithoutRetention(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewRetentionService(repo, &fakeFileStore{}, 0)
+
+	n, err := svc.PurgeExpired(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	repo.AssertNotCalled(t, "ListExpired", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	cases := []struct {
+		header   string
+		revision int
+		ok       bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"7"`, 7, true},
+		{` "abc", "4" `, 4, true},
+		{`W/"7"`, 0, false},
+		{`7`, 0, false},
+		{`"0"`, 0, false},
+	}
+	for _, tc := range cases {
+		revision, ok := patient.ParseIfMatch(tc.header)
+		assert.Equal(t, tc.revision, revision, tc.header)
+		assert.Equal(t, tc.ok, ok, tc.header)
+	}
+	assert.Equal(t, `"7"`, patient.ETag(7))
+}
+
+func TestUpdatePatientMedical_RevisionMismatch(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+
+	userID := testReceptionist.UserID
+	current := &patient.Patient{ID: 3, Name: "Jane Doe", Revision: 5}
+	repo.On("UpdateMedical", mock.Anything, 3, "Asthma", "", patient.Actor{UserID: &userID}, 4).Return(patient.ErrRevisionMismatch)
+	repo.On("GetByID", mock.Anything, 3).Return(current, nil)
+
+	_, err := svc.UpdatePatientMedical(context.Background(), testReceptionist, 3, patient.UpdatePatientMedicalRequest{Diagnosis: "Asthma", IfRevision: 4})
+	require.ErrorIs(t, err, patient.ErrRevisionMismatch)
+	var rev *patient.RevisionError
+	require.ErrorAs(t, err, &rev)
+	assert.Equal(t, current, rev.Current)
+}
+
+func TestUpdatePatient_IfMatch(t *testing.T) {
+	mockSvc := new(mockPatientService)
+	h := patient.NewHandler(mockSvc)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/patients/:id", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.UpdatePatient)
+
+	body := `{"name":"Jane Doe","date_of_birth":"1990-05-17","address":"1 Main St"}`
+	req := patient.UpdatePatientRequest{Name: "Jane Doe", DateOfBirth: "1990-05-17", Address: "1 Main St"}
+	diagnosis := "Asthma"
+	current := &patient.Patient{ID: 3, Name: "Jane Smith", Diagnosis: &diagnosis, Revision: 6}
+
+	stale := req
+	stale.IfRevision = 5
+	mockSvc.On("UpdatePatient", mock.Anything, mock.Anything, 3, stale).Return(nil, &patient.RevisionError{Current: current})
+	fresh := req
+	fresh.IfRevision = 6
+	mockSvc.On("UpdatePatient", mock.Anything, mock.Anything, 3, fresh).Return(&patient.Patient{ID: 3, Name: "Jane Doe", Revision: 7}, nil)
+
+	send := func(ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		httpReq, _ := http.NewRequest("PUT", "/patients/3", bytes.NewBufferString(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("If-Match", ifMatch)
+		r.ServeHTTP(w, httpReq)
+		return w
+	}
+
+	w := send(`"5"`)
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"6"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"name":"Jane Smith"`)
+	assert.NotContains(t, w.Body.String(), "Asthma")
+
+	w = send(`"6"`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"7"`, w.Header().Get("ETag"))
+
+	w = send(`W/"6"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	mockSvc.AssertNumberOfCalls(t, "UpdatePatient", 2)
+}
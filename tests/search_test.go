@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+func TestHighlight(t *testing.T) {
+	// Verbatim matches are marked as a whole, case-insensitively
+	assert.Equal(t, "<mark>John</mark> Doe", patient.Highlight("name", "John Doe", "john"))
+	// Otherwise every word resembling a query word is marked
+	assert.Equal(t, "<mark>John</mark> <mark>Doe</mark>", patient.Highlight("name", "John Doe", "Jon Doe"))
+	// Phone numbers match on digits regardless of formatting
+	assert.Equal(t, "(555) <mark>123-45</mark>67", patient.Highlight("phone_number", "(555) 123-4567", "12345"))
+	// Field values are escaped
+	assert.Equal(t, "&lt;b&gt; <mark>Main</mark> St", patient.Highlight("address", "<b> Main St", "main"))
+}
+
+func TestSearchPatients_HighlightsAndRestrictsPanel(t *testing.T) {
+	repo := new(mockPatientRepository)
+	svc := patient.NewService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+
+	phone := "555-0100"
+	repo.On("Search", mock.Anything, patient.SearchOptions{Query: "Jon Doe", PanelOf: 5}).Return([]patient.SearchResult{{
+		Patient: patient.Patient{ID: 1, Name: "John Doe", PhoneNumber: &phone},
+		Score:   0.6,
+		Matches: []patient.SearchMatch{{Field: "name", Type: patient.MatchPhonetic}},
+	}}, nil)
+
+	results, err := svc.SearchPatients(context.Background(), testDoctor, patient.SearchOptions{Query: "Jon Doe"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "<mark>John</mark> <mark>Doe</mark>", results[0].Matches[0].Highlight)
+
+	results, err = svc.SearchPatients(context.Background(), nil, patient.SearchOptions{Query: "Jon Doe"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	repo.AssertNumberOfCalls(t, "Search", 1)
+}
+
+func TestSearchPatients_Handler(t *testing.T) {
+	mockSvc := new(mockPatientService)
+	h := patient.NewHandler(mockSvc)
+	diagnosis := "Asthma"
+	mockSvc.On("SearchPatients", mock.Anything, mock.Anything, patient.SearchOptions{Query: "jane", Limit: 5}).Return([]patient.SearchResult{{
+		Patient: patient.Patient{ID: 2, Name: "Jane Smith", Diagnosis: &diagnosis},
+		Score:   0.85,
+		Matches: []patient.SearchMatch{{Field: "name", Type: patient.MatchExact, Highlight: "<mark>Jane</mark> Smith"}},
+	}}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/patients/search", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.SearchPatients)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/search?q=+jane+&limit=5", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"score":0.85`)
+	assert.Contains(t, w.Body.String(), `"field":"name"`)
+	assert.NotContains(t, w.Body.String(), "Asthma")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/patients/search?q=%20", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
+)
+
+type mockUserRepository struct {
+	mock.Mock
+}
+
+func (m *mockUserRepository) GetUserByUsername(ctx context.Context, username string) (*auth.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockUserRepository) GetUserByID(ctx context.Context, id int) (*auth.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockUserRepository) ListUsers(ctx context.Context) ([]auth.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]auth.User), args.Error(1)
+}
+func (m *mockUserRepository) CreateUser(ctx context.Context, user *auth.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+func (m *mockUserRepository) UpdateUser(ctx context.Context, user *auth.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+func (m *mockUserRepository) UpdateUserRole(ctx context.Context, id int, role string) error {
+	return m.Called(ctx, id, role).Error(0)
+}
+func (m *mockUserRepository) SetUserActive(ctx context.Context, id int, active bool) error {
+	return m.Called(ctx, id, active).Error(0)
+}
+func (m *mockUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string, mustChange bool) error {
+	return m.Called(ctx, id, passwordHash, mustChange).Error(0)
+}
+func (m *mockUserRepository) SetMustChangePassword(ctx context.Context, id int, mustChange bool) error {
+	return m.Called(ctx, id, mustChange).Error(0)
+}
+func (m *mockUserRepository) CreatePasswordResetToken(ctx context.Context, userID, createdBy int, tokenHash string, ttl time.Duration) (time.Time, error) {
+	args := m.Called(ctx, userID, createdBy, tokenHash, ttl)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (m *mockUserRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Int(0), args.Error(1)
+}
+
+type mockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *mockTokenRepository) CreateSession(ctx context.Context, s *auth.Session, ttl time.Duration) error {
+	return m.Called(ctx, s, ttl).Error(0)
+}
+func (m *mockTokenRepository) GetActiveSession(ctx context.Context, id string) (*auth.Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Session), args.Error(1)
+}
+func (m *mockTokenRepository) RevokeSession(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *mockTokenRepository) RevokeUserSessions(ctx context.Context, userID int) error {
+	return m.Called(ctx, userID).Error(0)
+}
+func (m *mockTokenRepository) RevokeOtherSessions(ctx context.Context, userID int, keepSessionID string) error {
+	return m.Called(ctx, userID, keepSessionID).Error(0)
+}
+func (m *mockTokenRepository) CreateRefreshToken(ctx context.Context, sessionID, tokenHash string, ttl time.Duration) error {
+	return m.Called(ctx, sessionID, tokenHash, ttl).Error(0)
+}
+func (m *mockTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.RefreshToken), args.Error(1)
+}
+func (m *mockTokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.RefreshToken), args.Error(1)
+}
+func (m *mockTokenRepository) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return m.Called(ctx, jti, ttl).Error(0)
+}
+func (m *mockTokenRepository) IsRevoked(ctx context.Context, jti, sessionID string) (bool, error) {
+	args := m.Called(ctx, jti, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockTokenRepository) CreateMFAChallenge(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error {
+	return m.Called(ctx, userID, tokenHash, ttl).Error(0)
+}
+func (m *mockTokenRepository) GetMFAChallenge(ctx context.Context, tokenHash string) (*auth.MFAChallenge, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.MFAChallenge), args.Error(1)
+}
+func (m *mockTokenRepository) RecordMFAChallengeFailure(ctx context.Context, id, maxAttempts int) error {
+	return m.Called(ctx, id, maxAttempts).Error(0)
+}
+func (m *mockTokenRepository) ConsumeMFAChallenge(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+var testKeys = newTestKeySet()
+
+var testAuthOptions = auth.Options{
+	Keys:            testKeys,
+	Issuer:          "medical-portal-test",
+	AccessTokenTTL:  15 * time.Minute,
+	RefreshTokenTTL: 24 * time.Hour,
+}
+
+func newTestKeySet() *jwtkeys.KeySet {
+	key, err := jwtkeys.GenerateEd25519()
+	if err != nil {
+		panic(err)
+	}
+	keys, err := jwtkeys.NewKeySet(key)
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+func newTestUser(t *testing.T, id int, role string, active bool) *auth.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	return &auth.User{ID: id, Username: role, PasswordHash: string(hash), Role: role, IsActive: active}
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	usedAt := time.Now()
+	tokens.On("ConsumeRefreshToken", mock.Anything, mock.Anything).Return(&auth.RefreshToken{ID: 1, SessionID: "sess-1", UsedAt: &usedAt}, nil)
+	tokens.On("GetActiveSession", mock.Anything, "sess-1").Return(&auth.Session{ID: "sess-1", UserID: 2}, nil)
+	users.On("GetUserByID", mock.Anything, 2).Return(newTestUser(t, 2, "doctor", true), nil)
+	tokens.On("CreateRefreshToken", mock.Anything, "sess-1", mock.Anything, testAuthOptions.RefreshTokenTTL).Return(nil)
+
+	pair, err := svc.Refresh(context.Background(), "old-refresh-token")
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.AccessToken)
+	assert.NotEqual(t, "old-refresh-token", pair.RefreshToken)
+	tokens.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+}
+
+func TestRefresh_ReusedTokenRevokesSession(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	usedAt := time.Now().Add(-time.Minute)
+	tokens.On("ConsumeRefreshToken", mock.Anything, mock.Anything).Return(nil, auth.ErrInvalidRefreshToken)
+	tokens.On("GetRefreshToken", mock.Anything, mock.Anything).Return(&auth.RefreshToken{ID: 1, SessionID: "sess-1", UsedAt: &usedAt}, nil)
+	tokens.On("RevokeSession", mock.Anything, "sess-1").Return(nil)
+
+	_, err := svc.Refresh(context.Background(), "stolen-refresh-token")
+
+	assert.ErrorIs(t, err, auth.ErrRefreshTokenReused)
+	tokens.AssertCalled(t, "RevokeSession", mock.Anything, "sess-1")
+}
+
+func TestRefresh_UnknownToken(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	tokens.On("ConsumeRefreshToken", mock.Anything, mock.Anything).Return(nil, auth.ErrInvalidRefreshToken)
+	tokens.On("GetRefreshToken", mock.Anything, mock.Anything).Return(nil, auth.ErrInvalidRefreshToken)
+
+	_, err := svc.Refresh(context.Background(), "made-up-token")
+
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken)
+}
+
+func TestAuthMiddleware_RejectsRevokedSession(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	users.On("GetUserByUsername", mock.Anything, "doctor").Return(newTestUser(t, 2, "doctor", true), nil)
+	tokens.On("CreateSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("CreateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("IsRevoked", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+
+	result, err := svc.Login(context.Background(), "doctor", "password123", auth.ClientInfo{})
+	require.NoError(t, err)
+
+	w := performAuthenticatedRequest(middleware.AuthMiddleware(svc), result.Tokens.AccessToken)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "revoked")
+}
+
+func TestAuthMiddleware_RejectsDeactivatedUser(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	users.On("GetUserByUsername", mock.Anything, "doctor").Return(newTestUser(t, 2, "doctor", true), nil).Once()
+	users.On("GetUserByID", mock.Anything, 2).Return(newTestUser(t, 2, "doctor", false), nil)
+	tokens.On("CreateSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("CreateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("IsRevoked", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	result, err := svc.Login(context.Background(), "doctor", "password123", auth.ClientInfo{})
+	require.NoError(t, err)
+
+	w := performAuthenticatedRequest(middleware.AuthMiddleware(svc), result.Tokens.AccessToken)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "deactivated")
+}
+
+func TestAuthMiddleware_AllowsActiveSession(t *testing.T) {
+	users := new(mockUserRepository)
+	tokens := new(mockTokenRepository)
+	svc := auth.NewService(users, tokens, testAuthOptions)
+
+	user := newTestUser(t, 2, "doctor", true)
+	users.On("GetUserByUsername", mock.Anything, "doctor").Return(user, nil)
+	users.On("GetUserByID", mock.Anything, 2).Return(user, nil)
+	tokens.On("CreateSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("CreateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tokens.On("IsRevoked", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	result, err := svc.Login(context.Background(), "doctor", "password123", auth.ClientInfo{})
+	require.NoError(t, err)
+
+	w := performAuthenticatedRequest(middleware.AuthMiddleware(svc), result.Tokens.AccessToken)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":2`)
+}
+
+func performAuthenticatedRequest(authMiddleware gin.HandlerFunc, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/protected", authMiddleware, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt(middleware.ContextKeyUserID)})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,327 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+	"github.com/kyash99252/Medical-Portal/pkg/jwtkeys"
+	"github.com/kyash99252/Medical-Portal/pkg/oidc"
+)
+
+type mockSSORepository struct {
+	mock.Mock
+}
+
+func (m *mockSSORepository) CreateLoginState(ctx context.Context, stateHash, nonce, codeVerifier string, ttl time.Duration) error {
+	return m.Called(ctx, stateHash, nonce, codeVerifier, ttl).Error(0)
+}
+func (m *mockSSORepository) ConsumeLoginState(ctx context.Context, stateHash string) (*auth.SSOLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.SSOLoginState), args.Error(1)
+}
+func (m *mockSSORepository) GetUserByIdentity(ctx context.Context, issuer, subject string) (*auth.User, error) {
+	args := m.Called(ctx, issuer, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockSSORepository) GetUserByEmail(ctx context.Context, email string) (*auth.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockSSORepository) LinkIdentity(ctx context.Context, userID int, issuer, subject string, email *string) error {
+	return m.Called(ctx, userID, issuer, subject, email).Error(0)
+}
+func (m *mockSSORepository) TouchIdentity(ctx context.Context, issuer, subject string) error {
+	return m.Called(ctx, issuer, subject).Error(0)
+}
+
+const (
+	testOIDCClientID    = "medical-portal"
+	testOIDCRedirectURL = "http://localhost:3000/sso/callback"
+)
+
+// mockOIDCProvider is a minimal OpenID provider. Every authorization request
+// is answered as if the user in claims had signed in.
+type mockOIDCProvider struct {
+	*httptest.Server
+	t      *testing.T
+	keys   *jwtkeys.KeySet
+	claims jwt.MapClaims
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := jwtkeys.NewKey(rsaKey)
+	require.NoError(t, err)
+	keys, err := jwtkeys.NewKeySet(key)
+	require.NoError(t, err)
+
+	p := &mockOIDCProvider{t: t, keys: keys, codes: make(map[string]url.Values)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidc.Metadata{
+			Issuer:                        p.URL,
+			AuthorizationEndpoint:         p.URL + "/authorize",
+			TokenEndpoint:                 p.URL + "/token",
+			JWKSURI:                       p.URL + "/jwks",
+			CodeChallengeMethodsSupported: []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(p.keys.JWKS())
+	})
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *mockOIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != testOIDCClientID || q.Get("redirect_uri") != testOIDCRedirectURL ||
+		q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := q.Get("state") + "-code"
+	p.mu.Lock()
+	p.codes[code] = q
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	require.NoError(p.t, r.ParseForm())
+	p.mu.Lock()
+	authz, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok || r.PostForm.Get("redirect_uri") != authz.Get("redirect_uri") ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != authz.Get("code_challenge") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.URL,
+		"aud":   testOIDCClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"nonce": authz.Get("nonce"),
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	idToken, err := p.keys.Sign(claims)
+	require.NoError(p.t, err)
+	json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+}
+
+// signIn drives the browser side of the flow: start the login, let the
+// provider authenticate, and return the code and state of its redirect
+func (p *mockOIDCProvider) signIn(t *testing.T, r http.Handler) url.Values {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/sso/login", nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return callback.Query()
+}
+
+func callback(r http.Handler, code, state string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(auth.SSOCallbackRequest{Code: code, State: state})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/sso/callback", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+type ssoFixture struct {
+	idp    *mockOIDCProvider
+	users  *mockUserRepository
+	sso    *mockSSORepository
+	tokens *mockAuthService
+	router *gin.Engine
+}
+
+func newSSOFixture(t *testing.T, opts auth.SSOOptions) *ssoFixture {
+	f := &ssoFixture{
+		idp:    newMockOIDCProvider(t),
+		users:  new(mockUserRepository),
+		sso:    new(mockSSORepository),
+		tokens: new(mockAuthService),
+	}
+
+	// Pending logins can be consumed once, as from the table
+	pending := make(map[string]bool)
+	f.sso.On("CreateLoginState", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		state := &auth.SSOLoginState{StateHash: args.String(1), Nonce: args.String(2), CodeVerifier: args.String(3)}
+		pending[state.StateHash] = true
+		f.sso.On("ConsumeLoginState", mock.Anything, state.StateHash).Run(func(mock.Arguments) {
+			delete(pending, state.StateHash)
+		}).Return(state, nil).Once()
+	}).Return(nil)
+	f.sso.On("ConsumeLoginState", mock.Anything, mock.MatchedBy(func(hash string) bool { return !pending[hash] })).
+		Return(nil, auth.ErrInvalidSSOState)
+
+	provider := oidc.NewProvider(oidc.Config{IssuerURL: f.idp.URL, ClientID: testOIDCClientID, ClientSecret: "secret", RedirectURL: testOIDCRedirectURL})
+	opts.RoleMappings, _ = auth.ParseRoleMappings("portal-admins=admin,doctors=doctor,front-desk=receptionist")
+	h := auth.NewSSOHandler(auth.NewSSOService(f.users, f.sso, f.tokens, provider, opts))
+
+	gin.SetMode(gin.TestMode)
+	f.router = gin.New()
+	f.router.GET("/sso/login", h.StartLogin)
+	f.router.POST("/sso/callback", h.Callback)
+	return f
+}
+
+func TestSSOLogin_ProvisionsUser(t *testing.T) {
+	f := newSSOFixture(t, auth.SSOOptions{AutoProvision: true, LinkByEmail: true})
+	f.idp.claims = jwt.MapClaims{
+		"sub": "idp-42", "preferred_username": "dr.house", "email": "house@example.org", "email_verified": true,
+		"groups": []string{"staff", "doctors"},
+	}
+
+	f.sso.On("GetUserByIdentity", mock.Anything, f.idp.URL, "idp-42").Return(nil, auth.ErrUserNotFound)
+	f.sso.On("GetUserByEmail", mock.Anything, "house@example.org").Return(nil, auth.ErrUserNotFound)
+	f.users.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+		return u.Username == "dr.house" && u.Role == auth.RoleDoctor && u.IsActive && !u.MustChangePassword
+	})).Run(func(args mock.Arguments) { args.Get(1).(*auth.User).ID = 9 }).Return(nil)
+	f.sso.On("LinkIdentity", mock.Anything, 9, f.idp.URL, "idp-42", mock.Anything).Return(nil)
+	f.tokens.On("StartSession", mock.Anything, mock.MatchedBy(func(u *auth.User) bool { return u.ID == 9 }), mock.Anything).
+		Return(&auth.TokenPair{AccessToken: "portal-access", RefreshToken: "portal-refresh", ExpiresIn: 900}, nil)
+
+	q := f.idp.signIn(t, f.router)
+	w := callback(f.router, q.Get("code"), q.Get("state"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp auth.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "portal-access", resp.Token)
+	assert.Equal(t, "Bearer", resp.TokenType)
+
+	// The state is single use
+	w = callback(f.router, q.Get("code"), q.Get("state"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSSOLogin_ProvisionedUserRoleFollowsProvider(t *testing.T) {
+	f := newSSOFixture(t, auth.SSOOptions{})
+	f.idp.claims = jwt.MapClaims{"sub": "idp-7", "groups": "front-desk"}
+
+	user := &auth.User{ID: 3, Username: "dr.house", PasswordHash: "!sso", Role: auth.RoleDoctor, IsActive: true}
+	f.sso.On("GetUserByIdentity", mock.Anything, f.idp.URL, "idp-7").Return(user, nil)
+	f.sso.On("TouchIdentity", mock.Anything, f.idp.URL, "idp-7").Return(nil)
+	f.users.On("UpdateUserRole", mock.Anything, 3, auth.RoleReceptionist).Return(nil)
+	f.tokens.On("LogoutAll", mock.Anything, 3).Return(nil)
+	f.tokens.On("StartSession", mock.Anything, mock.MatchedBy(func(u *auth.User) bool { return u.Role == auth.RoleReceptionist }), mock.Anything).
+		Return(&auth.TokenPair{AccessToken: "a", RefreshToken: "r"}, nil)
+
+	q := f.idp.signIn(t, f.router)
+	w := callback(f.router, q.Get("code"), q.Get("state"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	f.tokens.AssertCalled(t, "LogoutAll", mock.Anything, 3)
+}
+
+func TestSSOLogin_LinkedUserKeepsRole(t *testing.T) {
+	f := newSSOFixture(t, auth.SSOOptions{LinkByEmail: true})
+	f.idp.claims = jwt.MapClaims{"sub": "idp-8", "groups": "portal-admins", "email": "doctor@example.org", "email_verified": true}
+
+	user := newTestUser(t, 4, auth.RoleDoctor, true)
+	f.sso.On("GetUserByIdentity", mock.Anything, f.idp.URL, "idp-8").Return(nil, auth.ErrUserNotFound)
+	f.sso.On("GetUserByEmail", mock.Anything, "doctor@example.org").Return(user, nil)
+	f.sso.On("LinkIdentity", mock.Anything, 4, f.idp.URL, "idp-8", mock.Anything).Return(nil)
+	f.tokens.On("StartSession", mock.Anything, mock.MatchedBy(func(u *auth.User) bool { return u.Role == auth.RoleDoctor }), mock.Anything).
+		Return(&auth.TokenPair{AccessToken: "a", RefreshToken: "r"}, nil)
+
+	q := f.idp.signIn(t, f.router)
+	w := callback(f.router, q.Get("code"), q.Get("state"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	f.users.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSSOLogin_Rejections(t *testing.T) {
+	t.Run("unmapped groups", func(t *testing.T) {
+		f := newSSOFixture(t, auth.SSOOptions{AutoProvision: true})
+		f.idp.claims = jwt.MapClaims{"sub": "idp-1", "groups": []string{"visitors"}}
+
+		q := f.idp.signIn(t, f.router)
+		w := callback(f.router, q.Get("code"), q.Get("state"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		f.users.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown user without provisioning", func(t *testing.T) {
+		f := newSSOFixture(t, auth.SSOOptions{LinkByEmail: true})
+		// An unverified email is never used to link accounts
+		f.idp.claims = jwt.MapClaims{"sub": "idp-2", "groups": []string{"doctors"}, "email": "doctor@example.org", "email_verified": false}
+		f.sso.On("GetUserByIdentity", mock.Anything, mock.Anything, mock.Anything).Return(nil, auth.ErrUserNotFound)
+
+		q := f.idp.signIn(t, f.router)
+		w := callback(f.router, q.Get("code"), q.Get("state"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		f.sso.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("code without the login's verifier", func(t *testing.T) {
+		f := newSSOFixture(t, auth.SSOOptions{AutoProvision: true})
+		f.idp.claims = jwt.MapClaims{"sub": "idp-3", "groups": []string{"doctors"}}
+
+		// A code obtained for one login cannot be redeemed with another login's state
+		stolen := f.idp.signIn(t, f.router)
+		own := f.idp.signIn(t, f.router)
+		w := callback(f.router, stolen.Get("code"), own.Get("state"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token from another issuer", func(t *testing.T) {
+		f := newSSOFixture(t, auth.SSOOptions{AutoProvision: true})
+		f.idp.claims = jwt.MapClaims{"sub": "idp-4", "groups": []string{"doctors"}, "iss": "https://evil.example"}
+
+		q := f.idp.signIn(t, f.router)
+		w := callback(f.router, q.Get("code"), q.Get("state"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
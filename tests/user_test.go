@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/kyash99252/Medical-Portal/internal/auth"
+)
+
+type mockUserService struct {
+	mock.Mock
+}
+
+func (m *mockUserService) CreateUser(ctx context.Context, req auth.CreateUserRequest) (*auth.User, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockUserService) GetUser(ctx context.Context, id int) (*auth.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockUserService) ListUsers(ctx context.Context) ([]auth.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]auth.User), args.Error(1)
+}
+func (m *mockUserService) UpdateUser(ctx context.Context, id int, req auth.UpdateUserRequest) (*auth.User, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockUserService) UpdateUserRole(ctx context.Context, actorID, id int, req auth.UpdateUserRoleRequest) (*auth.User, error) {
+	args := m.Called(ctx, actorID, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.User), args.Error(1)
+}
+func (m *mockUserService) DeactivateUser(ctx context.Context, actorID, id int) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
+func (m *mockUserService) ActivateUser(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateUser_Success(t *testing.T) {
+	mockSvc := new(mockUserService)
+	h := auth.NewUserHandler(mockSvc)
+
+	req := auth.CreateUserRequest{Username: "dr.house", Password: "password123", Role: "doctor"}
+	mockSvc.On("CreateUser", mock.Anything, req).Return(&auth.User{ID: 3, Username: "dr.house", Role: "doctor", IsActive: true}, nil)
+
+	w := performUserRequest(h.CreateUser, "POST", "/users", "/users", req)
+
+	assert.Equal(t, 201, w.Code)
+	assert.Contains(t, w.Body.String(), "dr.house")
+	assert.NotContains(t, w.Body.String(), "password123")
+}
+
+func TestCreateUser_InvalidRole(t *testing.T) {
+	mockSvc := new(mockUserService)
+	h := auth.NewUserHandler(mockSvc)
+
+	req := auth.CreateUserRequest{Username: "nurse", Password: "password123", Role: "nurse"}
+	w := performUserRequest(h.CreateUser, "POST", "/users", "/users", req)
+
+	assert.Equal(t, 400, w.Code)
+	mockSvc.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func TestCreateUser_UsernameTaken(t *testing.T) {
+	mockSvc := new(mockUserService)
+	h := auth.NewUserHandler(mockSvc)
+
+	req := auth.CreateUserRequest{Username: "doctor", Password: "password123", Role: "doctor"}
+	mockSvc.On("CreateUser", mock.Anything, req).Return(nil, auth.ErrUsernameTaken)
+
+	w := performUserRequest(h.CreateUser, "POST", "/users", "/users", req)
+
+	assert.Equal(t, 409, w.Code)
+}
+
+func TestDeactivateUser_NotFound(t *testing.T) {
+	mockSvc := new(mockUserService)
+	h := auth.NewUserHandler(mockSvc)
+
+	mockSvc.On("DeactivateUser", mock.Anything, 0, 42).Return(auth.ErrUserNotFound)
+
+	w := performUserRequest(h.DeactivateUser, "POST", "/users/:id/deactivate", "/users/42/deactivate", nil)
+
+	assert.Equal(t, 404, w.Code)
+}
+
+func performUserRequest(handler gin.HandlerFunc, method, route, url string, body interface{}) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(method, route, handler)
+
+	reqBody := &bytes.Buffer{}
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
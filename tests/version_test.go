@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kyash99252/Medical-Portal/internal/authz"
+	"github.com/kyash99252/Medical-Portal/internal/careteam"
+	"github.com/kyash99252/Medical-Portal/internal/middleware"
+	"github.com/kyash99252/Medical-Portal/internal/patient"
+)
+
+type mockVersionRepository struct {
+	mock.Mock
+}
+
+func (m *mockVersionRepository) ListVersions(ctx context.Context, patientID int) ([]patient.Version, error) {
+	args := m.Called(ctx, patientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]patient.Version), args.Error(1)
+}
+func (m *mockVersionRepository) GetVersion(ctx context.Context, patientID, version int) (*patient.Version, error) {
+	args := m.Called(ctx, patientID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Version), args.Error(1)
+}
+func (m *mockVersionRepository) GetAsOf(ctx context.Context, patientID int, at time.Time) (*patient.Patient, error) {
+	args := m.Called(ctx, patientID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*patient.Patient), args.Error(1)
+}
+
+var testClinician = &middleware.Identity{
+	UserID:      5,
+	Role:        "doctor",
+	Permissions: []string{authz.PatientRead, authz.PatientAccessAll, authz.PatientClinicalRead},
+}
+
+func newVersionService(repo *mockVersionRepository) patient.VersionService {
+	return patient.NewVersionService(repo, careteam.NewService(new(mockCareTeamRepository), new(mockEmergencyRepository)))
+}
+
+func TestDiffVersions(t *testing.T) {
+	repo := new(mockVersionRepository)
+	svc := newVersionService(repo)
+	ctx := context.Background()
+
+	repo.On("GetVersion", mock.Anything, 7, 1).Return(&patient.Version{Version: 1, Snapshot: json.RawMessage(
+		`{"id": 7, "name": "Jane Smith", "diagnosis": null, "updated_at": "2026-01-02T10:00:00", "updated_by": 2}`)}, nil)
+	repo.On("GetVersion", mock.Anything, 7, 3).Return(&patient.Version{Version: 3, Snapshot: json.RawMessage(
+		`{"id": 7, "name": "Jane Doe", "diagnosis": "Asthma", "updated_at": "2026-02-02T10:00:00", "updated_by": 5, "email": "jane@example.com"}`)}, nil)
+	repo.On("GetVersion", mock.Anything, 7, 9).Return(nil, patient.ErrVersionNotFound)
+
+	diff, err := svc.DiffVersions(ctx, testClinician, 7, 1, 3)
+	require.NoError(t, err)
+	assert.Len(t, diff.Changes, 3)
+	assert.JSONEq(t, `{"old":"Jane Smith","new":"Jane Doe"}`, mustJSON(t, diff.Changes["name"]))
+	assert.JSONEq(t, `{"old":null,"new":"Asthma"}`, mustJSON(t, diff.Changes["diagnosis"]))
+	assert.JSONEq(t, `{"old":null,"new":"jane@example.com"}`, mustJSON(t, diff.Changes["email"]))
+
+	// Receptionists never see clinical fields, not even in history
+	diff, err = svc.DiffVersions(ctx, testReceptionist, 7, 1, 3)
+	require.NoError(t, err)
+	assert.NotContains(t, diff.Changes, "diagnosis")
+	assert.Contains(t, diff.Changes, "name")
+
+	_, err = svc.DiffVersions(ctx, testReceptionist, 7, 1, 9)
+	assert.ErrorIs(t, err, patient.ErrVersionNotFound)
+}
+
+func TestListVersions_HidesClinicalChanges(t *testing.T) {
+	repo := new(mockVersionRepository)
+	svc := newVersionService(repo)
+
+	changedBy := 5
+	history := func() []patient.Version {
+		return []patient.Version{{
+			PatientID: 7, Version: 2, Operation: "update", ChangedBy: &changedBy,
+			Changes: patient.Changes{
+				"diagnosis": {Old: json.RawMessage(`null`), New: json.RawMessage(`"Asthma"`)},
+				"notes":     {Old: json.RawMessage(`null`), New: json.RawMessage(`"Inhaler"`)},
+			},
+		}, {
+			PatientID: 7, Version: 1, Operation: "create",
+			Changes: patient.Changes{"name": {Old: json.RawMessage(`null`), New: json.RawMessage(`"Jane"`)}},
+		}}
+	}
+	repo.On("ListVersions", mock.Anything, 7).Return(history(), nil).Once()
+	repo.On("ListVersions", mock.Anything, 7).Return(history(), nil).Once()
+
+	// The only change of version 2 is clinical, so the version is left out
+	versions, err := svc.ListVersions(context.Background(), testReceptionist, 7)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Contains(t, versions[0].Changes, "name")
+
+	versions, err = svc.ListVersions(context.Background(), testClinician, 7)
+	require.NoError(t, err)
+	assert.Contains(t, versions[0].Changes, "diagnosis")
+}
+
+func TestListVersions_LeavesOutDiagnosisOnlyVersions(t *testing.T) {
+	repo := new(mockVersionRepository)
+	svc := newVersionService(repo)
+
+	repo.On("ListVersions", mock.Anything, 7).Return([]patient.Version{{
+		PatientID: 7, Version: 4, Operation: "update",
+		Changes: patient.Changes{
+			"address":   {Old: json.RawMessage(`"1 Old Rd"`), New: json.RawMessage(`"2 New Rd"`)},
+			"diagnosis": {Old: json.RawMessage(`"Asthma"`), New: json.RawMessage(`"COPD"`)},
+		},
+	}, {
+		PatientID: 7, Version: 3, Operation: "update",
+		Changes: patient.Changes{"diagnosis": {Old: json.RawMessage(`null`), New: json.RawMessage(`"Asthma"`)}},
+	}, {
+		PatientID: 7, Version: 2, Operation: "update",
+		Changes: patient.Changes{"name": {Old: json.RawMessage(`"Jane"`), New: json.RawMessage(`"Jane Doe"`)}},
+	}}, nil)
+
+	versions, err := svc.ListVersions(context.Background(), testReceptionist, 7)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 4, versions[0].Version)
+	assert.Contains(t, versions[0].Changes, "address")
+	assert.NotContains(t, versions[0].Changes, "diagnosis")
+	assert.Equal(t, 2, versions[1].Version)
+}
+
+func TestGetPatientAsOfHandler(t *testing.T) {
+	repo := new(mockVersionRepository)
+	h := patient.NewVersionHandler(newVersionService(repo))
+
+	diagnosis := "Asthma"
+	at := time.Date(2026, 1, 31, 17, 0, 0, 0, time.UTC)
+	repo.On("GetAsOf", mock.Anything, 7, mock.MatchedBy(at.Equal)).Return(&patient.Patient{ID: 7, Name: "Jane Smith", Diagnosis: &diagnosis}, nil)
+	repo.On("GetAsOf", mock.Anything, 7, mock.Anything).Return(nil, patient.ErrVersionNotFound)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/patients/:id/as-of", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyIdentity, testReceptionist)
+	}, h.GetPatientAsOf)
+
+	cases := []struct {
+		query string
+		code  int
+	}{
+		{"at=2026-01-31T17:00:00Z", http.StatusOK},
+		{"at=2019-01-01T00:00:00Z", http.StatusNotFound},
+		{"at=yesterday", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/patients/7/as-of?"+tc.query, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.query)
+		if tc.code == http.StatusOK {
+			assert.Contains(t, w.Body.String(), "Jane Smith")
+			assert.NotContains(t, w.Body.String(), "Asthma")
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}